@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/report"
+	"github.com/gofrs/uuid"
+)
+
+func runLs(c *cli, args []string) error {
+	folders := c.allFolders()
+	if c.orgID != uuid.Nil {
+		folders = c.orgFolders(c.orgID)
+	}
+	return c.printFolders(folders)
+}
+
+func runTree(c *cli, args []string) error {
+	folders := c.allFolders()
+	if c.orgID != uuid.Nil {
+		folders = c.orgFolders(c.orgID)
+	}
+
+	if c.output == "json" {
+		data, err := folder.MarshalTreeJson(folders)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, string(data))
+		return nil
+	}
+	if c.output == "dot" || c.output == "mermaid" {
+		var opts folder.GraphOptions
+		if c.highlight != "" {
+			src, dst, ok := strings.Cut(c.highlight, ":")
+			if !ok || c.orgID == uuid.Nil {
+				return &usageError{"-highlight takes src:dst and requires --org"}
+			}
+			opts.Highlight = &folder.MoveHighlight{OrgID: c.orgID, Source: src, Destination: dst}
+		}
+		if c.output == "dot" {
+			return folder.WriteDOT(c.stdout, folders, opts)
+		}
+		return folder.WriteMermaid(c.stdout, folders, opts)
+	}
+
+	var orgs []uuid.UUID
+	byOrg := make(map[uuid.UUID][]*folder.Folder)
+	for _, f := range folders {
+		if byOrg[f.OrgId] == nil {
+			orgs = append(orgs, f.OrgId)
+		}
+		byOrg[f.OrgId] = append(byOrg[f.OrgId], f)
+	}
+	for _, orgID := range orgs {
+		fmt.Fprintf(c.stdout, "org %s\n", orgID)
+		if err := folder.RenderTree(c.stdout, byOrg[orgID], c.render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runChildren(c *cli, args []string) error {
+	children, err := c.driver.GetAllChildFoldersByPath(c.ctx, c.orgID, args[0])
+	if err != nil {
+		return err
+	}
+	return c.printFolders(children)
+}
+
+func runMove(c *cli, args []string) error {
+	path, dst := args[0], args[1]
+	folders, err := c.driver.MoveFolderByPath(c.ctx, c.orgID, path, dst)
+	if err != nil {
+		return err
+	}
+	return c.printFolders([]*folder.Folder{folder.FindByPath(folders, c.orgID, folder.MovedPath(path, dst))})
+}
+
+func runMkdir(c *cli, args []string) error {
+	path := args[0]
+	parent, name := "", path
+	if idx := strings.LastIndex(path, "."); idx >= 0 {
+		parent, name = path[:idx], path[idx+1:]
+	}
+
+	created, err := c.driver.CreateFolder(c.ctx, c.orgID, name, parent)
+	if err != nil {
+		return err
+	}
+	return c.printFolders([]*folder.Folder{created})
+}
+
+func runRm(c *cli, args []string) error {
+	deleted, err := c.driver.DeleteFolder(c.ctx, c.orgID, args[0])
+	if err != nil {
+		return err
+	}
+	return c.printFolders(deleted)
+}
+
+func runTrash(c *cli, args []string) error {
+	items, err := c.trashDriver().Trash(c.ctx, c.orgID)
+	if err != nil {
+		return err
+	}
+	return c.printTrash(items)
+}
+
+func runRestore(c *cli, args []string) error {
+	id, err := uuid.FromString(args[0])
+	if err != nil {
+		return &usageError{fmt.Sprintf("invalid trash item ID '%s'", args[0])}
+	}
+	restored, err := c.trashDriver().RestoreFolder(c.ctx, c.orgID, id, c.restoreParent)
+	if err != nil {
+		return err
+	}
+	return c.printFolders(restored)
+}
+
+// runPurge prints the trash items purged when the dataset was loaded.
+func runPurge(c *cli, args []string) error {
+	return c.printTrash(c.purged)
+}
+
+func runRename(c *cli, args []string) error {
+	renamed, err := c.driver.RenameFolder(c.ctx, c.orgID, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return c.printFolders([]*folder.Folder{renamed})
+}
+
+func runCopy(c *cli, args []string) error {
+	copier, ok := c.driver.(interface {
+		CopyFolder(ctx context.Context, orgID uuid.UUID, src string, dst string, opts folder.CopyOptions) ([]*folder.Folder, error)
+	})
+	if !ok {
+		return errors.New("the driver cannot copy folders")
+	}
+	copies, err := copier.CopyFolder(c.ctx, c.orgID, args[0], args[1], folder.CopyOptions{})
+	if err != nil {
+		return err
+	}
+	return c.printFolders(copies)
+}
+
+func runTransfer(c *cli, args []string) error {
+	transferrer, ok := c.driver.(interface {
+		TransferFolder(ctx context.Context, srcOrgID uuid.UUID, path string, dstOrgID uuid.UUID, dstPath string) ([]*folder.Folder, error)
+	})
+	if !ok {
+		return errors.New("the driver cannot transfer folders")
+	}
+	dstOrgID, err := uuid.FromString(args[1])
+	if err != nil {
+		return &usageError{fmt.Sprintf("invalid dst-org '%s'", args[1])}
+	}
+	transferred, err := transferrer.TransferFolder(c.ctx, c.orgID, args[0], dstOrgID, args[2])
+	if err != nil {
+		return err
+	}
+	return c.printFolders(transferred)
+}
+
+func runGenerate(c *cli, args []string) error {
+	shape, err := folder.ParseShape(c.shape)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	c.generator.Shape = shape
+	if !c.seedSet {
+		c.generator.Seed = rand.Int63()
+	}
+	// The flags set maximums; lower the defaults' minimums to match.
+	for _, d := range []*folder.Distribution{&c.generator.RootsPerOrg, &c.generator.Branching, &c.generator.Depth} {
+		if d.Min > d.Max {
+			d.Min = d.Max
+		}
+	}
+
+	folders, err := folder.GenerateDataWithConfig(c.generator)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	data, err := folder.MarshalDataset(folders)
+	if err != nil {
+		return err
+	}
+	if c.file == "" {
+		fmt.Fprintln(c.stdout, string(data))
+		return nil
+	}
+	return os.WriteFile(c.file, data, 0o644)
+}
+
+func runValidate(c *cli, args []string) error {
+	orgs := make(map[uuid.UUID]bool)
+	for _, f := range c.folders {
+		orgs[f.OrgId] = true
+	}
+	fmt.Fprintf(c.stdout, "%s: %d folders in %d organizations\n", c.file, len(c.folders), len(orgs))
+	return nil
+}
+
+// move is a folder found at different paths in the two datasets of a diff.
+type move struct {
+	OrgId uuid.UUID `json:"org_id"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+}
+
+// diff is the difference between two datasets.
+type diff struct {
+	Added   []*folder.Folder `json:"added"`
+	Removed []*folder.Folder `json:"removed"`
+	Moved   []move           `json:"moved"`
+}
+
+func runRepair(c *cli, args []string) error {
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		return err
+	}
+
+	// The dataset is decoded without the validation of LoadDataset, which would reject it.
+	var dataset folder.Dataset
+	data, err = folder.UpgradeDataset(data)
+	if err == nil {
+		err = json.Unmarshal(data, &dataset)
+	}
+	if err != nil {
+		return &invalidDataError{file: c.file, err: err}
+	}
+
+	repaired, issues, err := folder.Repair(dataset.Folders, folder.RepairOptions{Policy: folder.TrustPaths, LostAndFound: c.lostAndFound})
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(c.stdout, issue)
+	}
+	fmt.Fprintf(c.stdout, "%d issues\n", len(issues))
+
+	if c.dryRun || len(issues) == 0 {
+		return nil
+	}
+	c.folders = repaired
+	c.driver = folder.NewDriver(repaired)
+	return c.save()
+}
+
+func runReport(c *cli, args []string) error {
+	return report.Write(c.ctx, c.stdout, c.driver, c.orgID, report.Options{Title: c.title})
+}
+
+func runAudit(c *cli, args []string) error {
+	file, err := os.Open(c.auditFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries, err := folder.ReadAuditLog(file, c.auditQuery)
+	if err != nil {
+		return err
+	}
+
+	if c.output == "json" {
+		if entries == nil {
+			entries = []folder.AuditEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTOR\tORG\tOP\tOLD PATH\tNEW PATH\tDESCENDANTS")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", e.Time.Format(time.RFC3339), e.Actor, e.OrgID, e.Op, e.OldPath, e.NewPath, e.Descendants)
+	}
+	return w.Flush()
+}
+
+func runDiff(c *cli, args []string) error {
+	other, err := loadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	d := diffFolders(c.folders, other)
+	if c.output == "json" {
+		data, err := json.MarshalIndent(d, "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, string(data))
+	} else {
+		for _, f := range d.Removed {
+			fmt.Fprintf(c.stdout, "- %s %s\n", f.OrgId, f.Paths)
+		}
+		for _, f := range d.Added {
+			fmt.Fprintf(c.stdout, "+ %s %s\n", f.OrgId, f.Paths)
+		}
+		for _, m := range d.Moved {
+			fmt.Fprintf(c.stdout, "~ %s %s -> %s\n", m.OrgId, m.From, m.To)
+		}
+	}
+
+	if c.exitCode && len(d.Added)+len(d.Removed)+len(d.Moved) > 0 {
+		return errDiffers
+	}
+	return nil
+}
+
+// errDiffers is returned by diff --exit-code when the datasets differ; it is not printed.
+var errDiffers = errors.New("datasets differ")
+
+// diffFolders compares two datasets by path. A folder whose name is unique within its
+// organization in both datasets, but whose path changed, is reported as moved.
+func diffFolders(before, after []*folder.Folder) diff {
+	key := func(f *folder.Folder) string { return f.OrgId.String() + "/" + f.Paths }
+	index := func(folders []*folder.Folder) (map[string]*folder.Folder, map[string]int) {
+		byPath := make(map[string]*folder.Folder)
+		names := make(map[string]int)
+		for _, f := range folders {
+			byPath[key(f)] = f
+			names[f.OrgId.String()+"/"+f.Name]++
+		}
+		return byPath, names
+	}
+	beforePaths, beforeNames := index(before)
+	afterPaths, afterNames := index(after)
+
+	d := diff{Added: []*folder.Folder{}, Removed: []*folder.Folder{}, Moved: []move{}}
+	// Removed folders by path, and the uniquely named ones by name to find where they moved.
+	removed := make(map[string]*folder.Folder)
+	removedNames := make(map[string]*folder.Folder)
+	for _, f := range before {
+		if _, exists := afterPaths[key(f)]; !exists {
+			removed[key(f)] = f
+			if name := f.OrgId.String() + "/" + f.Name; beforeNames[name] == 1 {
+				removedNames[name] = f
+			}
+		}
+	}
+
+	for _, f := range after {
+		if _, exists := beforePaths[key(f)]; exists {
+			continue
+		}
+		name := f.OrgId.String() + "/" + f.Name
+		if old, exists := removedNames[name]; exists && afterNames[name] == 1 {
+			d.Moved = append(d.Moved, move{OrgId: f.OrgId, From: old.Paths, To: f.Paths})
+			delete(removed, key(old))
+			continue
+		}
+		d.Added = append(d.Added, f)
+	}
+
+	for _, f := range before {
+		if _, exists := removed[key(f)]; exists {
+			d.Removed = append(d.Removed, f)
+		}
+	}
+	sort.SliceStable(d.Moved, func(i, j int) bool { return d.Moved[i].From < d.Moved[j].From })
+
+	return d
+}
+
+// printTrash writes trash items as a table or a JSON list, depending on -o.
+func (c *cli) printTrash(items []*folder.TrashItem) error {
+	if c.output == "json" {
+		data, err := folder.MarshalTrash(items)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPATH\tDELETED\tBY\tFOLDERS")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", item.ID, item.Path, item.DeletedAt.Format(time.RFC3339), item.DeletedBy, len(item.Folders))
+	}
+	return w.Flush()
+}
+
+// printFolders writes folders as a table or a JSON list, depending on -o.
+func (c *cli) printFolders(folders []*folder.Folder) error {
+	if c.output == "json" {
+		if folders == nil {
+			folders = []*folder.Folder{}
+		}
+		fmt.Fprintln(c.stdout, string(folder.MarshalJson(folders)))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tORG\tPATH")
+	for _, f := range folders {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, f.OrgId, f.Paths)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,501 @@
+// Command folderctl inspects and edits folder dataset files.
+//
+// Usage:
+//
+//	folderctl <command> -f <file> [--org <orgID>] [-o table|json] [args]
+//
+// Commands:
+//
+//	ls                               list folders
+//	tree                             print the folder tree, or export it with -o dot|mermaid
+//	children <path>                  list all descendants of a folder
+//	move <path> <dst>                move a folder under dst
+//	mkdir <path>                     create a folder
+//	copy <path> <dst>                copy a folder and its subtree under dst
+//	transfer <path> <dst-org> <dst>  transfer a folder and its subtree under dst of another organization
+//	rm <path>                        move a folder and its subtree to the trash
+//	trash                            list the trash of an organization
+//	restore <id>                     restore a folder from the trash, under -parent or at its original path
+//	purge                            drop the trash items older than -trash-retention
+//	rename <path> <name>             rename a folder
+//	generate                         write generated sample data, reproducible with -seed
+//	validate                         check a dataset file
+//	diff <other-file>                compare the dataset with another one
+//	repair                           fix the inconsistencies of a dataset file that fails to load
+//	shell                            navigate and edit an organization interactively
+//	report                           write an HTML report of an organization's folders
+//	audit                            list the entries of the audit log given by -audit
+//
+// Commands that edit the dataset append an entry per change to the JSON lines file given by -audit.
+// The trash is kept next to the dataset file, in folders.trash.json for folders.json. Commands that edit
+// the dataset first purge the trash items deleted longer than -trash-retention ago.
+//
+// Exit codes:
+//
+//	0  success
+//	1  unexpected error, or differences found by diff --exit-code
+//	2  invalid usage
+//	3  folder not found
+//	4  operation rejected: invalid move, existing folder or invalid name
+//	5  invalid dataset file
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitRejected    = 4
+	exitInvalidData = 5
+)
+
+// command is a folderctl subcommand.
+type command struct {
+	name    string
+	args    []string // names of the positional arguments
+	summary string
+	// needsOrg commands operate within the organization given by --org.
+	needsOrg bool
+	// mutates commands write the dataset back to its file.
+	mutates bool
+	run     func(c *cli, args []string) error
+}
+
+var commands = []*command{
+	{name: "ls", summary: "list folders", run: runLs},
+	{name: "tree", summary: "print the folder tree", run: runTree},
+	{name: "children", args: []string{"path"}, summary: "list all descendants of a folder", needsOrg: true, run: runChildren},
+	{name: "move", args: []string{"path", "dst"}, summary: "move a folder under dst", needsOrg: true, mutates: true, run: runMove},
+	{name: "mkdir", args: []string{"path"}, summary: "create a folder", needsOrg: true, mutates: true, run: runMkdir},
+	{name: "rm", args: []string{"path"}, summary: "move a folder and its subtree to the trash", needsOrg: true, mutates: true, run: runRm},
+	{name: "trash", summary: "list the trash of an organization", needsOrg: true, run: runTrash},
+	{name: "restore", args: []string{"id"}, summary: "restore a folder from the trash, under -parent or at its original path", needsOrg: true, mutates: true, run: runRestore},
+	{name: "purge", summary: "drop the trash items older than -trash-retention", mutates: true, run: runPurge},
+	{name: "copy", args: []string{"path", "dst"}, summary: "copy a folder and its subtree under dst", needsOrg: true, mutates: true, run: runCopy},
+	{name: "transfer", args: []string{"path", "dst-org", "dst"}, summary: "transfer a folder and its subtree under dst of another organization", needsOrg: true, mutates: true, run: runTransfer},
+	{name: "rename", args: []string{"path", "name"}, summary: "rename a folder", needsOrg: true, mutates: true, run: runRename},
+	{name: "generate", summary: "write generated sample data to -f, or stdout", run: runGenerate},
+	{name: "validate", summary: "check a dataset file", run: runValidate},
+	{name: "diff", args: []string{"other-file"}, summary: "compare the dataset with another one", run: runDiff},
+	{name: "repair", summary: "fix the inconsistencies of a dataset file that fails to load", run: runRepair},
+	{name: "shell", summary: "navigate and edit an organization interactively", needsOrg: true, run: runShell},
+	{name: "report", summary: "write an HTML report of an organization's folders", needsOrg: true, run: runReport},
+	{name: "audit", summary: "list the entries of the audit log given by -audit", run: runAudit},
+}
+
+// cli is the state shared by the commands of a single invocation.
+type cli struct {
+	// ctx carries no principal: folderctl edits local files, so every organization is accessible.
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	file       string
+	output     string
+	orgID      uuid.UUID
+	exitCode   bool
+	saveOnExit bool
+	render     folder.RenderOptions
+	highlight  string
+	title      string
+	generator  folder.GeneratorConfig
+	shape      string
+	seedSet    bool
+
+	lostAndFound string
+	dryRun       bool
+
+	auditFile  string
+	auditSink  *folder.JSONLAuditSink
+	auditQuery folder.AuditQuery
+
+	trashRetention time.Duration
+	restoreParent  string
+	// purged are the trash items purged when the dataset was loaded.
+	purged []*folder.TrashItem
+
+	// driverOpts are the options driver was created with, to create it again.
+	driverOpts []folder.Option
+
+	folders []*folder.Folder
+	driver  folder.IDriver
+}
+
+// usageError is an error in the command line.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes a folderctl command line and returns its exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == args[0] {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "folderctl: unknown command '%s'\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	c := &cli{ctx: context.Background(), stdin: stdin, stdout: stdout, stderr: stderr}
+	var org string
+	var verbose bool
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&c.file, "f", "", "dataset `file`")
+	fs.StringVar(&org, "org", "", "`orgID` to operate on")
+	fs.StringVar(&c.output, "o", "table", "output format: table or json, or dot or mermaid for tree")
+	fs.BoolVar(&verbose, "v", false, "log driver messages to stderr")
+	if cmd.name == "diff" {
+		fs.BoolVar(&c.exitCode, "exit-code", false, "exit with 1 if the datasets differ")
+	}
+	if cmd.name == "tree" || cmd.name == "shell" {
+		fs.IntVar(&c.render.MaxDepth, "depth", 0, "number of tree levels to print, 0 for all")
+		fs.BoolVar(&c.render.Counts, "counts", false, "print the number of descendants of every folder")
+		fs.BoolVar(&c.render.Color, "color", false, "colour the tree output")
+		fs.BoolVar(&c.render.ASCII, "ascii", false, "draw the tree with ASCII characters")
+	}
+	if cmd.name == "tree" {
+		fs.StringVar(&c.highlight, "highlight", "", "highlight the move `src:dst` in dot or mermaid output, requires --org")
+	}
+	if cmd.name == "generate" {
+		c.generator = folder.DefaultGeneratorConfig()
+		fs.Int64Var(&c.generator.Seed, "seed", 0, "seed of the generator, random if not set")
+		fs.IntVar(&c.generator.Orgs, "orgs", c.generator.Orgs, "number of organizations")
+		fs.IntVar(&c.generator.RootsPerOrg.Max, "roots", c.generator.RootsPerOrg.Max, "maximum number of root folders of each organization")
+		fs.IntVar(&c.generator.Depth.Max, "depth", c.generator.Depth.Max, "maximum number of levels of each tree")
+		fs.IntVar(&c.generator.Branching.Max, "branching", c.generator.Branching.Max, "maximum number of children of each folder")
+		fs.StringVar(&c.shape, "shape", "balanced", "shape of the trees: balanced, skewed, wide or deep")
+		fs.Float64Var(&c.generator.CollisionRate, "collisions", 0, "probability that a folder reuses a name of its organization")
+	}
+	if cmd.name == "repair" {
+		fs.StringVar(&c.lostAndFound, "lost-and-found", folder.DefaultLostAndFound, "`name` of the root folder orphans are moved under, empty to make them roots")
+		fs.BoolVar(&c.dryRun, "dry-run", false, "report the issues without writing the file")
+	}
+	if cmd.name == "report" {
+		fs.StringVar(&c.title, "title", "", "title of the report")
+	}
+	if cmd.name == "shell" {
+		fs.BoolVar(&c.saveOnExit, "save", false, "save the dataset file on exit if it changed")
+	}
+	if cmd.mutates || cmd.name == "shell" || cmd.name == "audit" {
+		fs.StringVar(&c.auditFile, "audit", "", "JSON lines audit log `file` of the changes")
+	}
+	if cmd.mutates {
+		fs.DurationVar(&c.trashRetention, "trash-retention", 30*24*time.Hour, "how long deleted folders are kept in the trash, 0 keeps them until restored")
+	}
+	if cmd.name == "restore" {
+		fs.StringVar(&c.restoreParent, "parent", "", "restore under the folder at `path` instead of the original parent")
+	}
+	var since, until string
+	if cmd.name == "audit" {
+		fs.StringVar(&c.auditQuery.Path, "path", "", "list the entries of the folder at `path` and its descendants")
+		fs.StringVar(&since, "since", "", "list the entries from an RFC 3339 `time`")
+		fs.StringVar(&until, "until", "", "list the entries before an RFC 3339 `time`")
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: folderctl %s [flags] %s\n", cmd.name, argNames(cmd))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	fs.Visit(func(f *flag.Flag) {
+		c.seedSet = c.seedSet || f.Name == "seed"
+	})
+
+	if verbose {
+		log.SetOutput(stderr)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+
+	err := c.setup(cmd, org, fs.Args())
+	if err == nil && cmd.name == "audit" {
+		err = c.parseAuditQuery(since, until)
+	}
+	if c.auditSink != nil {
+		defer c.auditSink.Close()
+	}
+	if err == nil {
+		err = cmd.run(c, fs.Args())
+	}
+	if err == nil && cmd.mutates {
+		err = c.save()
+	}
+	if errors.Is(err, errDiffers) {
+		return exitError
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "folderctl: %v\n", err)
+		return exitCodeOf(err)
+	}
+	return exitOK
+}
+
+// setup validates the command line and loads the dataset.
+func (c *cli) setup(cmd *command, org string, args []string) error {
+	if len(args) != len(cmd.args) {
+		return &usageError{fmt.Sprintf("usage: folderctl %s [flags] %s", cmd.name, argNames(cmd))}
+	}
+	switch {
+	case c.output == "table" || c.output == "json":
+	case cmd.name == "tree" && (c.output == "dot" || c.output == "mermaid"):
+	default:
+		return &usageError{fmt.Sprintf("invalid output format '%s'", c.output)}
+	}
+	if org != "" {
+		orgID, err := uuid.FromString(org)
+		if err != nil {
+			return &usageError{fmt.Sprintf("invalid org ID '%s'", org)}
+		}
+		c.orgID = orgID
+	} else if cmd.needsOrg {
+		return &usageError{fmt.Sprintf("%s requires --org", cmd.name)}
+	}
+
+	if cmd.name == "generate" {
+		return nil
+	}
+	if cmd.name == "audit" {
+		if c.auditFile == "" {
+			return &usageError{"audit requires an audit log file, given with -audit"}
+		}
+		return nil
+	}
+	if c.file == "" {
+		return &usageError{fmt.Sprintf("%s requires a dataset file, given with -f", cmd.name)}
+	}
+
+	if cmd.name == "repair" {
+		return nil
+	}
+
+	folders, err := loadFile(c.file)
+	if err != nil {
+		return err
+	}
+	c.folders = folders
+	trash, err := loadTrashFile(folder.TrashFile(c.file))
+	if err != nil {
+		return err
+	}
+
+	if c.auditFile != "" {
+		c.auditSink, err = folder.OpenAuditFile(c.auditFile)
+		if err != nil {
+			return err
+		}
+		c.driverOpts = append(c.driverOpts, folder.WithAuditSink(c.auditSink))
+	}
+	// The shell can't change the trash, so its undo recreates the driver with the trash as loaded.
+	c.driverOpts = append(c.driverOpts, folder.WithTrash(c.trashRetention, trash...))
+	c.driver = folder.NewDriver(folders, c.driverOpts...)
+
+	if !cmd.mutates {
+		return nil
+	}
+	// Save the purge at once, so it isn't audited again if the command fails.
+	c.purged, err = c.trashDriver().PurgeTrash(c.ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(c.purged) > 0 {
+		return c.saveTrash()
+	}
+	return nil
+}
+
+// parseAuditQuery completes the query of the audit command with the organization and the time range.
+func (c *cli) parseAuditQuery(since, until string) error {
+	c.auditQuery.OrgID = c.orgID
+	for _, bound := range []struct {
+		flag  string
+		value string
+		time  *time.Time
+	}{{"since", since, &c.auditQuery.Since}, {"until", until, &c.auditQuery.Until}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return &usageError{fmt.Sprintf("invalid -%s time '%s'", bound.flag, bound.value)}
+		}
+		*bound.time = t
+	}
+	return nil
+}
+
+// save writes the folders of the driver back to the dataset file, and its trash to the trash file.
+func (c *cli) save() error {
+	data, err := folder.MarshalDataset(c.allFolders())
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.file, data, 0o644); err != nil {
+		return err
+	}
+	return c.saveTrash()
+}
+
+// saveTrash writes the trash of the driver to the trash file of the dataset. No file is created for an empty trash.
+func (c *cli) saveTrash() error {
+	items, err := c.trashDriver().AllTrash(c.ctx)
+	if err != nil {
+		return err
+	}
+	path := folder.TrashFile(c.file)
+	if _, err := os.Stat(path); len(items) == 0 && errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	data, err := folder.MarshalTrash(items)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// trashDriver returns the driver, which always has a trash in folderctl.
+func (c *cli) trashDriver() folder.TrashDriver {
+	return c.driver.(folder.TrashDriver)
+}
+
+// allFolders returns every folder of the driver, grouped by organization in order of first appearance.
+func (c *cli) allFolders() []*folder.Folder {
+	var res []*folder.Folder
+	seen := make(map[uuid.UUID]bool)
+	for _, f := range c.folders {
+		if seen[f.OrgId] {
+			continue
+		}
+		seen[f.OrgId] = true
+		res = append(res, c.orgFolders(f.OrgId)...)
+	}
+
+	// Folders created in organizations the file didn't contain yet.
+	if c.orgID != uuid.Nil && !seen[c.orgID] {
+		res = append(res, c.orgFolders(c.orgID)...)
+	}
+	return res
+}
+
+// orgFolders returns the folders of an organization. The driver can't fail as ctx has neither a principal nor a deadline.
+func (c *cli) orgFolders(orgID uuid.UUID) []*folder.Folder {
+	folders, err := c.driver.GetFoldersByOrgID(c.ctx, orgID)
+	if err != nil {
+		panic(err)
+	}
+	return folders
+}
+
+// invalidDataError is an error in the contents of a dataset file.
+type invalidDataError struct {
+	file string
+	err  error
+}
+
+func (e *invalidDataError) Error() string {
+	return fmt.Sprintf("invalid dataset '%s':\n%v", e.file, e.err)
+}
+
+func (e *invalidDataError) Unwrap() error {
+	return e.err
+}
+
+// loadFile loads a dataset file of any known version.
+func loadFile(path string) ([]*folder.Folder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	folders, err := folder.ReadDataset(file)
+	if err != nil {
+		return nil, &invalidDataError{file: path, err: err}
+	}
+	return folders, nil
+}
+
+// loadTrashFile loads the trash file of a dataset, if there is one.
+func loadTrashFile(path string) ([]*folder.TrashItem, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := folder.LoadTrash(data)
+	if err != nil {
+		return nil, &invalidDataError{file: path, err: err}
+	}
+	return items, nil
+}
+
+// exitCodeOf maps an error to the exit code documented for its kind.
+func exitCodeOf(err error) int {
+	var usageErr *usageError
+	var dataErr *invalidDataError
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, folder.ErrFolderNotFound):
+		return exitNotFound
+	case errors.Is(err, folder.ErrInvalidMove), errors.Is(err, folder.ErrFolderExists), errors.Is(err, folder.ErrInvalidName):
+		return exitRejected
+	case errors.As(err, &dataErr):
+		return exitInvalidData
+	default:
+		return exitError
+	}
+}
+
+func argNames(cmd *command) string {
+	names := make([]string, len(cmd.args))
+	for i, arg := range cmd.args {
+		names[i] = "<" + arg + ">"
+	}
+	return strings.Join(names, " ")
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: folderctl <command> -f <file> [--org <orgID>] [-o table|json] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-30s %s\n", strings.TrimSpace(cmd.name+" "+argNames(cmd)), cmd.summary)
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	orgID1 = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+	orgID2 = "38b9879b-f73b-4b0e-b9d9-4fc4c23643a7"
+)
+
+const testDataset = `{"format_version": 1, "folders": [
+	{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+	{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "alpha.bravo"},
+	{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "alpha.bravo.charlie"},
+	{"name": "delta", "org_id": "` + orgID1 + `", "paths": "alpha.delta"},
+	{"name": "foxtrot", "org_id": "` + orgID2 + `", "paths": "foxtrot"}
+]}`
+
+// writeDataset writes a dataset to a temporary file and returns its path.
+func writeDataset(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "folders.json")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// Test_folderctl tests the output and exit code of every command.
+func Test_folderctl(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "ls in an organization",
+			args:       []string{"ls", "--org", orgID2},
+			wantStdout: "NAME     ORG                                   PATH\nfoxtrot  " + orgID2 + "  foxtrot\n",
+		},
+		{
+			name:       "ls as JSON",
+			args:       []string{"ls", "--org", orgID2, "-o", "json"},
+			wantStdout: "[\n\t{\n\t\t\"name\": \"foxtrot\",\n\t\t\"org_id\": \"" + orgID2 + "\",\n\t\t\"paths\": \"foxtrot\"\n\t}\n]\n",
+		},
+		{
+			name:       "tree",
+			args:       []string{"tree"},
+			wantStdout: "org " + orgID1 + "\nalpha\n├── bravo\n│   └── charlie\n└── delta\norg " + orgID2 + "\nfoxtrot\n",
+		},
+		{
+			name:       "tree with depth and counts",
+			args:       []string{"tree", "--org", orgID1, "-depth", "2", "-counts", "-ascii"},
+			wantStdout: "org " + orgID1 + "\nalpha (3)\n|-- bravo (1)\n`-- delta\n",
+		},
+		{
+			name:       "tree as Mermaid",
+			args:       []string{"tree", "--org", orgID2, "-o", "mermaid"},
+			wantStdout: "flowchart LR\n\tsubgraph org_0 [\"org " + orgID2 + "\"]\n\t\tn1[\"foxtrot\"]\n\tend\n",
+		},
+		{
+			name: "tree as DOT with a highlighted move",
+			args: []string{"tree", "--org", orgID1, "-o", "dot", "-highlight", "alpha.delta:alpha.bravo.charlie"},
+			wantStdout: "digraph folders {\n\trankdir=LR;\n\tnode [shape=folder];\n\tsubgraph cluster_0 {\n\t\tlabel=\"org " + orgID1 + "\";\n" +
+				"\t\tn1 [label=\"alpha\"];\n\t\tn2 [label=\"bravo\"];\n\t\tn3 [label=\"charlie\", style=filled, fillcolor=\"#99ccff\"];\n\t\tn4 [label=\"delta\", style=filled, fillcolor=\"#ff9966\"];\n" +
+				"\t\tn1 -> n2;\n\t\tn2 -> n3;\n\t\tn1 -> n4;\n\t}\n\tn3 -> n4 [style=dashed, color=\"#ff6600\", label=\"move\"];\n}\n",
+		},
+		{
+			name:       "Highlight of a missing folder",
+			args:       []string{"tree", "--org", orgID1, "-o", "dot", "-highlight", "alpha.zulu:alpha"},
+			wantCode:   exitNotFound,
+			wantStderr: "folderctl: source folder 'alpha.zulu' does not exist in orgID '" + orgID1 + "'\n",
+		},
+		{
+			name:       "Graph output for another command",
+			args:       []string{"ls", "-o", "dot"},
+			wantCode:   exitUsage,
+			wantStderr: "folderctl: invalid output format 'dot'\n",
+		},
+		{
+			name:       "children",
+			args:       []string{"children", "--org", orgID1, "alpha.bravo"},
+			wantStdout: "NAME     ORG                                   PATH\ncharlie  " + orgID1 + "  alpha.bravo.charlie\n",
+		},
+		{
+			name:       "validate",
+			args:       []string{"validate"},
+			wantStdout: "{file}: 5 folders in 2 organizations\n",
+		},
+		{
+			name:       "Unknown command",
+			args:       []string{"cp"},
+			wantCode:   exitUsage,
+			wantStderr: "folderctl: unknown command 'cp'\n",
+		},
+		{
+			name:       "Missing argument",
+			args:       []string{"move", "--org", orgID1, "alpha.bravo"},
+			wantCode:   exitUsage,
+			wantStderr: "folderctl: usage: folderctl move [flags] <path> <dst>\n",
+		},
+		{
+			name:       "Missing org",
+			args:       []string{"rm", "alpha"},
+			wantCode:   exitUsage,
+			wantStderr: "folderctl: rm requires --org\n",
+		},
+		{
+			name:       "Invalid org",
+			args:       []string{"ls", "--org", "acme"},
+			wantCode:   exitUsage,
+			wantStderr: "folderctl: invalid org ID 'acme'\n",
+		},
+		{
+			name:       "Folder not found",
+			args:       []string{"children", "--org", orgID2, "alpha"},
+			wantCode:   exitNotFound,
+			wantStderr: "folderctl: folder 'alpha' does not exist\n",
+		},
+		{
+			name:       "Invalid move",
+			args:       []string{"move", "--org", orgID1, "alpha.bravo", "alpha.bravo.charlie"},
+			wantCode:   exitRejected,
+			wantStderr: "folderctl: cannot move folder 'alpha.bravo' to a child of itself\n",
+		},
+		{
+			name:       "Existing folder",
+			args:       []string{"mkdir", "--org", orgID1, "alpha.delta"},
+			wantCode:   exitRejected,
+			wantStderr: "folderctl: folder 'alpha.delta' already exists\n",
+		},
+		{
+			name:       "Invalid name",
+			args:       []string{"rename", "--org", orgID1, "alpha.delta", ""},
+			wantCode:   exitRejected,
+			wantStderr: "folderctl: invalid folder name ''\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			file := writeDataset(t, testDataset)
+
+			var stdout, stderr bytes.Buffer
+			code := run(append([]string{tt.args[0], "-f", file}, tt.args[1:]...), nil, &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, bytes.ReplaceAll([]byte(tt.wantStdout), []byte("{file}"), []byte(file)), stdout.Bytes())
+			if tt.wantStderr != "" {
+				assert.Contains(t, stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+// Test_folderctl_Mutations tests that mutating commands write the dataset back to its file.
+func Test_folderctl_Mutations(t *testing.T) {
+	t.Parallel()
+	file := writeDataset(t, testDataset)
+
+	for _, args := range [][]string{
+		{"move", "-f", file, "--org", orgID1, "alpha.bravo", "alpha.delta"},
+		{"mkdir", "-f", file, "--org", orgID1, "alpha.delta.echo"},
+		{"rename", "-f", file, "--org", orgID1, "alpha.delta.bravo.charlie", "golf"},
+		{"rm", "-f", file, "--org", orgID2, "foxtrot"},
+		{"mkdir", "-f", file, "--org", orgID2, "hotel"},
+		{"copy", "-f", file, "--org", orgID1, "alpha.delta.echo", "alpha.delta"},
+		{"transfer", "-f", file, "--org", orgID1, "alpha.delta.echo-copy", orgID2, "hotel"},
+	} {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitOK, run(args, nil, &stdout, &stderr), "%v: %s", args, stderr.String())
+	}
+
+	var stdout bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"tree", "-f", file}, nil, &stdout, &stdout))
+	assert.Equal(t, "org "+orgID1+"\nalpha\n└── delta\n    ├── bravo\n    │   └── golf\n    └── echo\norg "+orgID2+"\nhotel\n└── echo-copy\n", stdout.String())
+}
+
+// Test_folderctl_CollidingNames tests that moves stay within --org when other organizations have the same folder names.
+func Test_folderctl_CollidingNames(t *testing.T) {
+	t.Parallel()
+	const dataset = `{"format_version": 1, "folders": [
+	{"name": "a", "org_id": "` + orgID1 + `", "paths": "a"},
+	{"name": "b", "org_id": "` + orgID1 + `", "paths": "b"},
+	{"name": "a", "org_id": "` + orgID2 + `", "paths": "a"},
+	{"name": "b", "org_id": "` + orgID2 + `", "paths": "b"}
+]}`
+	want := "org " + orgID1 + "\nb\n└── a\norg " + orgID2 + "\na\nb\n"
+
+	for _, args := range [][]string{
+		{"move", "--org", orgID1, "a", "b"},
+		{"shell", "--org", orgID1, "--save"},
+	} {
+		file := writeDataset(t, dataset)
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{args[0], "-f", file}, args[1:]...), strings.NewReader("mv a b\n"), &stdout, &stderr)
+		assert.Equal(t, exitOK, code, "%v: %s", args, stderr.String())
+
+		stdout.Reset()
+		assert.Equal(t, exitOK, run([]string{"tree", "-f", file}, nil, &stdout, &stdout))
+		assert.Equal(t, want, stdout.String(), "%v", args)
+	}
+}
+
+// Test_folderctl_SameNames tests that commands address folders by path when folders of an organization share a name.
+func Test_folderctl_SameNames(t *testing.T) {
+	t.Parallel()
+	const dataset = `{"format_version": 1, "folders": [
+	{"name": "a", "org_id": "` + orgID1 + `", "paths": "a"},
+	{"name": "x", "org_id": "` + orgID1 + `", "paths": "a.x"},
+	{"name": "b", "org_id": "` + orgID1 + `", "paths": "b"},
+	{"name": "x", "org_id": "` + orgID1 + `", "paths": "b.x"},
+	{"name": "y", "org_id": "` + orgID1 + `", "paths": "b.x.y"},
+	{"name": "c", "org_id": "` + orgID1 + `", "paths": "c"}
+]}`
+	file := writeDataset(t, dataset)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"children", "-f", file, "--org", orgID1, "-o", "json", "b.x"}, nil, &stdout, &stderr), stderr.String())
+	assert.JSONEq(t, `[{"name": "y", "org_id": "`+orgID1+`", "paths": "b.x.y"}]`, stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"move", "-f", file, "--org", orgID1, "-o", "json", "a.x", "c"}, nil, &stdout, &stderr), stderr.String())
+	assert.JSONEq(t, `[{"name": "x", "org_id": "`+orgID1+`", "paths": "c.x"}]`, stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"tree", "-f", file}, nil, &stdout, &stdout))
+	assert.Equal(t, "org "+orgID1+"\na\nb\n└── x\n    └── y\nc\n└── x\n", stdout.String())
+
+	stderr.Reset()
+	assert.Equal(t, exitRejected, run([]string{"move", "-f", file, "--org", orgID1, "c.x", "b"}, nil, &stdout, &stderr))
+	assert.Equal(t, "folderctl: folder 'b.x' already exists\n", stderr.String())
+
+	// The shell moves the folder at the typed path too
+	assert.Equal(t, exitOK, run([]string{"shell", "-f", file, "--org", orgID1, "--save"}, strings.NewReader("mv b/x a\n"), &stdout, &stderr), stderr.String())
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"tree", "-f", file}, nil, &stdout, &stdout))
+	assert.Equal(t, "org "+orgID1+"\na\n└── x\n    └── y\nb\nc\n└── x\n", stdout.String())
+}
+
+// Test_folderctl_Diff tests diff between a dataset and an edited copy.
+func Test_folderctl_Diff(t *testing.T) {
+	t.Parallel()
+	before := writeDataset(t, testDataset)
+	after := writeDataset(t, testDataset)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"diff", "-f", before, "--exit-code", after}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+
+	for _, args := range [][]string{
+		{"move", "-f", after, "--org", orgID1, "alpha.bravo", "alpha.delta"},
+		{"rm", "-f", after, "--org", orgID2, "foxtrot"},
+		{"mkdir", "-f", after, "--org", orgID1, "india"},
+	} {
+		assert.Equal(t, exitOK, run(args, nil, &stdout, &stderr))
+	}
+
+	stdout.Reset()
+	assert.Equal(t, exitError, run([]string{"diff", "-f", before, "--exit-code", after}, nil, &stdout, &stderr))
+	assert.Equal(t, "- "+orgID2+" foxtrot\n"+
+		"+ "+orgID1+" india\n"+
+		"~ "+orgID1+" alpha.bravo -> alpha.delta.bravo\n"+
+		"~ "+orgID1+" alpha.bravo.charlie -> alpha.delta.bravo.charlie\n", stdout.String())
+}
+
+// Test_folderctl_Diff_SameNames tests that diff reports every removed folder sharing a name.
+func Test_folderctl_Diff_SameNames(t *testing.T) {
+	t.Parallel()
+	before := writeDataset(t, `[
+		{"name": "a", "org_id": "`+orgID1+`", "paths": "a"},
+		{"name": "dup", "org_id": "`+orgID1+`", "paths": "a.dup"},
+		{"name": "b", "org_id": "`+orgID1+`", "paths": "b"},
+		{"name": "dup", "org_id": "`+orgID1+`", "paths": "b.dup"},
+		{"name": "x", "org_id": "`+orgID1+`", "paths": "b.x"}
+	]`)
+	after := writeDataset(t, `[
+		{"name": "a", "org_id": "`+orgID1+`", "paths": "a"},
+		{"name": "x", "org_id": "`+orgID1+`", "paths": "a.x"},
+		{"name": "b", "org_id": "`+orgID1+`", "paths": "b"}
+	]`)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitError, run([]string{"diff", "-f", before, "--exit-code", after}, nil, &stdout, &stderr))
+	assert.Equal(t, "- "+orgID1+" a.dup\n"+
+		"- "+orgID1+" b.dup\n"+
+		"~ "+orgID1+" b.x -> a.x\n", stdout.String())
+}
+
+// Test_folderctl_Generate tests that generate is reproducible with -seed.
+func Test_folderctl_Generate(t *testing.T) {
+	t.Parallel()
+
+	generate := func(args ...string) string {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitOK, run(append([]string{"generate"}, args...), nil, &stdout, &stderr), stderr.String())
+		return stdout.String()
+	}
+
+	deep := generate("-seed", "1", "-orgs", "1", "-roots", "1", "-shape", "deep", "-depth", "3")
+	assert.Equal(t, deep, generate("-seed", "1", "-orgs", "1", "-roots", "1", "-shape", "deep", "-depth", "3"))
+	assert.NotEqual(t, deep, generate("-seed", "2", "-orgs", "1", "-roots", "1", "-shape", "deep", "-depth", "3"))
+
+	folders, err := folder.LoadDataset([]byte(deep))
+	assert.NoError(t, err)
+	assert.Len(t, folders, 3)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitUsage, run([]string{"generate", "-shape", "round"}, nil, &stdout, &stderr))
+	assert.Equal(t, "folderctl: unknown shape 'round'\n", stderr.String())
+}
+
+// Test_folderctl_Repair tests that repair fixes a dataset that fails to load.
+func Test_folderctl_Repair(t *testing.T) {
+	t.Parallel()
+	file := writeDataset(t, `{"format_version": 1, "folders": [
+		{"name": "alpha", "org_id": "`+orgID1+`", "paths": "alpha"},
+		{"name": "alpha", "org_id": "`+orgID1+`", "paths": "alpha"},
+		{"name": "zulu", "org_id": "`+orgID1+`", "paths": "alpha.bravo"},
+		{"name": "charlie", "org_id": "`+orgID1+`", "paths": "alpha.missing.charlie"}
+	]}`)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitInvalidData, run([]string{"tree", "-f", file}, nil, &stdout, &stderr))
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"repair", "-f", file, "-dry-run"}, nil, &stdout, &stderr))
+	assert.Equal(t, "duplicate_path: folder 1 'alpha': path is also used by folder 0\n"+
+		"name_mismatch: folder 2 'alpha.bravo': last label of the path is not the name 'zulu'\n"+
+		"missing_parent: folder 3 'alpha.missing.charlie': parent path 'alpha.missing' does not exist\n"+
+		"3 issues\n", stdout.String())
+	assert.Equal(t, exitInvalidData, run([]string{"tree", "-f", file}, nil, &stdout, &stderr))
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"repair", "-f", file}, nil, &stdout, &stderr))
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"tree", "-f", file}, nil, &stdout, &stderr))
+	assert.Equal(t, "org "+orgID1+"\nalpha\n└── bravo\nlost+found\n└── charlie\n", stdout.String())
+}
+
+// Test_folderctl_Report tests that report writes an organization's HTML report.
+func Test_folderctl_Report(t *testing.T) {
+	t.Parallel()
+	file := writeDataset(t, testDataset)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"report", "-f", file, "--org", orgID1, "-title", "Acme"}, nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "<title>Acme</title>")
+	assert.Contains(t, stdout.String(), `data-path="alpha.bravo.charlie"`)
+}
+
+// Test_folderctl_Audit tests that mutations are appended to the audit log and can be queried.
+func Test_folderctl_Audit(t *testing.T) {
+	t.Parallel()
+	file := writeDataset(t, testDataset)
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"move", "-f", file, "--org", orgID1, "-audit", auditFile, "alpha.bravo", "alpha.delta"}, nil, &stdout, &stderr))
+	assert.Equal(t, exitOK, run([]string{"mkdir", "-f", file, "--org", orgID2, "-audit", auditFile, "foxtrot.golf"}, nil, &stdout, &stderr))
+	// Rejected mutations aren't recorded
+	assert.Equal(t, exitRejected, run([]string{"mkdir", "-f", file, "--org", orgID2, "-audit", auditFile, "foxtrot.golf"}, nil, &stdout, &stderr))
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"audit", "-audit", auditFile, "-o", "json"}, nil, &stdout, &stderr))
+	var entries []folder.AuditEntry
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, folder.AuditEntry{Time: entries[0].Time, OrgID: uuid.FromStringOrNil(orgID1), Op: folder.AuditMove, OldPath: "alpha.bravo", NewPath: "alpha.delta.bravo", Descendants: 1}, entries[0])
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"audit", "-audit", auditFile, "-path", "alpha.bravo"}, nil, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "alpha.delta.bravo")
+	assert.NotContains(t, stdout.String(), "foxtrot.golf")
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"audit", "-audit", auditFile, "--org", orgID2, "-since", entries[0].Time.Add(-time.Minute).Format(time.RFC3339)}, nil, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "foxtrot.golf")
+	assert.NotContains(t, stdout.String(), "alpha.delta.bravo")
+
+	assert.Equal(t, exitUsage, run([]string{"audit", "-audit", auditFile, "-until", "yesterday"}, nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"audit"}, nil, &stdout, &stderr))
+}
+
+// Test_folderctl_Audit_Undo tests that the shell keeps auditing after :undo, and audits the undo itself.
+func Test_folderctl_Audit_Undo(t *testing.T) {
+	t.Parallel()
+	file := writeDataset(t, testDataset)
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	script := "mkdir alpha/echo\n:undo\nmv alpha/bravo alpha/delta\n:undo\nmkdir alpha/golf\n"
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"shell", "-f", file, "--org", orgID1, "-audit", auditFile}, strings.NewReader(script), &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"audit", "-audit", auditFile, "-o", "json"}, nil, &stdout, &stderr))
+	var entries []folder.AuditEntry
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	got := make([]folder.AuditEntry, len(entries))
+	for i, e := range entries {
+		assert.Equal(t, uuid.FromStringOrNil(orgID1), e.OrgID)
+		got[i] = folder.AuditEntry{Op: e.Op, OldPath: e.OldPath, NewPath: e.NewPath, Descendants: e.Descendants}
+	}
+	assert.Equal(t, []folder.AuditEntry{
+		{Op: folder.AuditCreate, NewPath: "alpha.echo"},
+		{Op: folder.AuditDelete, OldPath: "alpha.echo"},
+		{Op: folder.AuditMove, OldPath: "alpha.bravo", NewPath: "alpha.delta.bravo", Descendants: 1},
+		{Op: folder.AuditMove, OldPath: "alpha.delta.bravo", NewPath: "alpha.bravo", Descendants: 1},
+		{Op: folder.AuditCreate, NewPath: "alpha.golf"},
+	}, got)
+}
+
+// Test_folderctl_Trash tests that rm keeps folders in a trash saved next to the dataset, to restore or purge them.
+func Test_folderctl_Trash(t *testing.T) {
+	t.Parallel()
+	file := writeDataset(t, testDataset)
+	trashFile := folder.TrashFile(file)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"rm", "-f", file, "--org", orgID1, "alpha.bravo"}, nil, &stdout, &stderr), stderr.String())
+	assert.FileExists(t, trashFile)
+
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"trash", "-f", file, "--org", orgID1, "-o", "json"}, nil, &stdout, &stderr), stderr.String())
+	items, err := folder.LoadTrash(stdout.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "alpha.bravo", items[0].Path)
+	assert.Len(t, items[0].Folders, 2)
+
+	stdout.Reset()
+	assert.Equal(t, exitNotFound, run([]string{"restore", "-f", file, "--org", orgID1, "-parent", "zulu", items[0].ID.String()}, nil, &stdout, &stderr))
+	assert.Equal(t, exitOK, run([]string{"restore", "-f", file, "--org", orgID1, "-parent", "alpha.delta", items[0].ID.String()}, nil, &stdout, &stderr), stderr.String())
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"children", "-f", file, "--org", orgID1, "-o", "json", "alpha.delta"}, nil, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), `"paths": "alpha.delta.bravo.charlie"`)
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"trash", "-f", file, "--org", orgID1}, nil, &stdout, &stderr))
+	assert.Equal(t, "ID  PATH  DELETED  BY  FOLDERS\n", stdout.String())
+
+	// Expired items are purged by the next command that edits the dataset
+	assert.Equal(t, exitOK, run([]string{"rm", "-f", file, "--org", orgID1, "alpha.delta"}, nil, &stdout, &stderr))
+	stdout.Reset()
+	assert.Equal(t, exitOK, run([]string{"purge", "-f", file, "-trash-retention", "1ns"}, nil, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "alpha.delta")
+	data, err := os.ReadFile(trashFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+}
+
+// Test_folderctl_InvalidData tests that invalid dataset files have their own exit code.
+func Test_folderctl_InvalidData(t *testing.T) {
+	t.Parallel()
+	file := writeDataset(t, `{"format_version": 1, "folders": [{"name": "alpha", "org_id": "`+orgID1+`", "paths": "zulu.alpha"}]}`)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitInvalidData, run([]string{"validate", "-f", file}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "/folders/0/paths: parent path 'zulu' does not exist")
+
+	assert.Equal(t, exitError, run([]string{"validate", "-f", file + ".missing"}, nil, &stdout, &stderr))
+}
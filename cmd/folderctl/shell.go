@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/peterh/liner"
+)
+
+// historyFile is where the shell keeps its command history, in the user's home directory.
+const historyFile = ".folderctl_history"
+
+// lineReader reads the shell's input one line at a time.
+type lineReader interface {
+	Prompt(prompt string) (string, error)
+}
+
+// scanReader reads lines from a non-interactive input such as a script or a pipe.
+type scanReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *scanReader) Prompt(string) (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// shell is an interactive session over the folders of one organization,
+// navigated like a filesystem where "/alpha/bravo" is the folder at path "alpha.bravo".
+type shell struct {
+	c   *cli
+	cwd string // ltree path of the current folder, "" at the organization's root
+
+	// snapshots are the states before each mutation, for :undo.
+	snapshots []snapshot
+	changed   bool
+	save      bool
+}
+
+// snapshot is the dataset before a mutation, and the audit entry of reverting to it.
+type snapshot struct {
+	dataset []byte
+	revert  folder.AuditEntry
+}
+
+// shellCommands lists the shell commands with their usage, for help and completion.
+var shellCommands = [][2]string{
+	{"cd [dir]", "change the current folder"},
+	{"pwd", "print the current folder"},
+	{"ls [dir]", "list the folders in a folder"},
+	{"tree [dir]", "print the tree below a folder"},
+	{"mv <src> <dst>", "move a folder under dst"},
+	{"mkdir <dir>", "create a folder"},
+	{"find <pattern>", "find folders below the current one whose name matches a glob pattern"},
+	{":undo", "undo the last mv or mkdir"},
+	{":w", "save the dataset file"},
+	{"help", "print this help"},
+	{"exit", "leave the shell, saving if --save is set"},
+}
+
+func runShell(c *cli, args []string) error {
+	s := &shell{c: c, save: c.saveOnExit}
+
+	var reader lineReader
+	if c.stdin == os.Stdin && liner.TerminalSupported() {
+		line := liner.NewLiner()
+		defer line.Close()
+		line.SetCtrlCAborts(true)
+		line.SetWordCompleter(s.complete)
+
+		history := filepath.Join(os.Getenv("HOME"), historyFile)
+		if f, err := os.Open(history); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+		defer func() {
+			if f, err := os.Create(history); err == nil {
+				line.WriteHistory(f)
+				f.Close()
+			}
+		}()
+
+		reader = &historyReader{line}
+	} else {
+		reader = &scanReader{bufio.NewScanner(c.stdin)}
+	}
+
+	for {
+		input, err := reader.Prompt(s.prompt())
+		if err == io.EOF || err == liner.ErrPromptAborted {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		quit, err := s.exec(input)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "%v\n", err)
+		}
+		if quit {
+			break
+		}
+	}
+
+	if s.save && s.changed {
+		return c.save()
+	}
+	return nil
+}
+
+// historyReader records every non-empty line in the liner's history.
+type historyReader struct {
+	*liner.State
+}
+
+func (r *historyReader) Prompt(prompt string) (string, error) {
+	input, err := r.State.Prompt(prompt)
+	if strings.TrimSpace(input) != "" {
+		r.AppendHistory(input)
+	}
+	return input, err
+}
+
+func (s *shell) prompt() string {
+	return fmt.Sprintf("%s:%s> ", s.c.orgID.String()[:8], toDir(s.cwd))
+}
+
+// exec runs a single shell command line and reports whether the shell should exit.
+func (s *shell) exec(input string) (bool, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return false, nil
+	}
+	name, args := fields[0], fields[1:]
+	out := s.c.stdout
+
+	switch name {
+	case "exit", "quit":
+		return true, nil
+	case "help":
+		for _, cmd := range shellCommands {
+			fmt.Fprintf(out, "  %-16s %s\n", cmd[0], cmd[1])
+		}
+	case "pwd":
+		fmt.Fprintln(out, toDir(s.cwd))
+	case "cd":
+		dir := ""
+		if len(args) > 0 {
+			resolved, err := s.resolveDir(args[0])
+			if err != nil {
+				return false, err
+			}
+			dir = resolved
+		}
+		s.cwd = dir
+	case "ls":
+		dir, err := s.dirArg(args)
+		if err != nil {
+			return false, err
+		}
+		for _, child := range s.children(dir) {
+			fmt.Fprintln(out, child.Name+"/")
+		}
+	case "tree":
+		dir, err := s.dirArg(args)
+		if err != nil {
+			return false, err
+		}
+		folders := s.children("")
+		if dir != "" {
+			folders = []*folder.Folder{s.find(dir)}
+		}
+		return false, folder.RenderTree(out, folders, s.c.render)
+	case "find":
+		if len(args) != 1 {
+			return false, errors.New("usage: find <pattern>")
+		}
+		for _, f := range s.c.orgFolders(s.c.orgID) {
+			if s.cwd != "" && !strings.HasPrefix(f.Paths, s.cwd+".") {
+				continue
+			}
+			if matched, err := path.Match(args[0], f.Name); err != nil {
+				return false, err
+			} else if matched {
+				fmt.Fprintln(out, toDir(f.Paths))
+			}
+		}
+	case "mv":
+		if len(args) != 2 {
+			return false, errors.New("usage: mv <src> <dst>")
+		}
+		return false, s.mutate(func() (folder.AuditEntry, error) { return s.move(args[0], args[1]) })
+	case "mkdir":
+		if len(args) != 1 {
+			return false, errors.New("usage: mkdir <dir>")
+		}
+		return false, s.mutate(func() (folder.AuditEntry, error) { return s.mkdir(args[0]) })
+	case ":undo":
+		return false, s.undo()
+	case ":w":
+		if err := s.c.save(); err != nil {
+			return false, err
+		}
+		s.changed = false
+	default:
+		return false, fmt.Errorf("unknown command '%s', try help", name)
+	}
+
+	return false, nil
+}
+
+// mutate snapshots the dataset before running a mutation, so that it can be undone.
+// The mutation returns the audit entry of its reversal.
+func (s *shell) mutate(mutation func() (folder.AuditEntry, error)) error {
+	dataset, err := folder.MarshalDataset(s.c.allFolders())
+	if err != nil {
+		return err
+	}
+	revert, err := mutation()
+	if err != nil {
+		return err
+	}
+
+	s.snapshots = append(s.snapshots, snapshot{dataset: dataset, revert: revert})
+	s.changed = true
+	return nil
+}
+
+// undo restores the dataset as it was before the last mutation, auditing the reversal first like the driver
+// audits mutations.
+func (s *shell) undo() error {
+	if len(s.snapshots) == 0 {
+		return errors.New("nothing to undo")
+	}
+
+	last := s.snapshots[len(s.snapshots)-1]
+	folders, err := folder.LoadDataset(last.dataset)
+	if err != nil {
+		return err
+	}
+	if s.c.auditSink != nil {
+		entry := last.revert
+		entry.Time = time.Now().UTC()
+		entry.OrgID = s.c.orgID
+		if err := s.c.auditSink.Append(entry); err != nil {
+			return fmt.Errorf("audit %s: %w", entry.Op, err)
+		}
+	}
+	s.snapshots = s.snapshots[:len(s.snapshots)-1]
+	s.c.folders = folders
+	s.c.driver = folder.NewDriver(folders, s.c.driverOpts...)
+	s.changed = true
+
+	// The current folder may have been created or moved by the undone mutation.
+	if s.cwd != "" && s.find(s.cwd) == nil {
+		s.cwd = ""
+	}
+	return nil
+}
+
+func (s *shell) move(src, dst string) (folder.AuditEntry, error) {
+	srcPath, err := s.resolveDir(src)
+	if err != nil {
+		return folder.AuditEntry{}, err
+	}
+	dstPath, err := s.resolveDir(dst)
+	if err != nil {
+		return folder.AuditEntry{}, err
+	}
+	if srcPath == "" || dstPath == "" {
+		return folder.AuditEntry{}, fmt.Errorf("cannot move to or from the organization's root")
+	}
+
+	folders, err := s.c.driver.MoveFolderByPath(s.c.ctx, s.c.orgID, srcPath, dstPath)
+	if err != nil {
+		return folder.AuditEntry{}, err
+	}
+	source := folder.FindByPath(folders, s.c.orgID, folder.MovedPath(srcPath, dstPath))
+
+	// Follow the current folder if it was moved.
+	if s.cwd == srcPath || strings.HasPrefix(s.cwd, srcPath+".") {
+		s.cwd = source.Paths + strings.TrimPrefix(s.cwd, srcPath)
+	}
+	return folder.AuditEntry{Op: folder.AuditMove, OldPath: source.Paths, NewPath: srcPath, Descendants: s.descendants(source.Paths)}, nil
+}
+
+func (s *shell) mkdir(dir string) (folder.AuditEntry, error) {
+	parent, name := path.Split(strings.TrimSuffix(dir, "/"))
+	parentPath := s.cwd
+	if parent != "" {
+		resolved, err := s.resolveDir(parent)
+		if err != nil {
+			return folder.AuditEntry{}, err
+		}
+		parentPath = resolved
+	}
+
+	created, err := s.c.driver.CreateFolder(s.c.ctx, s.c.orgID, name, parentPath)
+	if err != nil {
+		return folder.AuditEntry{}, err
+	}
+	return folder.AuditEntry{Op: folder.AuditDelete, OldPath: created.Paths}, nil
+}
+
+// descendants returns the number of folders below the folder at path.
+func (s *shell) descendants(path string) int {
+	n := 0
+	for _, f := range s.c.orgFolders(s.c.orgID) {
+		if strings.HasPrefix(f.Paths, path+".") {
+			n++
+		}
+	}
+	return n
+}
+
+// dirArg resolves the optional directory argument of ls and tree.
+func (s *shell) dirArg(args []string) (string, error) {
+	if len(args) == 0 {
+		return s.cwd, nil
+	}
+	return s.resolveDir(args[0])
+}
+
+// resolveDir converts a filesystem-style path, absolute or relative to the current folder,
+// to the ltree path of an existing folder.
+func (s *shell) resolveDir(dir string) (string, error) {
+	var labels []string
+	if !strings.HasPrefix(dir, "/") && s.cwd != "" {
+		labels = strings.Split(s.cwd, ".")
+	}
+
+	for _, label := range strings.Split(dir, "/") {
+		switch label {
+		case "", ".":
+		case "..":
+			if len(labels) > 0 {
+				labels = labels[:len(labels)-1]
+			}
+		default:
+			labels = append(labels, label)
+		}
+	}
+
+	resolved := strings.Join(labels, ".")
+	if resolved != "" && s.find(resolved) == nil {
+		return "", fmt.Errorf("%s: %w", toDir(resolved), folder.ErrFolderNotFound)
+	}
+	return resolved, nil
+}
+
+// find returns the folder at an ltree path in the current organization, or nil.
+func (s *shell) find(p string) *folder.Folder {
+	for _, f := range s.c.orgFolders(s.c.orgID) {
+		if f.Paths == p {
+			return f
+		}
+	}
+	return nil
+}
+
+// children returns the folders directly below an ltree path, sorted by name.
+func (s *shell) children(dir string) []*folder.Folder {
+	var res []*folder.Folder
+	for _, f := range s.c.orgFolders(s.c.orgID) {
+		parent := ""
+		if idx := strings.LastIndex(f.Paths, "."); idx >= 0 {
+			parent = f.Paths[:idx]
+		}
+		if parent == dir {
+			res = append(res, f)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// complete completes the word before the cursor: a command name first, then folder names.
+func (s *shell) complete(line string, pos int) (string, []string, string) {
+	head, tail := line[:pos], line[pos:]
+	start := strings.LastIndex(head, " ") + 1
+	prefix, word := head[:start], head[start:]
+
+	var candidates []string
+	if start == 0 {
+		for _, cmd := range shellCommands {
+			name := strings.Fields(cmd[0])[0]
+			if strings.HasPrefix(name, word) {
+				candidates = append(candidates, name+" ")
+			}
+		}
+		return prefix, candidates, tail
+	}
+
+	dirPart, namePart := path.Split(word)
+	dir, err := s.resolveDir(dirPart)
+	if err != nil {
+		return prefix, nil, tail
+	}
+	for _, child := range s.children(dir) {
+		if strings.HasPrefix(child.Name, namePart) {
+			candidates = append(candidates, dirPart+child.Name+"/")
+		}
+	}
+	return prefix, candidates, tail
+}
+
+// toDir formats an ltree path as a filesystem-style path.
+func toDir(p string) string {
+	return "/" + strings.ReplaceAll(p, ".", "/")
+}
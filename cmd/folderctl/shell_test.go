@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folderctl_Shell tests scripted shell sessions.
+func Test_folderctl_Shell(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name       string
+		flags      []string
+		script     string
+		wantStdout string
+		wantStderr string
+		wantSaved  bool
+	}{
+		{
+			name:       "navigation",
+			script:     "pwd\nls\ncd alpha/bravo\npwd\ncd ..\nls\ncd /\npwd\n",
+			wantStdout: "/\nalpha/\n/alpha/bravo\nbravo/\ndelta/\n/\n",
+		},
+		{
+			name:       "tree and find",
+			script:     "tree alpha\ncd alpha\nfind *a*\n",
+			wantStdout: "alpha\n├── bravo\n│   └── charlie\n└── delta\n/alpha/bravo\n/alpha/bravo/charlie\n/alpha/delta\n",
+		},
+		{
+			// The current folder follows a moved folder.
+			name:       "mv",
+			script:     "cd alpha/bravo\nmv . ../delta\npwd\ntree /\n",
+			wantStdout: "/alpha/delta/bravo\nalpha\n└── delta\n    └── bravo\n        └── charlie\n",
+		},
+		{
+			name:       "mkdir and undo",
+			script:     "mkdir alpha/echo\nls alpha\n:undo\nls alpha\n:undo\n",
+			wantStdout: "bravo/\ndelta/\necho/\nbravo/\ndelta/\n",
+			wantStderr: "nothing to undo\n",
+		},
+		{
+			name:      "save on exit",
+			flags:     []string{"--save"},
+			script:    "mkdir golf\nexit\nmkdir hotel\n",
+			wantSaved: true,
+		},
+		{
+			name:   "changes are not saved without --save",
+			script: "mkdir golf\n",
+		},
+		{
+			name:       "errors don't end the session",
+			script:     "cd nowhere\ncp a b\nmv alpha\npwd\n",
+			wantStdout: "/\n",
+			wantStderr: "/nowhere: folder not found\nunknown command 'cp', try help\nusage: mv <src> <dst>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			file := writeDataset(t, testDataset)
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"shell", "-f", file, "--org", orgID1}, tt.flags...)
+			code := run(args, strings.NewReader(tt.script), &stdout, &stderr)
+
+			assert.Equal(t, exitOK, code)
+			assert.Equal(t, tt.wantStdout, stdout.String())
+			assert.Equal(t, tt.wantStderr, stderr.String())
+
+			saved, err := os.ReadFile(file)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSaved, strings.Contains(string(saved), `"golf"`))
+			assert.NotContains(t, string(saved), `"hotel"`)
+		})
+	}
+}
+
+// Test_shell_complete tests tab completion of commands and folder names.
+func Test_shell_complete(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name string
+		cwd  string
+		line string
+		want []string
+	}{
+		{
+			name: "command",
+			line: "m",
+			want: []string{"mv ", "mkdir "},
+		},
+		{
+			name: "folder in the current folder",
+			cwd:  "alpha",
+			line: "cd b",
+			want: []string{"bravo/"},
+		},
+		{
+			name: "folder in a relative path",
+			line: "ls alpha/",
+			want: []string{"alpha/bravo/", "alpha/delta/"},
+		},
+		{
+			name: "unknown folder",
+			line: "ls nowhere/",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := &cli{ctx: context.Background(), file: writeDataset(t, testDataset), output: "table"}
+			assert.NoError(t, c.setup(&command{name: "shell", needsOrg: true}, orgID1, nil))
+
+			s := &shell{c: c, cwd: tt.cwd}
+			_, got, _ := s.complete(tt.line, len(tt.line))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
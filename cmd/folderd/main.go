@@ -0,0 +1,134 @@
+// Command folderd serves a folder driver over a REST API.
+//
+//	GET    /orgs/{orgID}/folders                  folders of an organization
+//	GET    /orgs/{orgID}/folders/{path}/children  all descendants of a folder
+//	DELETE /orgs/{orgID}/folders/{path}           move a folder and its subtree to the trash
+//	GET    /orgs/{orgID}/trash                    trash of an organization
+//	POST   /folders/{path}:move                   move a folder, body {"org_id": ..., "destination": ...}
+//	POST   /trash/{id}:restore                    restore a folder from the trash, body {"org_id": ..., "parent": ...}
+//	POST   /graphql                               nested folder queries, see graphqlapi/schema.graphql
+//
+// Every request must give the caller's organization in the X-Org-ID header, and may give
+// X-User-ID and X-Request-ID. Requests are limited to the caller's organization.
+// folderd doesn't verify these headers: it must only be reachable through a proxy that authenticates
+// callers and sets X-Org-ID and X-User-ID, overwriting any value sent by the caller.
+// Every change is appended to the audit log given by -audit before it is applied,
+// and delivered to the webhook endpoints configured by -webhooks after.
+// Deleted folders are kept in the trash for -trash-retention. The trash is loaded with the -data file,
+// from its trash file; like the folders, it is then changed in memory only. Expired items are purged
+// on startup and every hour.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/webhook"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	data := flag.String("data", "", "dataset file to serve, defaults to the sample data")
+	audit := flag.String("audit", "folderd-audit.jsonl", "JSON lines file the changes are appended to")
+	webhooks := flag.String("webhooks", "", "JSON file of the webhook endpoints, a list of {id, org_id, url, secret}")
+	queue := flag.String("webhook-queue", "folderd-webhooks.json", "file persisting the pending webhook deliveries")
+	retention := flag.Duration("trash-retention", 30*24*time.Hour, "how long deleted folders are kept in the trash, 0 keeps them until restored")
+	flag.Parse()
+
+	folders, err := loadFolders(*data)
+	if err != nil {
+		log.Fatalf("Error: Failed to load folders: %v", err)
+	}
+	trash, err := loadTrash(*data)
+	if err != nil {
+		log.Fatalf("Error: Failed to load trash: %v", err)
+	}
+	sink, err := folder.OpenAuditFile(*audit)
+	if err != nil {
+		log.Fatalf("Error: Failed to open audit log: %v", err)
+	}
+
+	bus := folder.NewEventBus()
+	if *webhooks != "" {
+		dispatcher, err := newDispatcher(*webhooks, *queue)
+		if err != nil {
+			log.Fatalf("Error: Failed to start webhooks: %v", err)
+		}
+		// Blocking keeps every event; queueing a delivery only writes the queue file.
+		// Run closes sub when it stops, after which changes are served without webhooks.
+		sub := bus.Subscribe(folder.SubscribeOptions{Overflow: folder.OverflowBlock})
+		go func() {
+			if err := dispatcher.Run(context.Background(), sub); err != nil {
+				log.Printf("Error: Webhook dispatcher stopped: %v", err)
+			}
+		}()
+	}
+
+	s := newServer(folder.NewDriver(folders, folder.WithAuditSink(sink), folder.WithEventBus(bus), folder.WithTrash(*retention, trash...)))
+	go purgeTrash(s, time.Hour)
+
+	log.Printf("Info: Serving %d folders on %s", len(folders), *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
+
+// purgeTrash purges the server's expired trash items now and then every interval.
+// The driver logs how many were purged.
+func purgeTrash(s *server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := time.Now(); ; now = <-ticker.C {
+		if err := s.purgeTrash(context.Background(), now); err != nil {
+			log.Printf("Error: Failed to purge trash: %v", err)
+		}
+	}
+}
+
+// newDispatcher returns a webhook dispatcher to the endpoints of a JSON file.
+func newDispatcher(path, queueFile string) (*webhook.Dispatcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var endpoints []webhook.Endpoint
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, err
+	}
+	return webhook.NewDispatcher(endpoints, webhook.Options{QueueFile: queueFile})
+}
+
+// loadFolders loads a dataset file of any known version, or the sample data when path is empty.
+func loadFolders(path string) ([]*folder.Folder, error) {
+	if path == "" {
+		return folder.GetSampleData(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return folder.ReadDataset(file)
+}
+
+// loadTrash loads the trash saved next to a dataset file, if there is one. The sample data has no trash.
+func loadTrash(path string) ([]*folder.TrashItem, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(folder.TrashFile(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return folder.LoadTrash(data)
+}
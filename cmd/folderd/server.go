@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/graphqlapi"
+	"github.com/gofrs/uuid"
+)
+
+// Headers of the caller's identity. Every request must give its organization, which it is limited to.
+const (
+	orgIDHeader     = "X-Org-ID"
+	userIDHeader    = "X-User-ID"
+	requestIDHeader = "X-Request-ID"
+)
+
+// moveSuffix marks the move action on a folder path, as in POST /folders/alpha.bravo:move.
+const moveSuffix = ":move"
+
+// restoreSuffix marks the restore action on a trash item ID, as in POST /trash/{id}:restore.
+const restoreSuffix = ":restore"
+
+// server exposes a folder driver as a REST API.
+type server struct {
+	// mu guards driver, which is not safe for concurrent use.
+	mu     sync.RWMutex
+	driver folder.IDriver
+	mux    *http.ServeMux
+}
+
+// moveRequest is the body of a move request.
+type moveRequest struct {
+	OrgID       string `json:"org_id"`
+	Destination string `json:"destination"`
+}
+
+// restoreRequest is the body of a restore request. An empty parent restores to the original path.
+type restoreRequest struct {
+	OrgID  string `json:"org_id"`
+	Parent string `json:"parent"`
+}
+
+// errorResponse is the body of every error response.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func newServer(driver folder.IDriver) *server {
+	s := &server{driver: driver, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /orgs/{orgID}/folders", s.listFolders)
+	s.mux.HandleFunc("GET /orgs/{orgID}/folders/{path}/children", s.listChildren)
+	s.mux.HandleFunc("DELETE /orgs/{orgID}/folders/{path}", s.deleteFolder)
+	s.mux.HandleFunc("GET /orgs/{orgID}/trash", s.listTrash)
+	s.mux.HandleFunc("POST /folders/{action}", s.moveFolder)
+	s.mux.HandleFunc("POST /trash/{action}", s.restoreFolder)
+	s.mux.Handle("POST /graphql", graphqlapi.NewHandler(driver, &s.mu))
+	return s
+}
+
+// ServeHTTP serves a request with its caller's principal and request ID in its context.
+// A request ID is generated if none is given, and echoed in the response.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = uuid.Must(uuid.NewV4()).String()
+	}
+	w.Header().Set(requestIDHeader, requestID)
+
+	org := r.Header.Get(orgIDHeader)
+	orgID, err := uuid.FromString(org)
+	if err != nil || orgID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, fmt.Sprintf("missing or invalid %s header '%s'", orgIDHeader, org))
+		return
+	}
+
+	ctx := folder.WithRequestID(r.Context(), requestID)
+	ctx = folder.WithPrincipal(ctx, folder.Principal{ID: r.Header.Get(userIDHeader), OrgID: orgID})
+	s.mux.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// listFolders handles GET /orgs/{orgID}/folders.
+func (s *server) listFolders(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := parseOrgID(w, r.PathValue("orgID"))
+	if !ok {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	folders, err := s.driver.GetFoldersByOrgID(r.Context(), orgID)
+	if err != nil {
+		writeDriverError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, folders)
+}
+
+// listChildren handles GET /orgs/{orgID}/folders/{path}/children.
+func (s *server) listChildren(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := parseOrgID(w, r.PathValue("orgID"))
+	if !ok {
+		return
+	}
+	path := r.PathValue("path")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	children, err := s.driver.GetAllChildFoldersByPath(r.Context(), orgID, path)
+	if err != nil {
+		writeDriverError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, children)
+}
+
+// moveFolder handles POST /folders/{path}:move.
+func (s *server) moveFolder(w http.ResponseWriter, r *http.Request) {
+	path, isMove := strings.CutSuffix(r.PathValue("action"), moveSuffix)
+	if !isMove {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown action on '%s'", r.PathValue("action")))
+		return
+	}
+
+	var req moveRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	orgID, ok := parseOrgID(w, req.OrgID)
+	if !ok {
+		return
+	}
+	if req.Destination == "" {
+		writeError(w, http.StatusBadRequest, "destination is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	folders, err := s.driver.MoveFolderByPath(r.Context(), orgID, path, req.Destination)
+	if err != nil {
+		writeDriverError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, folder.FindByPath(folders, orgID, folder.MovedPath(path, req.Destination)))
+}
+
+// deleteFolder handles DELETE /orgs/{orgID}/folders/{path}.
+func (s *server) deleteFolder(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := parseOrgID(w, r.PathValue("orgID"))
+	if !ok {
+		return
+	}
+	path := r.PathValue("path")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted, err := s.driver.DeleteFolder(r.Context(), orgID, path)
+	if err != nil {
+		writeDriverError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deleted)
+}
+
+// listTrash handles GET /orgs/{orgID}/trash.
+func (s *server) listTrash(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := parseOrgID(w, r.PathValue("orgID"))
+	if !ok {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	driver, ok := s.trashDriver(w)
+	if !ok {
+		return
+	}
+	items, err := driver.Trash(r.Context(), orgID)
+	if err != nil {
+		writeDriverError(w, err)
+		return
+	}
+	if items == nil {
+		items = []*folder.TrashItem{}
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// restoreFolder handles POST /trash/{id}:restore.
+func (s *server) restoreFolder(w http.ResponseWriter, r *http.Request) {
+	value, isRestore := strings.CutSuffix(r.PathValue("action"), restoreSuffix)
+	if !isRestore {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown action on '%s'", r.PathValue("action")))
+		return
+	}
+	id, err := uuid.FromString(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid trash item ID '%s'", value))
+		return
+	}
+
+	var req restoreRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	orgID, ok := parseOrgID(w, req.OrgID)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.trashDriver(w)
+	if !ok {
+		return
+	}
+	restored, err := driver.RestoreFolder(r.Context(), orgID, id, req.Parent)
+	if err != nil {
+		writeDriverError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, restored[0])
+}
+
+// purgeTrash purges the expired trash items of every organization, if the driver has a trash.
+func (s *server) purgeTrash(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.driver.(folder.TrashDriver)
+	if !ok {
+		return nil
+	}
+	_, err := driver.PurgeTrash(ctx, now)
+	return err
+}
+
+// trashDriver returns the driver's trash, writing a 501 response if it has none.
+func (s *server) trashDriver(w http.ResponseWriter) (folder.TrashDriver, bool) {
+	driver, ok := s.driver.(folder.TrashDriver)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "the driver has no trash")
+	}
+	return driver, ok
+}
+
+// parseOrgID parses an org ID, writing a 400 response if it is invalid.
+func parseOrgID(w http.ResponseWriter, value string) (uuid.UUID, bool) {
+	orgID, err := uuid.FromString(value)
+	if err != nil || orgID == uuid.Nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid org ID '%s'", value))
+		return uuid.Nil, false
+	}
+	return orgID, true
+}
+
+// writeDriverError maps the driver's error kinds to status codes.
+func writeDriverError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, folder.ErrFolderNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, folder.ErrInvalidMove), errors.Is(err, folder.ErrFolderExists):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, folder.ErrPermissionDenied):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		log.Printf("Error: Unexpected driver error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error: Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	orgID1 = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+	orgID2 = "38b9879b-f73b-4b0e-b9d9-4fc4c23643a7"
+)
+
+// newTestServer starts a server over a small linked folder tree in two organizations.
+func newTestServer(t *testing.T) *httptest.Server {
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+		{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "alpha.bravo"},
+		{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "alpha.bravo.charlie"},
+		{"name": "delta", "org_id": "` + orgID1 + `", "paths": "alpha.delta"},
+		{"name": "foxtrot", "org_id": "` + orgID2 + `", "paths": "foxtrot"}
+	]`))
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(newServer(folder.NewDriver(folders, folder.WithTrash(0))))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Test_folderd tests every route, including the mapping of errors to status codes.
+func Test_folderd(t *testing.T) {
+	t.Parallel()
+
+	emptyOrgID := uuid.Must(uuid.NewV4()).String()
+
+	tests := [...]struct {
+		name       string
+		org        string // X-Org-ID header, orgID1 if empty
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "List folders of an organization",
+			org:        orgID2,
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID2 + "/folders",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"name": "foxtrot", "org_id": "` + orgID2 + `", "paths": "foxtrot"}]`,
+		},
+		{
+			name:       "List folders of an organization without folders",
+			org:        emptyOrgID,
+			method:     http.MethodGet,
+			target:     "/orgs/" + emptyOrgID + "/folders",
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:       "List folders without an organization header",
+			org:        "-",
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID1 + "/folders",
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error": "missing or invalid X-Org-ID header ''"}`,
+		},
+		{
+			name:       "List folders of another organization",
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID2 + "/folders",
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"error": "'' may not access orgID '` + orgID2 + `'"}`,
+		},
+		{
+			name:       "List folders with an invalid org ID",
+			method:     http.MethodGet,
+			target:     "/orgs/not-a-uuid/folders",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error": "invalid org ID 'not-a-uuid'"}`,
+		},
+		{
+			name:       "List children",
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID1 + "/folders/alpha.bravo/children",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "alpha.bravo.charlie"}]`,
+		},
+		{
+			name:       "List children of a leaf",
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID1 + "/folders/alpha.delta/children",
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:       "List children of a folder in another organization",
+			org:        orgID2,
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID2 + "/folders/alpha/children",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "folder 'alpha' does not exist"}`,
+		},
+		{
+			name:       "Move with an invalid body",
+			method:     http.MethodPost,
+			target:     "/folders/alpha.bravo:move",
+			body:       `{"org": "` + orgID1 + `"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error": "invalid request body: json: unknown field \"org\""}`,
+		},
+		{
+			name:       "Move with an invalid org ID",
+			method:     http.MethodPost,
+			target:     "/folders/alpha.bravo:move",
+			body:       `{"org_id": "nil", "destination": "alpha.delta"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error": "invalid org ID 'nil'"}`,
+		},
+		{
+			name:       "Move a folder that does not exist",
+			method:     http.MethodPost,
+			target:     "/folders/alpha.echo:move",
+			body:       `{"org_id": "` + orgID1 + `", "destination": "alpha.delta"}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "source folder 'alpha.echo' does not exist"}`,
+		},
+		{
+			name:       "Move to a folder in another organization",
+			method:     http.MethodPost,
+			target:     "/folders/alpha.bravo:move",
+			body:       `{"org_id": "` + orgID1 + `", "destination": "foxtrot"}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "destination folder 'foxtrot' does not exist"}`,
+		},
+		{
+			name:       "Move to a child of itself",
+			method:     http.MethodPost,
+			target:     "/folders/alpha.bravo:move",
+			body:       `{"org_id": "` + orgID1 + `", "destination": "alpha.bravo.charlie"}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error": "cannot move folder 'alpha.bravo' to a child of itself"}`,
+		},
+		{
+			name:       "Unknown action",
+			method:     http.MethodPost,
+			target:     "/folders/alpha.bravo:copy",
+			body:       `{}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown action on 'alpha.bravo:copy'"}`,
+		},
+		{
+			name:       "Delete a folder that does not exist",
+			method:     http.MethodDelete,
+			target:     "/orgs/" + orgID1 + "/folders/alpha.echo",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "folder 'alpha.echo' does not exist"}`,
+		},
+		{
+			name:       "Delete a folder of another organization",
+			method:     http.MethodDelete,
+			target:     "/orgs/" + orgID2 + "/folders/foxtrot",
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"error": "'' may not access orgID '` + orgID2 + `'"}`,
+		},
+		{
+			name:       "List an empty trash",
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID1 + "/trash",
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:       "List the trash of another organization",
+			method:     http.MethodGet,
+			target:     "/orgs/" + orgID2 + "/trash",
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"error": "'' may not access orgID '` + orgID2 + `'"}`,
+		},
+		{
+			name:       "Restore with an invalid trash item ID",
+			method:     http.MethodPost,
+			target:     "/trash/alpha:restore",
+			body:       `{"org_id": "` + orgID1 + `"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error": "invalid trash item ID 'alpha'"}`,
+		},
+		{
+			name:       "Restore a trash item that does not exist",
+			method:     http.MethodPost,
+			target:     "/trash/" + emptyOrgID + ":restore",
+			body:       `{"org_id": "` + orgID1 + `"}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "trash item '` + emptyOrgID + `' does not exist"}`,
+		},
+		{
+			name:       "Unknown trash action",
+			method:     http.MethodPost,
+			target:     "/trash/" + emptyOrgID + ":purge",
+			body:       `{}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown action on '` + emptyOrgID + `:purge'"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+
+			org := tt.org
+			switch org {
+			case "":
+				org = orgID1
+			case "-":
+				org = ""
+			}
+			status, body := do(t, srv, org, tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.JSONEq(t, tt.wantBody, body)
+		})
+	}
+}
+
+// Test_folderd_Move tests that a move is visible to later requests.
+func Test_folderd_Move(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+
+	status, body := do(t, srv, orgID1, http.MethodPost, "/folders/alpha.bravo:move", `{"org_id": "`+orgID1+`", "destination": "alpha.delta"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"name": "bravo", "org_id": "`+orgID1+`", "paths": "alpha.delta.bravo"}`, body)
+
+	status, body = do(t, srv, orgID1, http.MethodGet, "/orgs/"+orgID1+"/folders/alpha.delta/children", "")
+	assert.Equal(t, http.StatusOK, status)
+
+	var children []*folder.Folder
+	assert.NoError(t, json.Unmarshal([]byte(body), &children))
+	var got []string
+	for _, child := range children {
+		got = append(got, child.Paths)
+	}
+	assert.ElementsMatch(t, []string{"alpha.delta.bravo", "alpha.delta.bravo.charlie"}, got)
+}
+
+// Test_folderd_Trash tests that a deleted folder is listed in the trash until it is restored,
+// and that servers without a trash don't serve it.
+func Test_folderd_Trash(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+
+	status, body := do(t, srv, orgID1, http.MethodDelete, "/orgs/"+orgID1+"/folders/alpha.bravo", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `[
+		{"name": "bravo", "org_id": "`+orgID1+`", "paths": "alpha.bravo"},
+		{"name": "charlie", "org_id": "`+orgID1+`", "paths": "alpha.bravo.charlie"}
+	]`, body)
+
+	status, body = do(t, srv, orgID1, http.MethodGet, "/orgs/"+orgID1+"/trash", "")
+	assert.Equal(t, http.StatusOK, status)
+	var items []*folder.TrashItem
+	assert.NoError(t, json.Unmarshal([]byte(body), &items))
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "alpha.bravo", items[0].Path)
+		assert.Len(t, items[0].Folders, 2)
+	}
+
+	status, body = do(t, srv, orgID1, http.MethodPost, "/trash/"+items[0].ID.String()+":restore", `{"org_id": "`+orgID1+`", "parent": "alpha.delta"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"name": "bravo", "org_id": "`+orgID1+`", "paths": "alpha.delta.bravo"}`, body)
+
+	status, body = do(t, srv, orgID1, http.MethodGet, "/orgs/"+orgID1+"/trash", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `[]`, body)
+
+	// Embedding hides the driver's trash methods.
+	noTrash := httptest.NewServer(newServer(struct{ folder.IDriver }{folder.NewDriver(nil)}))
+	t.Cleanup(noTrash.Close)
+	status, body = do(t, noTrash, orgID1, http.MethodGet, "/orgs/"+orgID1+"/trash", "")
+	assert.Equal(t, http.StatusNotImplemented, status)
+	assert.JSONEq(t, `{"error": "the driver has no trash"}`, body)
+}
+
+// Test_folderd_SameNames tests that folders are addressed by path when several folders of an organization share a name.
+func Test_folderd_SameNames(t *testing.T) {
+	t.Parallel()
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+		{"name": "x", "org_id": "` + orgID1 + `", "paths": "alpha.x"},
+		{"name": "y", "org_id": "` + orgID1 + `", "paths": "alpha.x.y"},
+		{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "bravo"},
+		{"name": "x", "org_id": "` + orgID1 + `", "paths": "bravo.x"},
+		{"name": "z", "org_id": "` + orgID1 + `", "paths": "bravo.x.z"},
+		{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "charlie"}
+	]`))
+	assert.NoError(t, err)
+	srv := httptest.NewServer(newServer(folder.NewDriver(folders)))
+	t.Cleanup(srv.Close)
+
+	status, body := do(t, srv, orgID1, http.MethodGet, "/orgs/"+orgID1+"/folders/bravo.x/children", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `[{"name": "z", "org_id": "`+orgID1+`", "paths": "bravo.x.z"}]`, body)
+
+	status, body = do(t, srv, orgID1, http.MethodPost, "/folders/alpha.x:move", `{"org_id": "`+orgID1+`", "destination": "charlie"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"name": "x", "org_id": "`+orgID1+`", "paths": "charlie.x"}`, body)
+
+	var got []string
+	for _, f := range folders {
+		got = append(got, f.Paths)
+	}
+	assert.ElementsMatch(t, []string{"alpha", "charlie.x", "charlie.x.y", "bravo", "bravo.x", "bravo.x.z", "charlie"}, got)
+
+	status, body = do(t, srv, orgID1, http.MethodPost, "/folders/charlie.x:move", `{"org_id": "`+orgID1+`", "destination": "bravo"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.JSONEq(t, `{"error": "folder 'bravo.x' already exists"}`, body)
+}
+
+// Test_folderd_RequestID tests that request IDs are echoed, or generated when missing.
+func Test_folderd_RequestID(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/orgs/"+orgID1+"/folders", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Org-ID", orgID1)
+	req.Header.Set("X-Request-ID", "req-42")
+	resp, err := srv.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "req-42", resp.Header.Get("X-Request-ID"))
+
+	req.Header.Del("X-Request-ID")
+	resp, err = srv.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+}
+
+// do sends a request for an organization to the test server and returns the status and body of the response.
+func do(t *testing.T, srv *httptest.Server, org, method, target, body string) (int, string) {
+	req, err := http.NewRequest(method, srv.URL+target, strings.NewReader(body))
+	assert.NoError(t, err)
+	if org != "" {
+		req.Header.Set("X-Org-ID", org)
+	}
+
+	resp, err := srv.Client().Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	return resp.StatusCode, string(b)
+}
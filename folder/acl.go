@@ -0,0 +1,534 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gofrs/uuid"
+)
+
+// Role is the access a principal has to a folder. Each role includes the ones below it.
+type Role int
+
+const (
+	// RoleNone gives no access; the folder is hidden.
+	RoleNone Role = iota
+	// RoleViewer may see the folder.
+	RoleViewer
+	// RoleEditor may also move the folder and move folders into it.
+	RoleEditor
+	// RoleOwner may also manage the grants of the folder and transfer it to another organization.
+	RoleOwner
+)
+
+var roleNames = []string{"none", "viewer", "editor", "owner"}
+
+func (r Role) String() string {
+	if r < 0 || int(r) >= len(roleNames) {
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// Principal is the user a request is made for, acting in one organization.
+type Principal struct {
+	ID     string
+	OrgID  uuid.UUID
+	Groups []string
+}
+
+// matches reports whether a grant to subject applies to the principal.
+func (p Principal) matches(subject string) bool {
+	if subject == p.ID {
+		return true
+	}
+	for _, group := range p.Groups {
+		if subject == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant gives a user or group a role on a folder and its subtree.
+// A deny grant instead caps the role of the subtree below Role, whatever is granted elsewhere.
+type Grant struct {
+	Subject string // a Principal's ID or one of its Groups
+	// SubjectOrgID is the organization of Subject, OrgID if nil. A grant to a subject of another
+	// organization only lets it transfer folders into OrgID, see AuthorizedDriver.TransferFolder.
+	SubjectOrgID uuid.UUID
+	OrgID        uuid.UUID
+	Path         string
+	Role         Role
+	Deny         bool
+}
+
+// subjectOrgID returns the organization of the grant's subject.
+func (g Grant) subjectOrgID() uuid.UUID {
+	if g.SubjectOrgID == uuid.Nil {
+		return g.OrgID
+	}
+	return g.SubjectOrgID
+}
+
+// ACL holds the grants of every organization. It is safe for concurrent use.
+type ACL struct {
+	mu     sync.RWMutex
+	grants []Grant
+}
+
+// NewACL returns an ACL with the given grants.
+func NewACL(grants ...Grant) *ACL {
+	return &ACL{grants: grants}
+}
+
+// Grant adds a grant.
+func (a *ACL) Grant(grant Grant) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.grants = append(a.grants, grant)
+}
+
+// Revoke removes the grants of subject on the folder at path, and reports whether any was removed.
+func (a *ACL) Revoke(subject string, orgID uuid.UUID, path string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	kept := a.grants[:0]
+	for _, grant := range a.grants {
+		if grant.Subject != subject || grant.OrgID != orgID || grant.Path != path {
+			kept = append(kept, grant)
+		}
+	}
+	removed := len(kept) < len(a.grants)
+	a.grants = kept
+	return removed
+}
+
+// drop removes the grants of an organization on the folder at path and its subtree.
+func (a *ACL) drop(orgID uuid.UUID, path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	kept := a.grants[:0]
+	for _, grant := range a.grants {
+		if grant.OrgID != orgID || !inSubtree(grant.Path, path) {
+			kept = append(kept, grant)
+		}
+	}
+	a.grants = kept
+}
+
+// move rewrites the paths of the grants on the folder at oldPath and its subtree after the folder moved to newPath.
+func (a *ACL) move(orgID uuid.UUID, oldPath, newPath string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, grant := range a.grants {
+		if grant.OrgID == orgID && inSubtree(grant.Path, oldPath) {
+			a.grants[i].Path = newPath + strings.TrimPrefix(grant.Path, oldPath)
+		}
+	}
+}
+
+// Role returns the role of a principal on the folder at path: the highest role granted on the folder
+// or an ancestor, capped below the lowest role denied on the folder or an ancestor.
+// Principals have no role on folders of other organizations.
+func (a *ACL) Role(p Principal, orgID uuid.UUID, path string) Role {
+	if orgID != p.OrgID {
+		return RoleNone
+	}
+	return a.role(p, orgID, path)
+}
+
+// role returns the role of a principal on the folder at path of any organization, from the grants
+// of that organization to subjects of the principal's organization.
+func (a *ACL) role(p Principal, orgID uuid.UUID, path string) Role {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	allowed, ceiling := RoleNone, RoleOwner
+	for _, grant := range a.grants {
+		if grant.OrgID != orgID || grant.subjectOrgID() != p.OrgID || !p.matches(grant.Subject) || !inSubtree(path, grant.Path) {
+			continue
+		}
+		switch {
+		case grant.Deny && grant.Role <= RoleViewer:
+			ceiling = RoleNone
+		case grant.Deny && grant.Role-1 < ceiling:
+			ceiling = grant.Role - 1
+		case !grant.Deny && grant.Role > allowed:
+			allowed = grant.Role
+		}
+	}
+
+	if allowed > ceiling {
+		return ceiling
+	}
+	return allowed
+}
+
+// inSubtree reports whether path is root or one of its descendants.
+func inSubtree(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+".")
+}
+
+// AuthorizedDriver wraps a driver with permission-aware variants of its queries and mutations,
+// made for the principal carried by their context. Folders the principal can't view are filtered out,
+// or reported as not found, so their existence doesn't leak.
+// Grants are keyed by path, and only the mutations of AuthorizedDriver keep them in step with the folders,
+// so the wrapped driver must not be mutated directly.
+type AuthorizedDriver struct {
+	driver IDriver
+	acl    *ACL
+}
+
+var _ IDriver = (*AuthorizedDriver)(nil)
+
+// NewAuthorizedDriver returns a driver checking the permissions of acl.
+func NewAuthorizedDriver(driver IDriver, acl *ACL) *AuthorizedDriver {
+	return &AuthorizedDriver{driver: driver, acl: acl}
+}
+
+// GetFoldersByOrgID returns the folders of an organization the principal can view.
+func (d *AuthorizedDriver) GetFoldersByOrgID(ctx context.Context, orgID uuid.UUID) ([]*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := d.driver.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return d.visible(p, folders), nil
+}
+
+// GetAllChildFolders returns the descendants the principal can view of a folder the principal can view,
+// the first one with the name if the principal can view several.
+func (d *AuthorizedDriver) GetAllChildFolders(ctx context.Context, orgID uuid.UUID, name string) ([]*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := d.findByName(ctx, p, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", name, orgID)
+		return []*Folder{}, nil
+	}
+
+	folders, err := d.driver.GetAllChildFoldersByPath(ctx, orgID, bases[0].Paths)
+	if err != nil {
+		return nil, err
+	}
+	return d.visible(p, folders), nil
+}
+
+// GetAllChildFoldersByPath returns the descendants the principal can view of the folder at path,
+// which the principal must be able to view.
+func (d *AuthorizedDriver) GetAllChildFoldersByPath(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if d.acl.Role(p, orgID, path) < RoleViewer {
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", path, orgID)
+		return nil, errorf(ErrFolderNotFound, "folder '%s' does not exist", path)
+	}
+
+	folders, err := d.driver.GetAllChildFoldersByPath(ctx, orgID, path)
+	if err != nil {
+		return nil, err
+	}
+	return d.visible(p, folders), nil
+}
+
+// MoveFolder moves a folder of the principal's organization, which requires the editor role
+// on the destination and on the folder and every descendant, so moves can't escape a deny.
+// The grants on the subtree move with it. It returns the folders the principal can view.
+// Names the principal can view on several folders are rejected with ErrInvalidMove; MoveFolderByPath moves them.
+func (d *AuthorizedDriver) MoveFolder(ctx context.Context, name string, dst string) ([]*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	source, err := d.findOneByName(ctx, p, name)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		logf(ctx, "Error: Source folder '%s' does not exist", name)
+		return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist", name)
+	}
+	dest, err := d.findOneByName(ctx, p, dst)
+	if err != nil {
+		return nil, err
+	}
+	if dest == nil {
+		logf(ctx, "Error: Destination folder '%s' does not exist", dst)
+		return nil, errorf(ErrFolderNotFound, "destination folder '%s' does not exist", dst)
+	}
+	return d.move(ctx, p, source.Paths, dest.Paths, name, dst)
+}
+
+// MoveFolderByPath moves the folder at path under the folder at dstPath, with the permissions of MoveFolder.
+func (d *AuthorizedDriver) MoveFolderByPath(ctx context.Context, orgID uuid.UUID, path string, dstPath string) ([]*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	source, err := d.findByPath(ctx, orgID, path)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil || d.acl.Role(p, orgID, path) < RoleViewer {
+		logf(ctx, "Error: Source folder '%s' does not exist", path)
+		return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist", path)
+	}
+	dest, err := d.findByPath(ctx, orgID, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	if dest == nil || d.acl.Role(p, orgID, dstPath) < RoleViewer {
+		logf(ctx, "Error: Destination folder '%s' does not exist", dstPath)
+		return nil, errorf(ErrFolderNotFound, "destination folder '%s' does not exist", dstPath)
+	}
+	return d.move(ctx, p, path, dstPath, path, dstPath)
+}
+
+// move moves the folder at path, which the principal can view, under the folder at dstPath once the
+// principal's roles allow it, naming them by label and dstLabel in errors. The driver is given the exact
+// paths the roles were checked on.
+func (d *AuthorizedDriver) move(ctx context.Context, p Principal, path, dstPath, label, dstLabel string) ([]*Folder, error) {
+	canMove, err := d.hasSubtreeRole(ctx, p, path, RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if !canMove {
+		logf(ctx, "Error: '%s' may not move folder '%s'", p.ID, label)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not move folder '%s'", p.ID, label)
+	}
+	if d.acl.Role(p, p.OrgID, dstPath) < RoleEditor {
+		logf(ctx, "Error: '%s' may not move folders into '%s'", p.ID, dstLabel)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not move folders into '%s'", p.ID, dstLabel)
+	}
+
+	newPath := dstPath + "." + path[strings.LastIndex(path, ".")+1:]
+	folders, err := d.driver.MoveFolderByPath(ctx, p.OrgID, path, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	d.acl.move(p.OrgID, path, newPath)
+	return d.visible(p, folders), nil
+}
+
+// TransferFolder transfers a folder of the principal's organization and its subtree under the folder at
+// dstPath of another organization. It requires the owner role on the folder and every descendant, and the
+// editor role on the destination from a grant of the destination organization to a subject of the principal's,
+// so both organizations agree to the transfer. Transfers to the root of another organization are left to
+// trusted callers. The grants of the principal's organization on the subtree are removed.
+func (d *AuthorizedDriver) TransferFolder(ctx context.Context, path string, dstOrgID uuid.UUID, dstPath string) ([]*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transferrer, ok := d.driver.(interface {
+		TransferFolder(ctx context.Context, srcOrgID uuid.UUID, path string, dstOrgID uuid.UUID, dstPath string) ([]*Folder, error)
+	})
+	if !ok {
+		logf(ctx, "Error: Driver does not support transfers")
+		return nil, errorf(ErrInvalidMove, "the driver does not support transfers")
+	}
+	source, err := d.findByPath(ctx, p.OrgID, path)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil || d.acl.Role(p, p.OrgID, path) < RoleViewer {
+		logf(ctx, "Error: Source folder '%s' does not exist", path)
+		return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist", path)
+	}
+
+	canTransfer, err := d.hasSubtreeRole(ctx, p, path, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if !canTransfer {
+		logf(ctx, "Error: '%s' may not transfer folder '%s'", p.ID, path)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not transfer folder '%s'", p.ID, path)
+	}
+	if dstPath == "" || d.acl.role(p, dstOrgID, dstPath) < RoleEditor {
+		logf(ctx, "Error: '%s' may not transfer folders into '%s' of orgID '%s'", p.ID, dstPath, dstOrgID)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not transfer folders into '%s' of orgID '%s'", p.ID, dstPath, dstOrgID)
+	}
+
+	folders, err := transferrer.TransferFolder(withTransferAuthorized(ctx), p.OrgID, path, dstOrgID, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	d.acl.drop(p.OrgID, path)
+	return folders, nil
+}
+
+// CreateFolder creates a folder under the folder at parentPath, which requires the editor role on the parent.
+// Root folders, with an empty parentPath, require the editor role granted on the empty path.
+func (d *AuthorizedDriver) CreateFolder(ctx context.Context, orgID uuid.UUID, name string, parentPath string) (*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	role := d.acl.Role(p, orgID, parentPath)
+	if role < RoleViewer && parentPath != "" {
+		logf(ctx, "Error: Parent folder '%s' does not exist in orgID '%s'", parentPath, orgID)
+		return nil, errorf(ErrFolderNotFound, "parent folder '%s' does not exist", parentPath)
+	}
+	if role < RoleEditor {
+		logf(ctx, "Error: '%s' may not create folders under '%s'", p.ID, parentPath)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not create folders under '%s'", p.ID, parentPath)
+	}
+
+	return d.driver.CreateFolder(ctx, orgID, name, parentPath)
+}
+
+// RenameFolder renames the folder at path, which requires the editor role on it. The grants on its subtree follow it.
+func (d *AuthorizedDriver) RenameFolder(ctx context.Context, orgID uuid.UUID, path string, name string) (*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	role := d.acl.Role(p, orgID, path)
+	if role < RoleViewer {
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", path, orgID)
+		return nil, errorf(ErrFolderNotFound, "folder '%s' does not exist", path)
+	}
+	if role < RoleEditor {
+		logf(ctx, "Error: '%s' may not rename folder '%s'", p.ID, path)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not rename folder '%s'", p.ID, path)
+	}
+
+	folder, err := d.driver.RenameFolder(ctx, orgID, path, name)
+	if err != nil {
+		return nil, err
+	}
+	d.acl.move(orgID, path, folder.Paths)
+	return folder, nil
+}
+
+// DeleteFolder deletes the folder at path with its subtree, which requires the editor role on the folder
+// and every descendant. The grants on the subtree are removed, so a folder later created or restored at
+// one of its paths doesn't inherit them. It returns the deleted folders.
+func (d *AuthorizedDriver) DeleteFolder(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if d.acl.Role(p, orgID, path) < RoleViewer {
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", path, orgID)
+		return nil, errorf(ErrFolderNotFound, "folder '%s' does not exist", path)
+	}
+	canDelete, err := d.hasSubtreeRole(ctx, p, path, RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if !canDelete {
+		logf(ctx, "Error: '%s' may not delete folder '%s'", p.ID, path)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not delete folder '%s'", p.ID, path)
+	}
+
+	deleted, err := d.driver.DeleteFolder(ctx, orgID, path)
+	if err != nil {
+		return nil, err
+	}
+	d.acl.drop(orgID, path)
+	return deleted, nil
+}
+
+// hasSubtreeRole reports whether the principal has at least role on the folder at path and all of its descendants.
+func (d *AuthorizedDriver) hasSubtreeRole(ctx context.Context, p Principal, path string, role Role) (bool, error) {
+	folders, err := d.driver.GetFoldersByOrgID(ctx, p.OrgID)
+	if err != nil {
+		return false, err
+	}
+	for _, folder := range folders {
+		if inSubtree(folder.Paths, path) && d.acl.Role(p, p.OrgID, folder.Paths) < role {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// principal returns the principal of ctx, which the authorized driver requires.
+func principal(ctx context.Context) (Principal, error) {
+	p, ok := PrincipalFrom(ctx)
+	if !ok {
+		logf(ctx, "Error: No principal in context")
+		return Principal{}, errorf(ErrPermissionDenied, "no principal in context")
+	}
+	return p, nil
+}
+
+// findByName returns the folders of an organization with the given name that the principal can view.
+func (d *AuthorizedDriver) findByName(ctx context.Context, p Principal, orgID uuid.UUID, name string) ([]*Folder, error) {
+	folders, err := d.driver.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Folder
+	for _, folder := range folders {
+		if folder.Name == name && d.acl.Role(p, orgID, folder.Paths) >= RoleViewer {
+			res = append(res, folder)
+		}
+	}
+	return res, nil
+}
+
+// findOneByName returns the folder of the principal's organization with the given name that the principal
+// can view, or nil. A name of several such folders is rejected, so a check of one can't authorize a move of another.
+func (d *AuthorizedDriver) findOneByName(ctx context.Context, p Principal, name string) (*Folder, error) {
+	folders, err := d.findByName(ctx, p, p.OrgID, name)
+	if err != nil {
+		return nil, err
+	}
+	switch len(folders) {
+	case 0:
+		return nil, nil
+	case 1:
+		return folders[0], nil
+	}
+	logf(ctx, "Error: Folder name '%s' is shared by %d folders, move them by path", name, len(folders))
+	return nil, errorf(ErrInvalidMove, "folder name '%s' is shared by %d folders, move them by path", name, len(folders))
+}
+
+// findByPath returns the folder of an organization with the given path, or nil.
+func (d *AuthorizedDriver) findByPath(ctx context.Context, orgID uuid.UUID, path string) (*Folder, error) {
+	folders, err := d.driver.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return FindByPath(folders, orgID, path), nil
+}
+
+// visible filters the folders the principal can view.
+func (d *AuthorizedDriver) visible(p Principal, folders []*Folder) []*Folder {
+	res := []*Folder{}
+	for _, folder := range folders {
+		if d.acl.Role(p, folder.OrgId, folder.Paths) >= RoleViewer {
+			res = append(res, folder)
+		}
+	}
+	return res
+}
@@ -0,0 +1,523 @@
+package folder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// names returns the names of folders.
+func names(folders []*folder.Folder) []string {
+	res := []string{}
+	for _, f := range folders {
+		res = append(res, f.Name)
+	}
+	return res
+}
+
+// Test_folder_ACL_Role tests inheritance, groups and deny grants.
+func Test_folder_ACL_Role(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.Must(uuid.NewV4())
+	otherOrgID := uuid.Must(uuid.NewV4())
+	acl := folder.NewACL(
+		folder.Grant{Subject: "alice", OrgID: orgID, Path: "alpha", Role: folder.RoleViewer},
+		folder.Grant{Subject: "alice", OrgID: orgID, Path: "alpha.bravo", Role: folder.RoleEditor},
+		folder.Grant{Subject: "support", OrgID: orgID, Path: "alpha", Role: folder.RoleOwner},
+		folder.Grant{Subject: "support", OrgID: orgID, Path: "alpha.delta", Role: folder.RoleEditor, Deny: true},
+		folder.Grant{Subject: "support", OrgID: orgID, Path: "alpha.delta.echo", Role: folder.RoleViewer, Deny: true},
+	)
+	alice := folder.Principal{ID: "alice", OrgID: orgID}
+	bob := folder.Principal{ID: "bob", OrgID: orgID, Groups: []string{"support"}}
+
+	tests := [...]struct {
+		name      string
+		principal folder.Principal
+		orgID     uuid.UUID
+		path      string
+		want      folder.Role
+	}{
+		{
+			name:      "Granted on the folder",
+			principal: alice,
+			orgID:     orgID,
+			path:      "alpha",
+			want:      folder.RoleViewer,
+		},
+		{
+			// The highest of the inherited and direct grants wins
+			name:      "Inherited and raised",
+			principal: alice,
+			orgID:     orgID,
+			path:      "alpha.bravo.charlie",
+			want:      folder.RoleEditor,
+		},
+		{
+			name:      "Inherited",
+			principal: alice,
+			orgID:     orgID,
+			path:      "alpha.delta",
+			want:      folder.RoleViewer,
+		},
+		{
+			// A grant on alpha doesn't apply to alphabet
+			name:      "Sibling with a common prefix",
+			principal: alice,
+			orgID:     orgID,
+			path:      "alphabet",
+			want:      folder.RoleNone,
+		},
+		{
+			name:      "Through a group",
+			principal: bob,
+			orgID:     orgID,
+			path:      "alpha.bravo",
+			want:      folder.RoleOwner,
+		},
+		{
+			// Denying editor leaves viewer
+			name:      "Deny caps the role",
+			principal: bob,
+			orgID:     orgID,
+			path:      "alpha.delta",
+			want:      folder.RoleViewer,
+		},
+		{
+			name:      "Deny hides the subtree",
+			principal: bob,
+			orgID:     orgID,
+			path:      "alpha.delta.echo.foxtrot",
+			want:      folder.RoleNone,
+		},
+		{
+			name:      "Another organization",
+			principal: folder.Principal{ID: "alice", OrgID: otherOrgID},
+			orgID:     orgID,
+			path:      "alpha",
+			want:      folder.RoleNone,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, acl.Role(tt.principal, tt.orgID, tt.path))
+		})
+	}
+}
+
+// Test_folder_ACL_Revoke tests that revoked grants no longer apply.
+func Test_folder_ACL_Revoke(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.Must(uuid.NewV4())
+	alice := folder.Principal{ID: "alice", OrgID: orgID}
+	acl := folder.NewACL()
+	acl.Grant(folder.Grant{Subject: "alice", OrgID: orgID, Path: "alpha", Role: folder.RoleEditor})
+	assert.Equal(t, folder.RoleEditor, acl.Role(alice, orgID, "alpha.bravo"))
+
+	assert.True(t, acl.Revoke("alice", orgID, "alpha"))
+	assert.False(t, acl.Revoke("alice", orgID, "alpha"))
+	assert.Equal(t, folder.RoleNone, acl.Role(alice, orgID, "alpha.bravo"))
+}
+
+// Test_folder_AuthorizedDriver tests that the driver filters and rejects according to the ACL.
+func Test_folder_AuthorizedDriver(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	acl := folder.NewACL(
+		folder.Grant{Subject: "alice", OrgID: orgID1, Path: "alpha", Role: folder.RoleEditor},
+		folder.Grant{Subject: "alice", OrgID: orgID1, Path: "alpha.delta", Role: folder.RoleEditor, Deny: true},
+		folder.Grant{Subject: "alice", OrgID: orgID1, Path: "golf", Role: folder.RoleViewer},
+		folder.Grant{Subject: "alice", OrgID: orgID1, Path: "alpha.delta.echo", Role: folder.RoleViewer, Deny: true},
+	)
+	alice := folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID1})
+
+	t.Run("GetFoldersByOrgID", func(t *testing.T) {
+		t.Parallel()
+		folders, _ := initializeFolders(orgID1, orgID2)
+		driver := folder.NewAuthorizedDriver(folder.NewDriver(folders), acl)
+
+		got, err := driver.GetFoldersByOrgID(alice, orgID1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alpha", "bravo", "charlie", "delta", "golf"}, names(got))
+
+		_, err = driver.GetFoldersByOrgID(alice, orgID2)
+		assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+
+		_, err = driver.GetFoldersByOrgID(context.Background(), orgID1)
+		assert.EqualError(t, err, "no principal in context")
+	})
+
+	t.Run("GetAllChildFolders", func(t *testing.T) {
+		t.Parallel()
+		folders, _ := initializeFolders(orgID1, orgID2)
+		driver := folder.NewAuthorizedDriver(folder.NewDriver(folders), acl)
+
+		got, err := driver.GetAllChildFolders(alice, orgID1, "alpha")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bravo", "charlie", "delta"}, names(got))
+
+		got, err = driver.GetAllChildFolders(alice, orgID1, "echo")
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	tests := [...]struct {
+		name    string
+		source  string
+		dst     string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			// Editor is denied on delta, which leaves viewer
+			name:    "Destination is view-only",
+			source:  "bravo",
+			dst:     "delta",
+			wantErr: folder.ErrPermissionDenied,
+			wantMsg: "'alice' may not move folders into 'delta'",
+		},
+		{
+			name:    "Source is view-only",
+			source:  "golf",
+			dst:     "alpha",
+			wantErr: folder.ErrPermissionDenied,
+			wantMsg: "'alice' may not move folder 'golf'",
+		},
+		{
+			// Hidden folders are reported as missing
+			name:    "Source is hidden",
+			source:  "echo",
+			dst:     "alpha",
+			wantErr: folder.ErrFolderNotFound,
+			wantMsg: "source folder 'echo' does not exist",
+		},
+		{
+			name:    "Destination in another organization",
+			source:  "bravo",
+			dst:     "foxtrot",
+			wantErr: folder.ErrFolderNotFound,
+			wantMsg: "destination folder 'foxtrot' does not exist",
+		},
+		{
+			name:   "Editor on both",
+			source: "bravo",
+			dst:    "alpha",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run("MoveFolder "+tt.name, func(t *testing.T) {
+			t.Parallel()
+			folders, _ := initializeFolders(orgID1, orgID2)
+			driver := folder.NewAuthorizedDriver(folder.NewDriver(folders), acl)
+
+			res, err := driver.MoveFolder(alice, tt.source, tt.dst)
+			if tt.wantMsg != "" {
+				assert.EqualError(t, err, tt.wantMsg)
+				assert.True(t, errors.Is(err, tt.wantErr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotContains(t, names(res), "foxtrot")
+			assert.NotContains(t, names(res), "echo")
+		})
+	}
+}
+
+// Test_folder_AuthorizedDriver_MoveSubtree tests that moves can't take denied folders out of reach of their denies.
+func Test_folder_AuthorizedDriver_MoveSubtree(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.Must(uuid.NewV4())
+	newFolders := func() []*folder.Folder {
+		p := &folder.Folder{Name: "p", OrgId: orgID, Paths: "p"}
+		q := &folder.Folder{Name: "q", OrgId: orgID, Paths: "q"}
+		x := &folder.Folder{Name: "x", OrgId: orgID, Paths: "p.x", Parent: p}
+		secret := &folder.Folder{Name: "secret", OrgId: orgID, Paths: "p.x.secret", Parent: x}
+		p.Children = []*folder.Folder{x}
+		x.Children = []*folder.Folder{secret}
+		return []*folder.Folder{p, q, x, secret}
+	}
+	bob := folder.WithPrincipal(context.Background(), folder.Principal{ID: "bob", OrgID: orgID})
+	carol := folder.Principal{ID: "carol", OrgID: orgID}
+
+	// A denied descendant blocks the move
+	acl := folder.NewACL(
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "p", Role: folder.RoleEditor},
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "q", Role: folder.RoleEditor},
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "p.x.secret", Role: folder.RoleViewer, Deny: true},
+	)
+	driver := folder.NewAuthorizedDriver(folder.NewDriver(newFolders()), acl)
+	_, err := driver.MoveFolder(bob, "x", "q")
+	assert.EqualError(t, err, "'bob' may not move folder 'x'")
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+	got, err := driver.GetFoldersByOrgID(bob, orgID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p", "q", "x"}, names(got))
+
+	// Grants on the subtree move with it, for every principal
+	acl = folder.NewACL(
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "p", Role: folder.RoleEditor},
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "q", Role: folder.RoleEditor},
+		folder.Grant{Subject: "carol", OrgID: orgID, Path: "q", Role: folder.RoleViewer},
+		folder.Grant{Subject: "carol", OrgID: orgID, Path: "p.x.secret", Role: folder.RoleViewer, Deny: true},
+	)
+	driver = folder.NewAuthorizedDriver(folder.NewDriver(newFolders()), acl)
+	_, err = driver.MoveFolder(bob, "x", "q")
+	assert.NoError(t, err)
+	assert.Equal(t, folder.RoleViewer, acl.Role(carol, orgID, "q.x"))
+	assert.Equal(t, folder.RoleNone, acl.Role(carol, orgID, "q.x.secret"))
+}
+
+// Test_folder_AuthorizedDriver_SameNames tests that moves check and move the same folder when names repeat.
+func Test_folder_AuthorizedDriver_SameNames(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.Must(uuid.NewV4())
+	alice := folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID})
+	newDriver := func(grants ...folder.Grant) (*folder.AuthorizedDriver, []*folder.Folder) {
+		a := &folder.Folder{Name: "a", OrgId: orgID, Paths: "a"}
+		x := &folder.Folder{Name: "x", OrgId: orgID, Paths: "a.x", Parent: a}
+		secret := &folder.Folder{Name: "secret", OrgId: orgID, Paths: "secret"}
+		c := &folder.Folder{Name: "c", OrgId: orgID, Paths: "c"}
+		a.Children = []*folder.Folder{x}
+		folders := []*folder.Folder{a, x, secret, c}
+		driver := folder.NewDriver(folders)
+		// The copy is secret.x, listed after a.x
+		_, err := driver.CopyFolder(context.Background(), orgID, "a.x", "secret", folder.CopyOptions{})
+		assert.NoError(t, err)
+		return folder.NewAuthorizedDriver(driver, folder.NewACL(grants...)), folders
+	}
+	editorOnA := folder.Grant{Subject: "alice", OrgID: orgID, Path: "a", Role: folder.RoleEditor}
+	editorOnC := folder.Grant{Subject: "alice", OrgID: orgID, Path: "c", Role: folder.RoleEditor}
+
+	// The hidden secret.x is neither checked nor moved
+	driver, folders := newDriver(editorOnA, editorOnC)
+	got, err := driver.MoveFolder(alice, "x", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "c.x", folders[1].Paths)
+	var paths []string
+	for _, f := range got {
+		paths = append(paths, f.Paths)
+	}
+	assert.ElementsMatch(t, []string{"a", "c", "c.x"}, paths)
+	_, err = driver.MoveFolderByPath(alice, orgID, "secret.x", "c")
+	assert.True(t, errors.Is(err, folder.ErrFolderNotFound))
+
+	// A name of several visible folders is ambiguous
+	driver, folders = newDriver(editorOnA, editorOnC, folder.Grant{Subject: "alice", OrgID: orgID, Path: "secret", Role: folder.RoleViewer})
+	_, err = driver.MoveFolder(alice, "x", "c")
+	assert.EqualError(t, err, "folder name 'x' is shared by 2 folders, move them by path")
+	assert.True(t, errors.Is(err, folder.ErrInvalidMove))
+	_, err = driver.MoveFolderByPath(alice, orgID, "secret.x", "c")
+	assert.EqualError(t, err, "'alice' may not move folder 'secret.x'")
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+	_, err = driver.MoveFolderByPath(alice, orgID, "a.x", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "c.x", folders[1].Paths)
+	children, err := driver.GetAllChildFoldersByPath(alice, orgID, "c")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x"}, names(children))
+}
+
+// Test_folder_AuthorizedDriver_RenameDelete tests that grants follow renamed folders and go with deleted ones.
+func Test_folder_AuthorizedDriver_RenameDelete(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.Must(uuid.NewV4())
+	a := &folder.Folder{Name: "a", OrgId: orgID, Paths: "a"}
+	secret := &folder.Folder{Name: "secret", OrgId: orgID, Paths: "a.secret", Parent: a}
+	a.Children = []*folder.Folder{secret}
+	acl := folder.NewACL(
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "a", Role: folder.RoleEditor},
+		folder.Grant{Subject: "carol", OrgID: orgID, Path: "a", Role: folder.RoleViewer},
+		folder.Grant{Subject: "carol", OrgID: orgID, Path: "a.secret", Role: folder.RoleViewer, Deny: true},
+		folder.Grant{Subject: "dave", OrgID: orgID, Path: "a.secret", Role: folder.RoleOwner},
+	)
+	driver := folder.NewAuthorizedDriver(folder.NewDriver([]*folder.Folder{a, secret}), acl)
+	ctx := context.Background()
+	bob := folder.WithPrincipal(ctx, folder.Principal{ID: "bob", OrgID: orgID})
+	carol := folder.WithPrincipal(ctx, folder.Principal{ID: "carol", OrgID: orgID})
+	dave := folder.Principal{ID: "dave", OrgID: orgID}
+
+	_, err := driver.RenameFolder(carol, orgID, "a", "b")
+	assert.EqualError(t, err, "'carol' may not rename folder 'a'")
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+
+	// The deny keeps hiding the renamed folder
+	renamed, err := driver.RenameFolder(bob, orgID, "a.secret", "public")
+	assert.NoError(t, err)
+	assert.Equal(t, "a.public", renamed.Paths)
+	got, err := driver.GetFoldersByOrgID(carol, orgID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, names(got))
+	assert.Equal(t, folder.RoleOwner, acl.Role(dave, orgID, "a.public"))
+
+	// A folder created at a deleted path doesn't inherit its grants
+	_, err = driver.DeleteFolder(carol, orgID, "a")
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+	_, err = driver.DeleteFolder(bob, orgID, "a.public")
+	assert.NoError(t, err)
+	assert.Equal(t, folder.RoleNone, acl.Role(dave, orgID, "a.public"))
+	assert.Equal(t, folder.RoleViewer, acl.Role(folder.Principal{ID: "carol", OrgID: orgID}, orgID, "a.public"))
+}
+
+// Test_folder_AuthorizedDriver_CreateFolder tests that creating a folder needs the editor role on its parent.
+func Test_folder_AuthorizedDriver_CreateFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.Must(uuid.NewV4())
+	a := &folder.Folder{Name: "a", OrgId: orgID, Paths: "a"}
+	secret := &folder.Folder{Name: "secret", OrgId: orgID, Paths: "a.secret", Parent: a}
+	a.Children = []*folder.Folder{secret}
+	acl := folder.NewACL(
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "a", Role: folder.RoleEditor},
+		folder.Grant{Subject: "bob", OrgID: orgID, Path: "a.secret", Role: folder.RoleViewer, Deny: true},
+		folder.Grant{Subject: "carol", OrgID: orgID, Path: "a", Role: folder.RoleViewer},
+		folder.Grant{Subject: "dave", OrgID: orgID, Path: "", Role: folder.RoleEditor},
+	)
+	var driver folder.IDriver = folder.NewAuthorizedDriver(folder.NewDriver([]*folder.Folder{a, secret}), acl)
+	ctx := context.Background()
+	bob := folder.WithPrincipal(ctx, folder.Principal{ID: "bob", OrgID: orgID})
+	carol := folder.WithPrincipal(ctx, folder.Principal{ID: "carol", OrgID: orgID})
+	dave := folder.WithPrincipal(ctx, folder.Principal{ID: "dave", OrgID: orgID})
+
+	created, err := driver.CreateFolder(bob, orgID, "b", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a.b", created.Paths)
+
+	_, err = driver.CreateFolder(carol, orgID, "c", "a")
+	assert.EqualError(t, err, "'carol' may not create folders under 'a'")
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+	_, err = driver.CreateFolder(bob, orgID, "c", "a.secret")
+	assert.EqualError(t, err, "parent folder 'a.secret' does not exist")
+	assert.True(t, errors.Is(err, folder.ErrFolderNotFound))
+
+	// Root folders need the editor role on the empty path
+	_, err = driver.CreateFolder(bob, orgID, "c", "")
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+	created, err = driver.CreateFolder(dave, orgID, "c", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "c", created.Paths)
+}
+
+// Test_folder_AuthorizedDriver_TransferFolder tests that transfers need both organizations' grants.
+func Test_folder_AuthorizedDriver_TransferFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	alice := folder.Principal{ID: "alice", OrgID: orgID1}
+	owner := folder.Grant{Subject: "alice", OrgID: orgID1, Path: "alpha", Role: folder.RoleOwner}
+	guest := folder.Grant{Subject: "alice", SubjectOrgID: orgID1, OrgID: orgID2, Path: "foxtrot", Role: folder.RoleEditor}
+	carol := folder.Grant{Subject: "carol", OrgID: orgID1, Path: "alpha.bravo.charlie", Role: folder.RoleViewer}
+
+	tests := [...]struct {
+		name         string
+		grants       []folder.Grant
+		path         string
+		dstPath      string
+		wantPaths    []string
+		expectedKind error
+	}{
+		{
+			name:      "Owner of the source, editor of the destination",
+			grants:    []folder.Grant{owner, guest, carol},
+			path:      "alpha.bravo",
+			dstPath:   "foxtrot",
+			wantPaths: []string{"foxtrot.bravo", "foxtrot.bravo.charlie"},
+		},
+		{
+			name:         "Editor of the source",
+			grants:       []folder.Grant{{Subject: "alice", OrgID: orgID1, Path: "alpha", Role: folder.RoleEditor}, guest},
+			path:         "alpha.bravo",
+			dstPath:      "foxtrot",
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			// A descendant the principal doesn't own can't be transferred away
+			name:         "Owner denied on a descendant",
+			grants:       []folder.Grant{owner, guest, {Subject: "alice", OrgID: orgID1, Path: "alpha.bravo.charlie", Role: folder.RoleOwner, Deny: true}},
+			path:         "alpha.bravo",
+			dstPath:      "foxtrot",
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name:         "No grant in the destination",
+			grants:       []folder.Grant{owner},
+			path:         "alpha.bravo",
+			dstPath:      "foxtrot",
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			// A grant to the destination's own 'alice' isn't a grant to the source's
+			name:         "Grant to a namesake of the destination",
+			grants:       []folder.Grant{owner, {Subject: "alice", OrgID: orgID2, Path: "foxtrot", Role: folder.RoleOwner}},
+			path:         "alpha.bravo",
+			dstPath:      "foxtrot",
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name:         "To the root",
+			grants:       []folder.Grant{owner, guest},
+			path:         "alpha.bravo",
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name:         "Hidden source",
+			grants:       []folder.Grant{guest},
+			path:         "alpha.bravo",
+			dstPath:      "foxtrot",
+			expectedKind: folder.ErrFolderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := folder.WithPrincipal(context.Background(), alice)
+			folders, _ := initializeFolders(orgID1, orgID2)
+			sink := &memorySink{}
+			acl := folder.NewACL(tt.grants...)
+			driver := folder.NewAuthorizedDriver(folder.NewDriver(folders, folder.WithAuditSink(sink)), acl)
+
+			transferred, err := driver.TransferFolder(ctx, tt.path, orgID2, tt.dstPath)
+			if tt.expectedKind != nil {
+				assert.True(t, errors.Is(err, tt.expectedKind), "got error %v", err)
+				assert.Empty(t, sink.entries, "no folder should be transferred")
+				return
+			}
+
+			assert.NoError(t, err)
+			var got []string
+			for _, f := range transferred {
+				got = append(got, f.Paths)
+			}
+			assert.Equal(t, tt.wantPaths, got)
+			assert.Len(t, sink.entries, 1)
+			assert.Equal(t, "alice", sink.entries[0].Actor)
+
+			// The grants on the subtree are dropped with it, the others kept
+			carol := folder.Principal{ID: "carol", OrgID: orgID1}
+			assert.Equal(t, folder.RoleNone, acl.Role(carol, orgID1, "alpha.bravo.charlie"))
+			assert.Equal(t, folder.RoleOwner, acl.Role(alice, orgID1, "alpha"))
+		})
+	}
+
+	// Grants to subjects of another organization give no role within it
+	acl := folder.NewACL(guest)
+	assert.Equal(t, folder.RoleNone, acl.Role(folder.Principal{ID: "alice", OrgID: orgID2}, orgID2, "foxtrot"))
+	assert.Equal(t, folder.RoleNone, acl.Role(alice, orgID2, "foxtrot"))
+}
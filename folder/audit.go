@@ -0,0 +1,162 @@
+package folder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// AuditOp is the kind of mutation an audit entry records.
+type AuditOp string
+
+const (
+	AuditCreate   AuditOp = "create"
+	AuditMove     AuditOp = "move"
+	AuditDelete   AuditOp = "delete"
+	AuditRename   AuditOp = "rename"
+	AuditRestore  AuditOp = "restore"
+	AuditCopy     AuditOp = "copy"
+	AuditTransfer AuditOp = "transfer"
+	AuditPurge    AuditOp = "purge"
+)
+
+// AuditEntry records a mutation of the folders. OldPath is empty for creations and NewPath for deletions.
+// A copy is recorded in the organization copied into, with the path of the source as OldPath. A transfer is
+// recorded in the source organization with the organization it went to as DstOrgID, which is nil otherwise.
+type AuditEntry struct {
+	Time      time.Time  `json:"time"`
+	Actor     string     `json:"actor"` // the principal's ID, empty for trusted callers
+	RequestID string     `json:"request_id,omitempty"`
+	OrgID     uuid.UUID  `json:"org_id"`
+	DstOrgID  *uuid.UUID `json:"dst_org_id,omitempty"`
+	Op        AuditOp    `json:"op"`
+	OldPath   string     `json:"old_path,omitempty"`
+	NewPath   string     `json:"new_path,omitempty"`
+	// Descendants is the number of folders below the mutated one that the mutation affected.
+	Descendants int `json:"descendants"`
+}
+
+// AuditSink is an append-only destination of audit entries.
+type AuditSink interface {
+	Append(entry AuditEntry) error
+}
+
+// JSONLAuditSink writes audit entries as JSON lines. It is safe for concurrent use.
+type JSONLAuditSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONLAuditSink returns a sink writing to w.
+func NewJSONLAuditSink(w io.Writer) *JSONLAuditSink {
+	return &JSONLAuditSink{w: w}
+}
+
+// OpenAuditFile returns a sink appending to the JSON lines file at path, created if needed.
+func OpenAuditFile(path string) (*JSONLAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLAuditSink{w: file, closer: file}, nil
+}
+
+// Close closes the file of a sink opened with OpenAuditFile.
+func (s *JSONLAuditSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// Append writes an entry on a line of its own.
+func (s *JSONLAuditSink) Append(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// AuditQuery selects audit entries. Zero fields match every entry.
+type AuditQuery struct {
+	// OrgID matches entries of the organization, and transfers to it.
+	OrgID uuid.UUID
+	// Path matches entries whose old or new path is the path or one of its descendants.
+	Path  string
+	Since time.Time // inclusive
+	Until time.Time // exclusive
+}
+
+// Matches reports whether the query selects an entry.
+func (q AuditQuery) Matches(entry AuditEntry) bool {
+	switch {
+	case q.OrgID != uuid.Nil && entry.OrgID != q.OrgID && (entry.DstOrgID == nil || *entry.DstOrgID != q.OrgID):
+		return false
+	case !q.Since.IsZero() && entry.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !entry.Time.Before(q.Until):
+		return false
+	case q.Path != "":
+		return (entry.OldPath != "" && inSubtree(entry.OldPath, q.Path)) || (entry.NewPath != "" && inSubtree(entry.NewPath, q.Path))
+	default:
+		return true
+	}
+}
+
+// ReadAuditLog returns the entries of a JSON lines audit log selected by q, in order.
+func ReadAuditLog(r io.Reader, q AuditQuery) ([]AuditEntry, error) {
+	var res []AuditEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", line, err)
+		}
+		if q.Matches(entry) {
+			res = append(res, entry)
+		}
+	}
+	return res, scanner.Err()
+}
+
+// audit appends an entry for a mutation made with ctx to the driver's sink, if it has one.
+// Drivers audit mutations before applying them, so none is applied unrecorded.
+func (f *driver) audit(ctx context.Context, entry AuditEntry) error {
+	if f.auditSink == nil {
+		return nil
+	}
+	entry.Time = time.Now().UTC()
+	entry.RequestID = RequestIDFrom(ctx)
+	if p, ok := PrincipalFrom(ctx); ok {
+		entry.Actor = p.ID
+	}
+	if err := f.auditSink.Append(entry); err != nil {
+		logf(ctx, "Error: Failed to audit %s of '%s': %v", entry.Op, entry.path(), err)
+		return fmt.Errorf("audit %s: %w", entry.Op, err)
+	}
+	return nil
+}
+
+// path returns the path of the folder an entry is about, before the mutation if it existed.
+func (e AuditEntry) path() string {
+	if e.OldPath != "" {
+		return e.OldPath
+	}
+	return e.NewPath
+}
@@ -0,0 +1,174 @@
+package folder_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// memorySink keeps audit entries in memory, or fails every append with err.
+type memorySink struct {
+	entries []folder.AuditEntry
+	err     error
+}
+
+func (s *memorySink) Append(entry folder.AuditEntry) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Test_folder_Audit tests the audit entries recorded for each mutation.
+func Test_folder_Audit(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	ctx := folder.WithRequestID(folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID1}), "req-1")
+
+	tests := [...]struct {
+		name   string
+		mutate func(d folder.IDriver) error
+		want   folder.AuditEntry
+	}{
+		{
+			name: "Move",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.MoveFolder(ctx, "bravo", "delta")
+				return err
+			},
+			want: folder.AuditEntry{Op: folder.AuditMove, OldPath: "alpha.bravo", NewPath: "alpha.delta.bravo", Descendants: 1},
+		},
+		{
+			name: "Create",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.CreateFolder(ctx, orgID1, "hotel", "golf")
+				return err
+			},
+			want: folder.AuditEntry{Op: folder.AuditCreate, NewPath: "golf.hotel"},
+		},
+		{
+			name: "Delete",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.DeleteFolder(ctx, orgID1, "alpha")
+				return err
+			},
+			want: folder.AuditEntry{Op: folder.AuditDelete, OldPath: "alpha", Descendants: 4},
+		},
+		{
+			name: "Rename",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.RenameFolder(ctx, orgID1, "alpha.delta", "hotel")
+				return err
+			},
+			want: folder.AuditEntry{Op: folder.AuditRename, OldPath: "alpha.delta", NewPath: "alpha.hotel", Descendants: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folders, _ := initializeFolders(orgID1, orgID2)
+			sink := &memorySink{}
+			before := time.Now()
+
+			assert.NoError(t, tt.mutate(folder.NewDriver(folders, folder.WithAuditSink(sink))))
+			assert.Len(t, sink.entries, 1)
+
+			got := sink.entries[0]
+			assert.False(t, got.Time.Before(before.Truncate(time.Second)))
+			got.Time = time.Time{}
+			tt.want.Actor, tt.want.RequestID, tt.want.OrgID = "alice", "req-1", orgID1
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Failed mutations are not audited", func(t *testing.T) {
+		t.Parallel()
+		folders, _ := initializeFolders(orgID1, orgID2)
+		sink := &memorySink{}
+
+		_, err := folder.NewDriver(folders, folder.WithAuditSink(sink)).MoveFolder(ctx, "bravo", "charlie")
+		assert.True(t, errors.Is(err, folder.ErrInvalidMove))
+		assert.Empty(t, sink.entries)
+	})
+
+	t.Run("Mutations are not applied if they can't be audited", func(t *testing.T) {
+		t.Parallel()
+		folders, folderMap := initializeFolders(orgID1, orgID2)
+		sink := &memorySink{err: errors.New("disk full")}
+
+		_, err := folder.NewDriver(folders, folder.WithAuditSink(sink)).MoveFolder(ctx, "bravo", "delta")
+		assert.EqualError(t, err, "audit move: disk full")
+		assert.Equal(t, "alpha.bravo", folderMap["bravo"].Paths)
+	})
+}
+
+// Test_folder_ReadAuditLog tests queries of a JSON lines audit log.
+func Test_folder_ReadAuditLog(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	day := time.Date(2024, 5, 14, 0, 0, 0, 0, time.UTC)
+	entries := []folder.AuditEntry{
+		{Time: day, Actor: "alice", OrgID: orgID1, Op: folder.AuditCreate, NewPath: "alpha.bravo"},
+		{Time: day.Add(time.Hour), Actor: "alice", OrgID: orgID1, Op: folder.AuditMove, OldPath: "alpha.bravo", NewPath: "golf.bravo", Descendants: 2},
+		{Time: day.Add(2 * time.Hour), Actor: "bob", OrgID: orgID1, Op: folder.AuditRename, OldPath: "golf", NewPath: "hotel", Descendants: 3},
+		{Time: day.Add(3 * time.Hour), Actor: "carol", OrgID: orgID2, Op: folder.AuditDelete, OldPath: "alpha"},
+		{Time: day.Add(4 * time.Hour), OrgID: orgID1, DstOrgID: &orgID2, Op: folder.AuditTransfer, OldPath: "delta", NewPath: "foxtrot.delta"},
+	}
+
+	var buf bytes.Buffer
+	sink := folder.NewJSONLAuditSink(&buf)
+	for _, entry := range entries {
+		assert.NoError(t, sink.Append(entry))
+	}
+	log := buf.String()
+	// Only transfers have a destination organization
+	assert.Equal(t, 1, strings.Count(log, `"dst_org_id"`))
+
+	tests := [...]struct {
+		name  string
+		query folder.AuditQuery
+		want  []folder.AuditEntry
+	}{
+		{name: "Everything", want: entries},
+		{
+			// Both the old and the new path match, including descendants
+			name:  "Path",
+			query: folder.AuditQuery{Path: "golf"},
+			want:  entries[1:3],
+		},
+		{name: "Destination organization", query: folder.AuditQuery{OrgID: orgID2}, want: entries[3:]},
+		{name: "Path and organization", query: folder.AuditQuery{OrgID: orgID1, Path: "alpha"}, want: entries[:2]},
+		{name: "Time range", query: folder.AuditQuery{Since: day.Add(time.Hour), Until: day.Add(3 * time.Hour)}, want: entries[1:3]},
+		{name: "No match", query: folder.AuditQuery{Path: "zulu"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := folder.ReadAuditLog(strings.NewReader(log), tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Invalid line", func(t *testing.T) {
+		t.Parallel()
+		_, err := folder.ReadAuditLog(strings.NewReader(log+"{\n"), folder.AuditQuery{})
+		assert.ErrorContains(t, err, "audit log line 6")
+	})
+}
@@ -0,0 +1,85 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gofrs/uuid"
+)
+
+// contextKey is the type of the keys of the values the driver reads from a context.
+type contextKey int
+
+const (
+	principalKey contextKey = iota
+	requestIDKey
+	transferAuthorizedKey
+)
+
+// cancelCheckInterval is the number of folders traversed between checks for cancellation.
+const cancelCheckInterval = 1024
+
+// WithPrincipal returns a context carrying the authenticated principal.
+// Driver calls made with it are limited to the principal's organization.
+// Calls made without a principal are trusted, as from the command line or tests.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFrom returns the principal carried by ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
+
+// WithRequestID returns a context carrying a request ID, which prefixes the driver's logs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFrom returns the request ID carried by ctx, or "".
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// withTransferAuthorized returns a context marking a transfer between organizations as authorized
+// for the principal of ctx, which the driver otherwise rejects. Only AuthorizedDriver sets it.
+func withTransferAuthorized(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transferAuthorizedKey, true)
+}
+
+// transferAuthorized reports whether ctx is marked by withTransferAuthorized.
+func transferAuthorized(ctx context.Context) bool {
+	ok, _ := ctx.Value(transferAuthorizedKey).(bool)
+	return ok
+}
+
+// authorizeOrg checks that ctx is done with neither cancellation nor a principal of another organization.
+func authorizeOrg(ctx context.Context, orgID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p, ok := PrincipalFrom(ctx); ok && p.OrgID != orgID {
+		logf(ctx, "Error: '%s' of orgID '%s' may not access orgID '%s'", p.ID, p.OrgID, orgID)
+		return errorf(ErrPermissionDenied, "'%s' may not access orgID '%s'", p.ID, orgID)
+	}
+	return nil
+}
+
+// checkCanceled returns the error of a canceled ctx every cancelCheckInterval iterations of a traversal.
+func checkCanceled(ctx context.Context, i int) error {
+	if i%cancelCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// logf logs like log.Printf, prefixed with the request ID carried by ctx.
+func logf(ctx context.Context, format string, args ...interface{}) {
+	if id := RequestIDFrom(ctx); id != "" {
+		format = fmt.Sprintf("[%s] %s", id, format)
+	}
+	log.Printf(format, args...)
+}
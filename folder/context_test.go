@@ -0,0 +1,153 @@
+package folder_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_PrincipalScope tests that a principal in the context limits driver calls to its organization.
+func Test_folder_PrincipalScope(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	ctx := folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID1})
+
+	tests := [...]struct {
+		name         string
+		call         func(d folder.IDriver) error
+		expectedKind error
+	}{
+		{
+			name: "GetFoldersByOrgID in own organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.GetFoldersByOrgID(ctx, orgID1)
+				return err
+			},
+		},
+		{
+			name: "GetFoldersByOrgID in another organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.GetFoldersByOrgID(ctx, orgID2)
+				return err
+			},
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name: "GetAllChildFolders in another organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.GetAllChildFolders(ctx, orgID2, "foxtrot")
+				return err
+			},
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name: "CreateFolder in another organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.CreateFolder(ctx, orgID2, "hotel", "foxtrot")
+				return err
+			},
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name: "DeleteFolder in another organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.DeleteFolder(ctx, orgID2, "foxtrot")
+				return err
+			},
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name: "RenameFolder in another organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.RenameFolder(ctx, orgID2, "foxtrot", "hotel")
+				return err
+			},
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			// Folders of other organizations aren't found by name
+			name: "MoveFolder of another organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.MoveFolder(ctx, "foxtrot", "golf")
+				return err
+			},
+			expectedKind: folder.ErrFolderNotFound,
+		},
+		{
+			name: "MoveFolder in own organization",
+			call: func(d folder.IDriver) error {
+				_, err := d.MoveFolder(ctx, "bravo", "golf")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folders, _ := initializeFolders(orgID1, orgID2)
+
+			err := tt.call(folder.NewDriver(folders))
+			if tt.expectedKind != nil {
+				assert.True(t, errors.Is(err, tt.expectedKind), "got error %v", err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// Test_folder_Canceled tests that driver calls stop when their context is canceled.
+func Test_folder_Canceled(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, _ := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := driver.GetFoldersByOrgID(ctx, orgID1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = driver.GetAllChildFolders(ctx, orgID1, "alpha")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = driver.MoveFolder(ctx, "bravo", "golf")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = driver.CreateFolder(ctx, orgID1, "hotel", "")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "alpha.bravo", folders[1].Paths, "nothing should be moved")
+}
+
+// Test_folder_RequestIDLogs tests that the request ID of the context prefixes the driver's logs.
+// It doesn't run in parallel as it redirects the standard logger.
+func Test_folder_RequestIDLogs(t *testing.T) {
+	var buf bytes.Buffer
+	out, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	}()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, _ := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders)
+
+	ctx := folder.WithRequestID(context.Background(), "req-42")
+	_, err := driver.MoveFolder(ctx, "zulu", "golf")
+	assert.Error(t, err)
+	assert.Equal(t, "[req-42] Error: Source folder 'zulu' does not exist\n", buf.String())
+}
@@ -0,0 +1,104 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+// CopyOptions configures CopyFolder.
+type CopyOptions struct {
+	// DstOrgID is the organization to copy into, the source's if nil.
+	DstOrgID uuid.UUID
+	// AllowCrossOrg allows copying into another organization than the source's.
+	AllowCrossOrg bool
+}
+
+// copyName returns the name of a copy of a folder under parent, the folder's own name unless a sibling
+// already has it, then "name-copy", "name-copy-2" and so on
+func (f *driver) copyName(orgID uuid.UUID, parent *Folder, name string) string {
+	prefix := ""
+	if parent != nil {
+		prefix = parent.Paths + "."
+	}
+	candidate := name
+	for i := 1; FindByPath(f.folders, orgID, prefix+candidate) != nil; i++ {
+		candidate = name + "-copy"
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-copy-%d", name, i)
+		}
+	}
+	return candidate
+}
+
+// copySubtree returns new folders copying a folder and its descendants, in depth-first order, with the
+// copy of the folder named name and placed under parent
+func copySubtree(folder *Folder, parent *Folder, name string, orgID uuid.UUID) []*Folder {
+	dup := &Folder{Name: name, OrgId: orgID, Paths: name, Parent: parent}
+	if parent != nil {
+		dup.Paths = parent.Paths + "." + name
+	}
+	copies := []*Folder{dup}
+	for _, child := range folder.Children {
+		childCopies := copySubtree(child, dup, child.Name, orgID)
+		dup.Children = append(dup.Children, childCopies[0])
+		copies = append(copies, childCopies...)
+	}
+	return copies
+}
+
+// CopyFolder copies the folder at src and its subtree under the folder at dst, or to the root if dst is
+// empty. The copies are new folders; the copy of src is renamed if a folder under dst already has its name.
+// The copies otherwise keep the names of their sources, so within an organization they are moved by path.
+// It returns the created folders, the copy of src first.
+func (f *driver) CopyFolder(ctx context.Context, orgID uuid.UUID, src string, dst string, opts CopyOptions) ([]*Folder, error) {
+	dstOrgID := orgID
+	if opts.DstOrgID != uuid.Nil {
+		dstOrgID = opts.DstOrgID
+	}
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if dstOrgID != orgID {
+		if !opts.AllowCrossOrg {
+			logf(ctx, "Error: Cannot copy folder '%s' to a different organization", src)
+			return nil, errorf(ErrInvalidMove, "cannot copy folder '%s' to a different organization", src)
+		}
+		if err := authorizeOrg(ctx, dstOrgID); err != nil {
+			return nil, err
+		}
+	}
+
+	source := FindByPath(f.folders, orgID, src)
+	if source == nil {
+		logf(ctx, "Error: Source folder '%s' does not exist in orgID '%s'", src, orgID)
+		return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist", src)
+	}
+	var parent *Folder
+	if dst != "" {
+		if parent = FindByPath(f.folders, dstOrgID, dst); parent == nil {
+			logf(ctx, "Error: Destination folder '%s' does not exist in orgID '%s'", dst, dstOrgID)
+			return nil, errorf(ErrFolderNotFound, "destination folder '%s' does not exist", dst)
+		}
+	}
+
+	// The whole subtree is copied before it is attached, so copying a folder into itself copies it once
+	copies := copySubtree(source, parent, f.copyName(dstOrgID, parent, source.Name), dstOrgID)
+	if err := f.audit(ctx, AuditEntry{OrgID: dstOrgID, Op: AuditCopy, OldPath: src, NewPath: copies[0].Paths, Descendants: len(copies) - 1}); err != nil {
+		return nil, err
+	}
+
+	if parent != nil {
+		parent.Children = append(parent.Children, copies[0])
+	}
+	f.folders = append(f.folders, copies...)
+
+	paths := make([]string, len(copies))
+	for i, c := range copies {
+		paths[i] = c.Paths
+	}
+	f.publish(ctx, &FolderCopied{EventMeta: EventMeta{OrgID: dstOrgID}, Source: src, Paths: paths})
+
+	return copies, nil
+}
@@ -0,0 +1,208 @@
+package folder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_CopyFolder tests the CopyFolder method.
+func Test_folder_CopyFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	tests := [...]struct {
+		name         string
+		ctx          context.Context
+		src          string
+		dst          string
+		opts         folder.CopyOptions
+		existing     []string // names created under dst before copying
+		wantOrgID    uuid.UUID
+		wantPaths    []string
+		expectedKind error
+	}{
+		{
+			name:      "Under another parent",
+			src:       "alpha.bravo",
+			dst:       "golf",
+			wantOrgID: orgID1,
+			wantPaths: []string{"golf.bravo", "golf.bravo.charlie"},
+		},
+		{
+			name:      "To the root",
+			src:       "alpha.delta",
+			wantOrgID: orgID1,
+			wantPaths: []string{"delta", "delta.echo"},
+		},
+		{
+			// The copy is made before it is attached, so it doesn't contain itself
+			name:      "Into its own subtree",
+			src:       "alpha",
+			dst:       "alpha.delta.echo",
+			wantOrgID: orgID1,
+			wantPaths: []string{
+				"alpha.delta.echo.alpha",
+				"alpha.delta.echo.alpha.bravo",
+				"alpha.delta.echo.alpha.bravo.charlie",
+				"alpha.delta.echo.alpha.delta",
+				"alpha.delta.echo.alpha.delta.echo",
+			},
+		},
+		{
+			name:      "Next to itself",
+			src:       "alpha.bravo",
+			dst:       "alpha",
+			wantOrgID: orgID1,
+			wantPaths: []string{"alpha.bravo-copy", "alpha.bravo-copy.charlie"},
+		},
+		{
+			name:      "Next to itself and a copy",
+			src:       "alpha.bravo",
+			dst:       "alpha",
+			existing:  []string{"bravo-copy", "bravo-copy-2"},
+			wantOrgID: orgID1,
+			wantPaths: []string{"alpha.bravo-copy-3", "alpha.bravo-copy-3.charlie"},
+		},
+		{
+			name:      "Into another organization",
+			src:       "alpha.bravo",
+			dst:       "foxtrot",
+			opts:      folder.CopyOptions{DstOrgID: orgID2, AllowCrossOrg: true},
+			wantOrgID: orgID2,
+			wantPaths: []string{"foxtrot.bravo", "foxtrot.bravo.charlie"},
+		},
+		{
+			name:         "Into another organization without allowing it",
+			src:          "alpha.bravo",
+			dst:          "foxtrot",
+			opts:         folder.CopyOptions{DstOrgID: orgID2},
+			expectedKind: folder.ErrInvalidMove,
+		},
+		{
+			// A principal only has access to its own organization
+			name:         "Into another organization as a principal",
+			ctx:          folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID1}),
+			src:          "alpha.bravo",
+			dst:          "foxtrot",
+			opts:         folder.CopyOptions{DstOrgID: orgID2, AllowCrossOrg: true},
+			expectedKind: folder.ErrPermissionDenied,
+		},
+		{
+			name:         "Source does not exist",
+			src:          "alpha.zulu",
+			dst:          "golf",
+			expectedKind: folder.ErrFolderNotFound,
+		},
+		{
+			// Destinations are looked up in the source's organization by default
+			name:         "Destination in another organization",
+			src:          "alpha.bravo",
+			dst:          "foxtrot",
+			expectedKind: folder.ErrFolderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			folders, _ := initializeFolders(orgID1, orgID2)
+			bus := folder.NewEventBus()
+			driver := folder.NewDriver(folders, folder.WithEventBus(bus))
+			for _, name := range tt.existing {
+				_, err := driver.CreateFolder(context.Background(), orgID1, name, tt.dst)
+				assert.NoError(t, err)
+			}
+			sub := bus.Subscribe(folder.SubscribeOptions{})
+			defer sub.Close()
+			before := paths(folders)
+
+			copies, err := driver.CopyFolder(ctx, orgID1, tt.src, tt.dst, tt.opts)
+			if tt.expectedKind != nil {
+				assert.True(t, errors.Is(err, tt.expectedKind), "got error %v", err)
+				got := append(paths(orgFolders(t, driver, orgID1)), paths(orgFolders(t, driver, orgID2))...)
+				assert.ElementsMatch(t, before, got, "no folder should be created")
+				return
+			}
+
+			assert.NoError(t, err)
+			var got []string
+			for _, c := range copies {
+				got = append(got, c.Paths)
+				assert.Equal(t, tt.wantOrgID, c.OrgId)
+			}
+			assert.Equal(t, tt.wantPaths, got)
+			assert.Subset(t, paths(orgFolders(t, driver, tt.wantOrgID)), paths(copies))
+			if parent := copies[0].Parent; parent != nil {
+				assert.Contains(t, parent.Children, copies[0])
+			}
+
+			// Every existing folder is left as it was
+			all := append(paths(orgFolders(t, driver, orgID1)), paths(orgFolders(t, driver, orgID2))...)
+			assert.Subset(t, all, before)
+
+			e := receive(t, sub).(*folder.FolderCopied)
+			assert.Equal(t, tt.wantOrgID, e.OrgID)
+			assert.Equal(t, tt.src, e.Source)
+			assert.Equal(t, tt.wantPaths, e.Paths)
+		})
+	}
+}
+
+// Test_folder_CopyFolder_Independent tests that copies share no folders with their source.
+func Test_folder_CopyFolder_Independent(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	ctx := context.Background()
+	folders, folderMap := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders)
+
+	copies, err := driver.CopyFolder(ctx, orgID1, "alpha.bravo", "golf", folder.CopyOptions{})
+	assert.NoError(t, err)
+	for _, c := range copies {
+		assert.NotSame(t, folderMap[c.Name], c)
+	}
+
+	// Renaming the copy leaves the source alone
+	_, err = driver.RenameFolder(ctx, orgID1, "golf.bravo", "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha.bravo.charlie", folderMap["charlie"].Paths)
+	assert.Equal(t, "golf.hotel.charlie", copies[1].Paths)
+}
+
+// Test_folder_CopyFolder_Move tests that after a copy, moves by name are rejected and moves by path move the source.
+func Test_folder_CopyFolder_Move(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	ctx := context.Background()
+	folders, folderMap := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders)
+
+	copies, err := driver.CopyFolder(ctx, orgID1, "alpha.bravo", "golf", folder.CopyOptions{})
+	assert.NoError(t, err)
+
+	_, err = driver.MoveFolder(ctx, "charlie", "delta")
+	assert.True(t, errors.Is(err, folder.ErrInvalidMove), "got error %v", err)
+	assert.Equal(t, "alpha.bravo.charlie", folderMap["charlie"].Paths)
+	assert.Equal(t, "golf.bravo.charlie", copies[1].Paths)
+
+	_, err = driver.MoveFolderByPath(ctx, orgID1, "alpha.bravo.charlie", "alpha.delta")
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha.delta.charlie", folderMap["charlie"].Paths)
+	assert.Equal(t, "golf.bravo.charlie", copies[1].Paths)
+}
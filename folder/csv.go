@@ -0,0 +1,202 @@
+package folder
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// Columns of the CSV format. name, org_id and paths are required;
+// id and order are optional.
+const (
+	csvName  = "name"
+	csvOrgID = "org_id"
+	csvPaths = "paths"
+	csvID    = "id"
+	csvOrder = "order"
+)
+
+// LineError is a validation error at a line of an imported file.
+type LineError struct {
+	Line int
+	Msg  string
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// lineErrorf creates a LineError with a formatted message.
+func lineErrorf(line int, format string, args ...interface{}) *LineError {
+	return &LineError{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// csvRow is a parsed CSV record with the line it was read from.
+type csvRow struct {
+	folder *Folder
+	line   int
+	order  int
+}
+
+// ReadCSV imports linked folders from CSV with a header row.
+// The optional id column is a spreadsheet row identifier that only has to be unique,
+// and the optional order column sorts the rows, and therefore siblings.
+// Every invalid row is reported as a *LineError joined into the returned error.
+func ReadCSV(r io.Reader) ([]*Folder, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[strings.TrimSpace(column)] = i
+	}
+	for _, column := range []string{csvName, csvOrgID, csvPaths} {
+		if _, exists := columns[column]; !exists {
+			return nil, lineErrorf(1, "missing required column '%s'", column)
+		}
+	}
+	idColumn, hasID := columns[csvID]
+	orderColumn, hasOrder := columns[csvOrder]
+
+	var errs []error
+	var rows []csvRow
+	ids := make(map[string]int)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				errs = append(errs, lineErrorf(parseErr.Line, "%v", parseErr.Err))
+				continue
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := csvRow{
+			folder: &Folder{
+				Name:  record[columns[csvName]],
+				Paths: record[columns[csvPaths]],
+			},
+			line: line,
+		}
+
+		orgID, err := uuid.FromString(record[columns[csvOrgID]])
+		if err != nil {
+			errs = append(errs, lineErrorf(line, "invalid org_id '%s'", record[columns[csvOrgID]]))
+			continue
+		}
+		row.folder.OrgId = orgID
+
+		if hasID {
+			id := record[idColumn]
+			if id == "" {
+				errs = append(errs, lineErrorf(line, "id is required when the id column is present"))
+				continue
+			}
+			if first, exists := ids[id]; exists {
+				errs = append(errs, lineErrorf(line, "duplicate id '%s', first used on line %d", id, first))
+				continue
+			}
+			ids[id] = line
+		}
+
+		if hasOrder {
+			row.order, err = strconv.Atoi(record[orderColumn])
+			if err != nil {
+				errs = append(errs, lineErrorf(line, "invalid order '%s'", record[orderColumn]))
+				continue
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].order < rows[j].order })
+	errs = append(errs, validateRows(rows)...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	folders := make([]*Folder, len(rows))
+	for i, row := range rows {
+		folders[i] = row.folder
+	}
+	if err := linkFolders(folders); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// validateRows checks names, paths and parents of the imported rows.
+func validateRows(rows []csvRow) []error {
+	var errs []error
+
+	byPath := make(map[string]int, len(rows))
+	for _, row := range rows {
+		key := pathKey(row.folder.OrgId, row.folder.Paths)
+		if first, exists := byPath[key]; exists {
+			errs = append(errs, lineErrorf(row.line, "duplicate path '%s', first used on line %d", row.folder.Paths, first))
+			continue
+		}
+		byPath[key] = row.line
+	}
+
+	for _, row := range rows {
+		folder := row.folder
+		if folder.Name == "" {
+			errs = append(errs, lineErrorf(row.line, "name is required"))
+			continue
+		}
+		if strings.Contains(folder.Name, ".") {
+			errs = append(errs, lineErrorf(row.line, "name '%s' must not contain '.'", folder.Name))
+			continue
+		}
+
+		idx := strings.LastIndex(folder.Paths, ".")
+		if folder.Paths[idx+1:] != folder.Name {
+			errs = append(errs, lineErrorf(row.line, "paths '%s' does not end with name '%s'", folder.Paths, folder.Name))
+			continue
+		}
+		if idx >= 0 {
+			if _, exists := byPath[pathKey(folder.OrgId, folder.Paths[:idx])]; !exists {
+				errs = append(errs, lineErrorf(row.line, "parent path '%s' does not exist", folder.Paths[:idx]))
+			}
+		}
+	}
+
+	return errs
+}
+
+// WriteCSV exports folders as CSV with the name, org_id and paths columns.
+func WriteCSV(w io.Writer, folders []*Folder) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{csvName, csvOrgID, csvPaths}); err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		if err := writer.Write([]string{folder.Name, folder.OrgId.String(), folder.Paths}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,112 @@
+package folder_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_CSV_RoundTrip tests that sample.json survives an export to CSV and back.
+func Test_folder_CSV_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sample := folder.GetSampleData()
+
+	var buf bytes.Buffer
+	assert.NoError(t, folder.WriteCSV(&buf, sample))
+
+	got, err := folder.ReadCSV(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, paths(sample), paths(got))
+
+	// Imported folders are linked so they can be moved straight away
+	for _, f := range got {
+		if strings.Contains(f.Paths, ".") {
+			assert.NotNil(t, f.Parent, "folder '%s' should have a parent", f.Paths)
+		}
+	}
+}
+
+// Test_folder_ReadCSV tests the optional columns and validation errors of ReadCSV.
+func Test_folder_ReadCSV(t *testing.T) {
+	t.Parallel()
+
+	const org = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+
+	tests := [...]struct {
+		name          string
+		input         string
+		want          []string
+		expectedError string
+	}{
+		{
+			name: "Required columns only",
+			input: "name,org_id,paths\n" +
+				"alpha," + org + ",alpha\n" +
+				"bravo," + org + ",alpha.bravo\n",
+			want: []string{org + "/alpha", org + "/alpha.bravo"},
+		},
+		{
+			name: "Order column sorts rows so parents may come later in the file",
+			input: "id,order,name,org_id,paths\n" +
+				"b,2,bravo," + org + ",alpha.bravo\n" +
+				"a,1,alpha," + org + ",alpha\n",
+			want: []string{org + "/alpha", org + "/alpha.bravo"},
+		},
+		{
+			name:          "Missing required column",
+			input:         "name,paths\nalpha,alpha\n",
+			expectedError: "line 1: missing required column 'org_id'",
+		},
+		{
+			name: "Every invalid row is reported with its line",
+			input: "id,name,org_id,paths\n" +
+				"1,alpha," + org + ",alpha\n" +
+				"2,bravo,not-a-uuid,alpha.bravo\n" +
+				"1,charlie," + org + ",alpha.charlie\n" +
+				"4,delta," + org + ",alpha.echo\n" +
+				"5,golf," + org + ",alpha.foxtrot.golf\n" +
+				"6,," + org + ",alpha.\n" +
+				"7,alpha," + org + ",alpha\n",
+			expectedError: strings.Join([]string{
+				"line 3: invalid org_id 'not-a-uuid'",
+				"line 4: duplicate id '1', first used on line 2",
+				"line 8: duplicate path 'alpha', first used on line 2",
+				"line 5: paths 'alpha.echo' does not end with name 'delta'",
+				"line 6: parent path 'alpha.foxtrot' does not exist",
+				"line 7: name is required",
+			}, "\n"),
+		},
+		{
+			name:          "Invalid order",
+			input:         "order,name,org_id,paths\nfirst,alpha," + org + ",alpha\n",
+			expectedError: "line 2: invalid order 'first'",
+		},
+		{
+			name:          "Wrong number of fields",
+			input:         "name,org_id,paths\nalpha," + org + "\n",
+			expectedError: "line 2: wrong number of fields",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := folder.ReadCSV(strings.NewReader(tt.input))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				var lineErr *folder.LineError
+				assert.True(t, errors.As(err, &lineErr), "errors should be line errors")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, paths(got))
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package folder
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FormatVersion is the current version of the folder dataset file format.
+//
+// Version history:
+//   - 0: bare JSON array of flat or nested folders, as in sample.json.
+//   - 1: {"format_version": 1, "folders": [...]} with flat folders.
+const FormatVersion = 1
+
+// DatasetSchema is the JSON Schema of the current dataset file format.
+//
+//go:embed schema/folders.schema.json
+var DatasetSchema []byte
+
+// Dataset is the top-level envelope of a folder dataset file.
+type Dataset struct {
+	FormatVersion int       `json:"format_version"`
+	Folders       []*Folder `json:"folders"`
+}
+
+// upgraders[v] upgrades a dataset from format version v to v+1.
+var upgraders = map[int]func([]byte) ([]byte, error){
+	0: upgradeBareArray,
+}
+
+// SchemaError is a dataset validation error at a JSON Pointer into the file.
+type SchemaError struct {
+	Path string
+	Msg  string
+}
+
+func (e *SchemaError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Msg)
+}
+
+// MarshalDataset converts folders to the current dataset file format.
+func MarshalDataset(folders []*Folder) ([]byte, error) {
+	if folders == nil {
+		folders = []*Folder{}
+	}
+	return json.MarshalIndent(Dataset{FormatVersion: FormatVersion, Folders: folders}, "", "\t")
+}
+
+// LoadDataset upgrades a dataset file of any known version, validates it against
+// DatasetSchema and returns its folders linked through Parent and Children.
+// Every validation error is reported as a *SchemaError joined into the returned error.
+func LoadDataset(data []byte) ([]*Folder, error) {
+	data, err := UpgradeDataset(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset: %w", err)
+	}
+	if errs := datasetSchema.validate(value, ""); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var dataset Dataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset: %w", err)
+	}
+	if errs := validateDatasetFolders(dataset.Folders); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := linkFolders(dataset.Folders); err != nil {
+		return nil, err
+	}
+
+	return dataset.Folders, nil
+}
+
+// UpgradeDataset rewrites a dataset file of any known version in the current format.
+func UpgradeDataset(data []byte) ([]byte, error) {
+	version, err := datasetVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version > FormatVersion {
+		return nil, fmt.Errorf("format_version %d is newer than the supported version %d", version, FormatVersion)
+	}
+
+	for ; version < FormatVersion; version++ {
+		upgrade, exists := upgraders[version]
+		if !exists {
+			return nil, fmt.Errorf("no upgrader from format_version %d", version)
+		}
+		data, err = upgrade(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade from format_version %d: %w", version, err)
+		}
+	}
+
+	return data, nil
+}
+
+// datasetVersion returns the format version of a dataset file; a bare array is version 0.
+func datasetVersion(data []byte) (int, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return 0, nil
+	}
+
+	var envelope struct {
+		FormatVersion *int `json:"format_version"`
+	}
+	if err := json.Unmarshal(trimmed, &envelope); err != nil {
+		return 0, fmt.Errorf("failed to parse dataset: %w", err)
+	}
+	if envelope.FormatVersion == nil {
+		return 0, &SchemaError{Path: "", Msg: "missing required property 'format_version'"}
+	}
+
+	return *envelope.FormatVersion, nil
+}
+
+// upgradeBareArray wraps a bare array of folders in the version 1 envelope, flattening nested folders.
+func upgradeBareArray(data []byte) ([]byte, error) {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	folders := json.RawMessage(data)
+	if len(items) > 0 {
+		if _, nested := items[0]["folders"]; nested {
+			loaded, err := UnmarshalFolders(data)
+			if err != nil {
+				return nil, err
+			}
+			folders = MarshalJson(loaded)
+		}
+	}
+
+	return json.Marshal(struct {
+		FormatVersion int             `json:"format_version"`
+		Folders       json.RawMessage `json:"folders"`
+	}{FormatVersion: 1, Folders: folders})
+}
+
+// validateDatasetFolders checks the rules the schema can't express: names match paths and parents exist.
+func validateDatasetFolders(folders []*Folder) []error {
+	var errs []error
+
+	byPath := make(map[string]int, len(folders))
+	for i, folder := range folders {
+		key := pathKey(folder.OrgId, folder.Paths)
+		if first, exists := byPath[key]; exists {
+			errs = append(errs, &SchemaError{
+				Path: fmt.Sprintf("/folders/%d/paths", i),
+				Msg:  fmt.Sprintf("duplicate path '%s', first used at /folders/%d", folder.Paths, first),
+			})
+			continue
+		}
+		byPath[key] = i
+	}
+
+	for i, folder := range folders {
+		idx := strings.LastIndex(folder.Paths, ".")
+		if folder.Paths[idx+1:] != folder.Name {
+			errs = append(errs, &SchemaError{
+				Path: fmt.Sprintf("/folders/%d/name", i),
+				Msg:  fmt.Sprintf("name '%s' is not the last label of paths '%s'", folder.Name, folder.Paths),
+			})
+			continue
+		}
+		if idx >= 0 {
+			if _, exists := byPath[pathKey(folder.OrgId, folder.Paths[:idx])]; !exists {
+				errs = append(errs, &SchemaError{
+					Path: fmt.Sprintf("/folders/%d/paths", i),
+					Msg:  fmt.Sprintf("parent path '%s' does not exist", folder.Paths[:idx]),
+				})
+			}
+		}
+	}
+
+	return errs
+}
+
+// jsonSchema is the subset of JSON Schema used by DatasetSchema.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Const                interface{}            `json:"const"`
+	Pattern              string                 `json:"pattern"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Items                *jsonSchema            `json:"items"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+
+	root    *jsonSchema
+	pattern *regexp.Regexp
+}
+
+var datasetSchema = mustParseSchema(DatasetSchema)
+
+// mustParseSchema parses a schema and compiles its patterns, panicking on error like regexp.MustCompile.
+func mustParseSchema(data []byte) *jsonSchema {
+	var root jsonSchema
+	if err := json.Unmarshal(data, &root); err != nil {
+		panic(err)
+	}
+
+	var prepare func(s *jsonSchema)
+	prepare = func(s *jsonSchema) {
+		if s == nil {
+			return
+		}
+		s.root = &root
+		if s.Pattern != "" {
+			s.pattern = regexp.MustCompile(s.Pattern)
+		}
+		for _, child := range s.Properties {
+			prepare(child)
+		}
+		for _, child := range s.Defs {
+			prepare(child)
+		}
+		prepare(s.Items)
+	}
+	prepare(&root)
+
+	return &root
+}
+
+// validate checks a value decoded with UseNumber against the schema; path is the value's JSON Pointer.
+func (s *jsonSchema) validate(value interface{}, path string) []error {
+	if s.Ref != "" {
+		def, exists := s.root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !exists {
+			return []error{&SchemaError{Path: path, Msg: fmt.Sprintf("unresolved reference '%s'", s.Ref)}}
+		}
+		return def.validate(value, path)
+	}
+
+	if s.Const != nil {
+		want, _ := json.Marshal(s.Const)
+		got, _ := json.Marshal(value)
+		if !bytes.Equal(want, got) {
+			return []error{&SchemaError{Path: path, Msg: fmt.Sprintf("must be %s", want)}}
+		}
+	}
+
+	if s.Type != "" && jsonType(value) != s.Type && !(s.Type == "number" && jsonType(value) == "integer") {
+		return []error{&SchemaError{Path: path, Msg: fmt.Sprintf("expected %s, got %s", s.Type, jsonType(value))}}
+	}
+
+	var errs []error
+	switch v := value.(type) {
+	case string:
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			errs = append(errs, &SchemaError{Path: path, Msg: fmt.Sprintf("'%s' does not match pattern '%s'", v, s.Pattern)})
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.validate(item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, exists := v[name]; !exists {
+				errs = append(errs, &SchemaError{Path: path, Msg: fmt.Sprintf("missing required property '%s'", name)})
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, exists := s.Properties[name]
+			if !exists {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs = append(errs, &SchemaError{Path: path + "/" + name, Msg: "unknown property"})
+				}
+				continue
+			}
+			errs = append(errs, property.validate(v[name], path+"/"+name)...)
+		}
+	}
+
+	return errs
+}
+
+// jsonType returns the JSON Schema type name of a value decoded with UseNumber.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,138 @@
+package folder_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_DatasetSchema tests that the published schema is valid JSON for the current format version.
+func Test_folder_DatasetSchema(t *testing.T) {
+	t.Parallel()
+
+	var schema struct {
+		Properties struct {
+			FormatVersion struct {
+				Const int `json:"const"`
+			} `json:"format_version"`
+		} `json:"properties"`
+	}
+	assert.NoError(t, json.Unmarshal(folder.DatasetSchema, &schema))
+	assert.Equal(t, folder.FormatVersion, schema.Properties.FormatVersion.Const)
+}
+
+// Test_folder_Dataset_RoundTrip tests that sample.json, a bare array, is upgraded and survives the envelope.
+func Test_folder_Dataset_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sample := folder.GetSampleData()
+
+	data, err := folder.MarshalDataset(sample)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), `"format_version": 1`))
+
+	got, err := folder.LoadDataset(data)
+	assert.NoError(t, err)
+	assert.Equal(t, paths(sample), paths(got))
+}
+
+// Test_folder_LoadDataset tests versions, upgrades and validation errors of LoadDataset.
+func Test_folder_LoadDataset(t *testing.T) {
+	t.Parallel()
+
+	const org = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+
+	tests := [...]struct {
+		name          string
+		input         string
+		want          []string
+		expectedError string
+	}{
+		{
+			name:  "Current version",
+			input: `{"format_version": 1, "folders": [{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"}]}`,
+			want:  []string{org + "/alpha"},
+		},
+		{
+			name:  "Bare flat array is upgraded",
+			input: `[{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"}]`,
+			want:  []string{org + "/alpha"},
+		},
+		{
+			name:  "Bare nested array is upgraded",
+			input: `[{"org_id": "` + org + `", "folders": [{"name": "alpha", "children": [{"name": "bravo"}]}]}]`,
+			want:  []string{org + "/alpha", org + "/alpha.bravo"},
+		},
+		{
+			name:          "Newer version",
+			input:         `{"format_version": 2, "folders": []}`,
+			expectedError: "format_version 2 is newer than the supported version 1",
+		},
+		{
+			name:          "Missing version",
+			input:         `{"folders": []}`,
+			expectedError: "/: missing required property 'format_version'",
+		},
+		{
+			name: "Schema errors are reported with their path",
+			input: `{"format_version": 1, "extra": true, "folders": [
+				{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"},
+				{"name": "bravo", "org_id": "not-a-uuid", "paths": "alpha.bravo"},
+				{"name": 7, "paths": "alpha..charlie"}
+			]}`,
+			expectedError: strings.Join([]string{
+				"/extra: unknown property",
+				"/folders/1/org_id: 'not-a-uuid' does not match pattern '^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$'",
+				"/folders/2: missing required property 'org_id'",
+				"/folders/2/name: expected string, got integer",
+				"/folders/2/paths: 'alpha..charlie' does not match pattern '^[^.]+(\\.[^.]+)*$'",
+			}, "\n"),
+		},
+		{
+			name: "Paths inconsistencies are reported with their path",
+			input: `{"format_version": 1, "folders": [
+				{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"},
+				{"name": "bravo", "org_id": "` + org + `", "paths": "alpha.charlie"},
+				{"name": "echo", "org_id": "` + org + `", "paths": "delta.echo"},
+				{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"}
+			]}`,
+			expectedError: strings.Join([]string{
+				"/folders/3/paths: duplicate path 'alpha', first used at /folders/0",
+				"/folders/1/name: name 'bravo' is not the last label of paths 'alpha.charlie'",
+				"/folders/2/paths: parent path 'delta' does not exist",
+			}, "\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := folder.LoadDataset([]byte(tt.input))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, paths(got))
+		})
+	}
+}
+
+// Test_folder_UpgradeDataset tests that a bare array is rewritten in the current format.
+func Test_folder_UpgradeDataset(t *testing.T) {
+	t.Parallel()
+
+	got, err := folder.UpgradeDataset([]byte(`[{"name": "alpha", "org_id": "c1556e17-b7c0-45a3-a6ae-9546248fb17a", "paths": "alpha"}]`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"format_version": 1, "folders": [{"name": "alpha", "org_id": "c1556e17-b7c0-45a3-a6ae-9546248fb17a", "paths": "alpha"}]}`, string(got))
+
+	_, err = folder.LoadDataset([]byte(`{"format_version": 1, "folders": "alpha"}`))
+	var schemaErr *folder.SchemaError
+	assert.True(t, errors.As(err, &schemaErr))
+	assert.Equal(t, "/folders", schemaErr.Path)
+}
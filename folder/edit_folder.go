@@ -0,0 +1,156 @@
+package folder
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// validateName checks that a folder name can be used as an ltree label
+func validateName(name string) error {
+	if name == "" || strings.Contains(name, ".") {
+		return errorf(ErrInvalidName, "invalid folder name '%s'", name)
+	}
+	return nil
+}
+
+// subtree returns the folder at the given path and all of its descendants, found by path prefix
+func (f *driver) subtree(orgID uuid.UUID, path string) []*Folder {
+	var res []*Folder
+	for _, folder := range f.folders {
+		if folder.OrgId == orgID && (folder.Paths == path || strings.HasPrefix(folder.Paths, path+".")) {
+			res = append(res, folder)
+		}
+	}
+	return res
+}
+
+// CreateFolder creates a folder under the folder at parentPath, or a root folder if parentPath is empty
+// Its name may be used by folders under other parents, which MoveFolder then rejects in favour of MoveFolderByPath
+func (f *driver) CreateFolder(ctx context.Context, orgID uuid.UUID, name string, parentPath string) (*Folder, error) {
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if err := validateName(name); err != nil {
+		logf(ctx, "Error: %v", err)
+		return nil, err
+	}
+
+	folder := &Folder{Name: name, OrgId: orgID, Paths: name}
+	if parentPath != "" {
+		parent := FindByPath(f.folders, orgID, parentPath)
+		if parent == nil {
+			logf(ctx, "Error: Parent folder '%s' does not exist in orgID '%s'", parentPath, orgID)
+			return nil, errorf(ErrFolderNotFound, "parent folder '%s' does not exist", parentPath)
+		}
+		folder.Parent = parent
+		folder.Paths = parent.Paths + "." + name
+	}
+
+	if FindByPath(f.folders, orgID, folder.Paths) != nil {
+		logf(ctx, "Error: Folder '%s' already exists in orgID '%s'", folder.Paths, orgID)
+		return nil, errorf(ErrFolderExists, "folder '%s' already exists", folder.Paths)
+	}
+
+	if err := f.audit(ctx, AuditEntry{OrgID: orgID, Op: AuditCreate, NewPath: folder.Paths}); err != nil {
+		return nil, err
+	}
+
+	if folder.Parent != nil {
+		folder.Parent.Children = append(folder.Parent.Children, folder)
+	}
+	f.folders = append(f.folders, folder)
+	f.publish(ctx, &FolderCreated{EventMeta: EventMeta{OrgID: orgID}, Path: folder.Paths})
+
+	return folder, nil
+}
+
+// DeleteFolder deletes the folder at path with its subtree and returns the deleted folders
+func (f *driver) DeleteFolder(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error) {
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	folder := FindByPath(f.folders, orgID, path)
+	if folder == nil {
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", path, orgID)
+		return nil, errorf(ErrFolderNotFound, "folder '%s' does not exist", path)
+	}
+
+	deleted := f.subtree(orgID, path)
+	if err := f.audit(ctx, AuditEntry{OrgID: orgID, Op: AuditDelete, OldPath: path, Descendants: len(deleted) - 1}); err != nil {
+		return nil, err
+	}
+	isDeleted := make(map[*Folder]bool, len(deleted))
+	for _, d := range deleted {
+		isDeleted[d] = true
+	}
+
+	remaining := make([]*Folder, 0, len(f.folders)-len(deleted))
+	for _, other := range f.folders {
+		if !isDeleted[other] {
+			remaining = append(remaining, other)
+		}
+	}
+	f.folders = remaining
+
+	removeChild(folder.Parent, folder)
+	folder.Parent = nil
+	f.trashDeleted(ctx, orgID, path, deleted)
+
+	paths := make([]string, len(deleted))
+	for i, d := range deleted {
+		paths[i] = d.Paths
+	}
+	f.publish(ctx, &FolderDeleted{EventMeta: EventMeta{OrgID: orgID}, Paths: paths})
+
+	return deleted, nil
+}
+
+// RenameFolder renames the folder at path, updating the paths of its subtree
+func (f *driver) RenameFolder(ctx context.Context, orgID uuid.UUID, path string, name string) (*Folder, error) {
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if err := validateName(name); err != nil {
+		logf(ctx, "Error: %v", err)
+		return nil, err
+	}
+
+	folder := FindByPath(f.folders, orgID, path)
+	if folder == nil {
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", path, orgID)
+		return nil, errorf(ErrFolderNotFound, "folder '%s' does not exist", path)
+	}
+
+	newPath := name
+	if idx := strings.LastIndex(path, "."); idx >= 0 {
+		newPath = path[:idx] + "." + name
+	}
+	if newPath == path {
+		return folder, nil
+	}
+	if FindByPath(f.folders, orgID, newPath) != nil {
+		logf(ctx, "Error: Folder '%s' already exists in orgID '%s'", newPath, orgID)
+		return nil, errorf(ErrFolderExists, "folder '%s' already exists", newPath)
+	}
+
+	renamed := f.subtree(orgID, path)
+	if err := f.audit(ctx, AuditEntry{OrgID: orgID, Op: AuditRename, OldPath: path, NewPath: newPath, Descendants: len(renamed) - 1}); err != nil {
+		return nil, err
+	}
+
+	changes := make([]PathChange, len(renamed))
+	for i, d := range renamed {
+		changes[i].Old = d.Paths
+		d.Paths = newPath + strings.TrimPrefix(d.Paths, path)
+		changes[i].New = d.Paths
+	}
+	folder.Name = name
+	// The renamed folder is the shallowest, so it comes first
+	sort.SliceStable(changes, func(i, j int) bool { return len(changes[i].Old) < len(changes[j].Old) })
+	f.publish(ctx, &FolderMoved{EventMeta: EventMeta{OrgID: orgID}, Changes: changes})
+
+	return folder, nil
+}
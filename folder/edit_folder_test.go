@@ -0,0 +1,207 @@
+package folder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// orgFolders returns the folders of one or more organizations, failing the test on error.
+func orgFolders(t *testing.T, driver folder.IDriver, orgIDs ...uuid.UUID) []*folder.Folder {
+	t.Helper()
+	var res []*folder.Folder
+	for _, orgID := range orgIDs {
+		folders, err := driver.GetFoldersByOrgID(context.Background(), orgID)
+		assert.NoError(t, err)
+		res = append(res, folders...)
+	}
+	return res
+}
+
+// Test_folder_CreateFolder tests the CreateFolder method.
+func Test_folder_CreateFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	tests := [...]struct {
+		name         string
+		orgID        uuid.UUID
+		folderName   string
+		parent       string
+		wantPath     string
+		expectedKind error
+	}{
+		{name: "Root folder", orgID: orgID1, folderName: "hotel", parent: "", wantPath: "hotel"},
+		{name: "Nested folder", orgID: orgID1, folderName: "hotel", parent: "alpha.bravo", wantPath: "alpha.bravo.hotel"},
+		{name: "Same name under another parent", orgID: orgID1, folderName: "charlie", parent: "alpha.delta", wantPath: "alpha.delta.charlie"},
+		{name: "Existing path", orgID: orgID1, folderName: "charlie", parent: "alpha.bravo", expectedKind: folder.ErrFolderExists},
+		{name: "Parent does not exist", orgID: orgID1, folderName: "hotel", parent: "alpha.zulu", expectedKind: folder.ErrFolderNotFound},
+		{name: "Parent in another organization", orgID: orgID2, folderName: "hotel", parent: "alpha", expectedKind: folder.ErrFolderNotFound},
+		{name: "Empty name", orgID: orgID1, folderName: "", parent: "alpha", expectedKind: folder.ErrInvalidName},
+		{name: "Name with a dot", orgID: orgID1, folderName: "a.b", parent: "alpha", expectedKind: folder.ErrInvalidName},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folders, folderMap := initializeFolders(orgID1, orgID2)
+			driver := folder.NewDriver(folders)
+
+			got, err := driver.CreateFolder(context.Background(), tt.orgID, tt.folderName, tt.parent)
+			if tt.expectedKind != nil {
+				assert.True(t, errors.Is(err, tt.expectedKind), "got error %v", err)
+				assert.Len(t, orgFolders(t, driver, orgID1), 6, "no folder should be created")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPath, got.Paths)
+			assert.Contains(t, orgFolders(t, driver, tt.orgID), got)
+			if tt.parent != "" {
+				assert.Equal(t, tt.parent, got.Parent.Paths)
+				assert.Contains(t, got.Parent.Children, got)
+			}
+			assert.NotContains(t, folderMap["golf"].Children, got)
+		})
+	}
+}
+
+// Test_folder_CreateFolder_SameName tests that a created folder sharing a name with another is only moved by path.
+func Test_folder_CreateFolder_SameName(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, folderMap := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders)
+	ctx := context.Background()
+
+	created, err := driver.CreateFolder(ctx, orgID1, "charlie", "golf")
+	assert.NoError(t, err)
+
+	_, err = driver.MoveFolder(ctx, "charlie", "delta")
+	assert.EqualError(t, err, "folder name 'charlie' is shared by 2 folders, move them by path")
+	assert.True(t, errors.Is(err, folder.ErrInvalidMove))
+	_, err = driver.MoveFolder(ctx, "echo", "charlie")
+	assert.True(t, errors.Is(err, folder.ErrInvalidMove))
+
+	_, err = driver.MoveFolderByPath(ctx, orgID1, "golf.charlie", "alpha.delta")
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha.delta.charlie", created.Paths)
+	assert.Equal(t, "alpha.bravo.charlie", folderMap["charlie"].Paths)
+
+	// Within an organization, names of other organizations don't count
+	_, err = driver.CreateFolder(ctx, orgID2, "golf", "")
+	assert.NoError(t, err)
+	_, err = driver.MoveFolder(folder.WithPrincipal(ctx, folder.Principal{OrgID: orgID1}), "golf", "alpha")
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha.golf", folderMap["golf"].Paths)
+}
+
+// Test_folder_DeleteFolder tests the DeleteFolder method.
+func Test_folder_DeleteFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	folders, folderMap := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders)
+
+	// Deleting bravo deletes charlie with it
+	deleted, err := driver.DeleteFolder(context.Background(), orgID1, "alpha.bravo")
+	assert.NoError(t, err)
+	assert.Equal(t, []*folder.Folder{folderMap["bravo"], folderMap["charlie"]}, deleted)
+	assert.Equal(t, []*folder.Folder{folderMap["alpha"], folderMap["delta"], folderMap["echo"], folderMap["golf"]}, orgFolders(t, driver, orgID1))
+	assert.Equal(t, []*folder.Folder{folderMap["delta"]}, folderMap["alpha"].Children)
+
+	// Folders must exist in the given organization
+	_, err = driver.DeleteFolder(context.Background(), orgID1, "alpha.bravo")
+	assert.True(t, errors.Is(err, folder.ErrFolderNotFound))
+	_, err = driver.DeleteFolder(context.Background(), orgID2, "alpha")
+	assert.True(t, errors.Is(err, folder.ErrFolderNotFound))
+
+	// Deleting a root folder
+	deleted, err = driver.DeleteFolder(context.Background(), orgID2, "foxtrot")
+	assert.NoError(t, err)
+	assert.Equal(t, []*folder.Folder{folderMap["foxtrot"]}, deleted)
+	assert.Empty(t, orgFolders(t, driver, orgID2))
+}
+
+// Test_folder_RenameFolder tests the RenameFolder method.
+func Test_folder_RenameFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	tests := [...]struct {
+		name         string
+		orgID        uuid.UUID
+		path         string
+		newName      string
+		wantPaths    map[string]string
+		expectedKind error
+	}{
+		{
+			name:    "Rename a folder with children",
+			orgID:   orgID1,
+			path:    "alpha.delta",
+			newName: "india",
+			wantPaths: map[string]string{
+				"delta": "alpha.india",
+				"echo":  "alpha.india.echo",
+				"bravo": "alpha.bravo",
+			},
+		},
+		{
+			name:    "Rename a root folder",
+			orgID:   orgID1,
+			path:    "alpha",
+			newName: "juliett",
+			wantPaths: map[string]string{
+				"alpha":   "juliett",
+				"charlie": "juliett.bravo.charlie",
+				"golf":    "golf",
+			},
+		},
+		{
+			name:      "Rename to the same name",
+			orgID:     orgID1,
+			path:      "alpha.bravo",
+			newName:   "bravo",
+			wantPaths: map[string]string{"bravo": "alpha.bravo"},
+		},
+		{name: "Sibling with the new name", orgID: orgID1, path: "alpha.bravo", newName: "delta", expectedKind: folder.ErrFolderExists},
+		{name: "Folder does not exist", orgID: orgID2, path: "alpha.bravo", newName: "kilo", expectedKind: folder.ErrFolderNotFound},
+		{name: "Invalid name", orgID: orgID1, path: "alpha.bravo", newName: "a.b", expectedKind: folder.ErrInvalidName},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folders, folderMap := initializeFolders(orgID1, orgID2)
+			driver := folder.NewDriver(folders)
+
+			got, err := driver.RenameFolder(context.Background(), tt.orgID, tt.path, tt.newName)
+			if tt.expectedKind != nil {
+				assert.True(t, errors.Is(err, tt.expectedKind), "got error %v", err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.newName, got.Name)
+			for name, path := range tt.wantPaths {
+				assert.Equal(t, path, folderMap[name].Paths, "path of %s", name)
+			}
+		})
+	}
+}
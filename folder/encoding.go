@@ -0,0 +1,477 @@
+package folder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// Alternative tree encodings of a folder set for services that can't use ltree.
+// Every encoding identifies folders by an integer ID assigned in input order,
+// starting at 1; ID 0 means "no parent".
+
+// Node is a folder as seen by the alternative encodings.
+type Node struct {
+	ID    int
+	Name  string
+	OrgId uuid.UUID
+}
+
+// TreeEncoding is implemented by every alternative encoding so that they can be
+// compared with each other and with the Paths form.
+type TreeEncoding interface {
+	// Subtree returns the IDs of all descendants of the given folder.
+	Subtree(id int) []int
+	// Move re-parents a folder and its subtree under parentID.
+	Move(id, parentID int) error
+	// Folders converts the encoding back to linked folders in the Paths form.
+	Folders() []*Folder
+}
+
+// pathKey builds the lookup key of a path within an organization.
+func pathKey(orgID uuid.UUID, path string) string {
+	return orgID.String() + "/" + path
+}
+
+// indexFolders assigns IDs to the folders and resolves each folder's parent ID from its Paths.
+func indexFolders(folders []*Folder) ([]Node, []int, error) {
+	nodes := make([]Node, len(folders))
+	parents := make([]int, len(folders))
+	byPath := make(map[string]int, len(folders))
+
+	for i, folder := range folders {
+		key := pathKey(folder.OrgId, folder.Paths)
+		if _, exists := byPath[key]; exists {
+			return nil, nil, fmt.Errorf("duplicate path '%s' in organization '%s'", folder.Paths, folder.OrgId)
+		}
+		byPath[key] = i + 1
+		nodes[i] = Node{ID: i + 1, Name: folder.Name, OrgId: folder.OrgId}
+	}
+
+	for i, folder := range folders {
+		idx := strings.LastIndex(folder.Paths, ".")
+		if idx < 0 {
+			continue
+		}
+		parent, exists := byPath[pathKey(folder.OrgId, folder.Paths[:idx])]
+		if !exists {
+			return nil, nil, fmt.Errorf("parent path '%s' of folder '%s' does not exist", folder.Paths[:idx], folder.Name)
+		}
+		parents[i] = parent
+	}
+
+	return nodes, parents, nil
+}
+
+// linkFolders sets Parent and Children of the folders from their Paths.
+// Children are linked in input order.
+func linkFolders(folders []*Folder) error {
+	_, parents, err := indexFolders(folders)
+	if err != nil {
+		return err
+	}
+
+	for i, parent := range parents {
+		if parent == 0 {
+			continue
+		}
+		folders[i].Parent = folders[parent-1]
+		folders[parent-1].Children = append(folders[parent-1].Children, folders[i])
+	}
+
+	return nil
+}
+
+// buildFolders creates linked folders in the Paths form from nodes and their parent IDs.
+// parents[i] is the parent ID of nodes[i], and nodes[i].ID must be i+1.
+func buildFolders(nodes []Node, parents []int) []*Folder {
+	folders := make([]*Folder, len(nodes))
+	for i, node := range nodes {
+		folders[i] = &Folder{Name: node.Name, OrgId: node.OrgId}
+	}
+
+	for i, parent := range parents {
+		if parent == 0 {
+			continue
+		}
+		folders[i].Parent = folders[parent-1]
+		folders[parent-1].Children = append(folders[parent-1].Children, folders[i])
+	}
+
+	for _, folder := range folders {
+		if folder.Parent == nil {
+			folder.Paths = folder.Name
+			for _, child := range folder.Children {
+				updatePaths(child, folder.Paths)
+			}
+		}
+	}
+
+	return folders
+}
+
+// validateMove applies the same rules as MoveFolder to a move between two nodes.
+func validateMove(source, dest Node, destIsDescendant bool) error {
+	if source.ID == dest.ID {
+		return errorf(ErrInvalidMove, "cannot move folder '%s' to itself", source.Name)
+	}
+	if destIsDescendant {
+		return errorf(ErrInvalidMove, "cannot move folder '%s' to a child of itself", source.Name)
+	}
+	if source.OrgId != dest.OrgId {
+		return errorf(ErrInvalidMove, "cannot move folder '%s' to a different organization", source.Name)
+	}
+	return nil
+}
+
+// AdjacencyRow is a single row of an adjacency list: a folder and its parent ID.
+type AdjacencyRow struct {
+	Node
+	ParentID int
+}
+
+// AdjacencyList stores each folder with a reference to its parent.
+// Rows are indexed by ID-1.
+type AdjacencyList struct {
+	Rows     []AdjacencyRow
+	children map[int][]int // index on ParentID
+}
+
+// ToAdjacencyList converts folders in the Paths form to an adjacency list.
+func ToAdjacencyList(folders []*Folder) (*AdjacencyList, error) {
+	nodes, parents, err := indexFolders(folders)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]AdjacencyRow, len(nodes))
+	for i, node := range nodes {
+		rows[i] = AdjacencyRow{Node: node, ParentID: parents[i]}
+	}
+
+	return NewAdjacencyList(rows), nil
+}
+
+// NewAdjacencyList creates an adjacency list from its rows.
+func NewAdjacencyList(rows []AdjacencyRow) *AdjacencyList {
+	a := &AdjacencyList{Rows: rows, children: make(map[int][]int)}
+	for _, row := range rows {
+		a.children[row.ParentID] = append(a.children[row.ParentID], row.ID)
+	}
+	return a
+}
+
+// Subtree walks the parent index level by level, like a recursive CTE.
+func (a *AdjacencyList) Subtree(id int) []int {
+	var res []int
+	level := a.children[id]
+	for len(level) > 0 {
+		res = append(res, level...)
+		var next []int
+		for _, child := range level {
+			next = append(next, a.children[child]...)
+		}
+		level = next
+	}
+	return res
+}
+
+// Move updates the ParentID of a single row.
+func (a *AdjacencyList) Move(id, parentID int) error {
+	if id < 1 || id > len(a.Rows) {
+		return errorf(ErrFolderNotFound, "source folder id %d does not exist", id)
+	}
+	if parentID < 1 || parentID > len(a.Rows) {
+		return errorf(ErrFolderNotFound, "destination folder id %d does not exist", parentID)
+	}
+
+	destIsDescendant := false
+	for current := parentID; current != 0; current = a.Rows[current-1].ParentID {
+		if current == id && current != parentID {
+			destIsDescendant = true
+			break
+		}
+	}
+
+	row := &a.Rows[id-1]
+	if err := validateMove(row.Node, a.Rows[parentID-1].Node, destIsDescendant); err != nil {
+		return err
+	}
+
+	siblings := a.children[row.ParentID]
+	for i, sibling := range siblings {
+		if sibling == id {
+			a.children[row.ParentID] = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	row.ParentID = parentID
+	a.children[parentID] = append(a.children[parentID], id)
+
+	return nil
+}
+
+// Folders converts the adjacency list back to the Paths form.
+func (a *AdjacencyList) Folders() []*Folder {
+	nodes := make([]Node, len(a.Rows))
+	parents := make([]int, len(a.Rows))
+	for i, row := range a.Rows {
+		nodes[i] = row.Node
+		parents[i] = row.ParentID
+	}
+	return buildFolders(nodes, parents)
+}
+
+// ClosureRow records that Ancestor is Depth levels above Descendant.
+// Every folder has a row with itself at depth 0.
+type ClosureRow struct {
+	Ancestor   int
+	Descendant int
+	Depth      int
+}
+
+// ClosureTable stores every ancestor/descendant pair of the tree.
+// Nodes are indexed by ID-1; Rows are unindexed, as in a plain table.
+type ClosureTable struct {
+	Nodes []Node
+	Rows  []ClosureRow
+}
+
+// ToClosureTable converts folders in the Paths form to a closure table.
+func ToClosureTable(folders []*Folder) (*ClosureTable, error) {
+	nodes, parents, err := indexFolders(folders)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ClosureTable{Nodes: nodes}
+	for _, node := range nodes {
+		depth := 0
+		for ancestor := node.ID; ancestor != 0; ancestor = parents[ancestor-1] {
+			c.Rows = append(c.Rows, ClosureRow{Ancestor: ancestor, Descendant: node.ID, Depth: depth})
+			depth++
+		}
+	}
+
+	return c, nil
+}
+
+// Subtree selects the rows whose ancestor is the given folder.
+func (c *ClosureTable) Subtree(id int) []int {
+	var res []int
+	for _, row := range c.Rows {
+		if row.Ancestor == id && row.Depth > 0 {
+			res = append(res, row.Descendant)
+		}
+	}
+	return res
+}
+
+// Move disconnects the subtree from its old ancestors and reconnects it to the new parent's ancestors.
+func (c *ClosureTable) Move(id, parentID int) error {
+	if id < 1 || id > len(c.Nodes) {
+		return errorf(ErrFolderNotFound, "source folder id %d does not exist", id)
+	}
+	if parentID < 1 || parentID > len(c.Nodes) {
+		return errorf(ErrFolderNotFound, "destination folder id %d does not exist", parentID)
+	}
+
+	// Depth of every member of the subtree relative to the moved folder.
+	subtree := make(map[int]int)
+	for _, row := range c.Rows {
+		if row.Ancestor == id {
+			subtree[row.Descendant] = row.Depth
+		}
+	}
+
+	_, destInSubtree := subtree[parentID]
+	if err := validateMove(c.Nodes[id-1], c.Nodes[parentID-1], destInSubtree && parentID != id); err != nil {
+		return err
+	}
+
+	var ancestors []ClosureRow
+	rows := c.Rows[:0]
+	for _, row := range c.Rows {
+		if row.Descendant == parentID {
+			ancestors = append(ancestors, row)
+		}
+		_, descInSubtree := subtree[row.Descendant]
+		_, ancInSubtree := subtree[row.Ancestor]
+		if descInSubtree && !ancInSubtree {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	for _, ancestor := range ancestors {
+		for descendant, depth := range subtree {
+			rows = append(rows, ClosureRow{
+				Ancestor:   ancestor.Ancestor,
+				Descendant: descendant,
+				Depth:      ancestor.Depth + depth + 1,
+			})
+		}
+	}
+	c.Rows = rows
+
+	return nil
+}
+
+// Folders converts the closure table back to the Paths form.
+func (c *ClosureTable) Folders() []*Folder {
+	parents := make([]int, len(c.Nodes))
+	for _, row := range c.Rows {
+		if row.Depth == 1 {
+			parents[row.Descendant-1] = row.Ancestor
+		}
+	}
+	return buildFolders(c.Nodes, parents)
+}
+
+// NestedSetRow is a folder with its left and right bounds; descendants lie strictly between them.
+type NestedSetRow struct {
+	Node
+	Lft int
+	Rgt int
+}
+
+// NestedSet stores the tree as nested intervals. Rows are indexed by ID-1.
+// Roots of all organizations share one numbering space.
+type NestedSet struct {
+	Rows []NestedSetRow
+}
+
+// ToNestedSet converts folders in the Paths form to a nested set.
+func ToNestedSet(folders []*Folder) (*NestedSet, error) {
+	nodes, parents, err := indexFolders(folders)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]int)
+	for i, parent := range parents {
+		children[parent] = append(children[parent], i+1)
+	}
+
+	n := &NestedSet{Rows: make([]NestedSetRow, len(nodes))}
+	counter := 0
+	var number func(id int)
+	number = func(id int) {
+		counter++
+		n.Rows[id-1] = NestedSetRow{Node: nodes[id-1], Lft: counter}
+		for _, child := range children[id] {
+			number(child)
+		}
+		counter++
+		n.Rows[id-1].Rgt = counter
+	}
+	for _, root := range children[0] {
+		number(root)
+	}
+
+	return n, nil
+}
+
+// Subtree selects the rows nested within the folder's bounds.
+func (n *NestedSet) Subtree(id int) []int {
+	var res []int
+	if id < 1 || id > len(n.Rows) {
+		return res
+	}
+	node := n.Rows[id-1]
+	for _, row := range n.Rows {
+		if row.Lft > node.Lft && row.Rgt < node.Rgt {
+			res = append(res, row.ID)
+		}
+	}
+	return res
+}
+
+// Move closes the gap left by the subtree, opens one at the end of the new parent and shifts the subtree into it.
+func (n *NestedSet) Move(id, parentID int) error {
+	if id < 1 || id > len(n.Rows) {
+		return errorf(ErrFolderNotFound, "source folder id %d does not exist", id)
+	}
+	if parentID < 1 || parentID > len(n.Rows) {
+		return errorf(ErrFolderNotFound, "destination folder id %d does not exist", parentID)
+	}
+
+	source := n.Rows[id-1]
+	dest := n.Rows[parentID-1]
+	destIsDescendant := dest.Lft > source.Lft && dest.Rgt < source.Rgt
+	if err := validateMove(source.Node, dest.Node, destIsDescendant); err != nil {
+		return err
+	}
+
+	width := source.Rgt - source.Lft + 1
+	inSubtree := make([]bool, len(n.Rows))
+	for i, row := range n.Rows {
+		inSubtree[i] = row.Lft >= source.Lft && row.Rgt <= source.Rgt
+	}
+
+	// Close the gap left by the subtree.
+	for i := range n.Rows {
+		row := &n.Rows[i]
+		if inSubtree[i] {
+			continue
+		}
+		if row.Lft > source.Rgt {
+			row.Lft -= width
+		}
+		if row.Rgt > source.Rgt {
+			row.Rgt -= width
+		}
+	}
+
+	// Open a gap at the end of the new parent.
+	pos := n.Rows[parentID-1].Rgt
+	for i := range n.Rows {
+		row := &n.Rows[i]
+		if inSubtree[i] {
+			continue
+		}
+		if row.Lft >= pos {
+			row.Lft += width
+		}
+		if row.Rgt >= pos {
+			row.Rgt += width
+		}
+	}
+
+	// Shift the subtree into the gap.
+	offset := pos - source.Lft
+	for i := range n.Rows {
+		row := &n.Rows[i]
+		if inSubtree[i] {
+			row.Lft += offset
+			row.Rgt += offset
+		}
+	}
+
+	return nil
+}
+
+// Folders converts the nested set back to the Paths form.
+func (n *NestedSet) Folders() []*Folder {
+	ordered := make([]NestedSetRow, len(n.Rows))
+	copy(ordered, n.Rows)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Lft < ordered[j].Lft })
+
+	nodes := make([]Node, len(n.Rows))
+	parents := make([]int, len(n.Rows))
+	var stack []NestedSetRow
+	for _, row := range ordered {
+		for len(stack) > 0 && stack[len(stack)-1].Rgt < row.Lft {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			parents[row.ID-1] = stack[len(stack)-1].ID
+		}
+		nodes[row.ID-1] = row.Node
+		stack = append(stack, row)
+	}
+
+	return buildFolders(nodes, parents)
+}
@@ -0,0 +1,219 @@
+package folder_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodings lists the constructors of every alternative encoding under test.
+var encodings = []struct {
+	name string
+	new  func([]*folder.Folder) (folder.TreeEncoding, error)
+}{
+	{"adjacency list", func(f []*folder.Folder) (folder.TreeEncoding, error) { return folder.ToAdjacencyList(f) }},
+	{"closure table", func(f []*folder.Folder) (folder.TreeEncoding, error) { return folder.ToClosureTable(f) }},
+	{"nested set", func(f []*folder.Folder) (folder.TreeEncoding, error) { return folder.ToNestedSet(f) }},
+}
+
+// paths returns "org/path" for every folder, which identifies the shape of a folder set.
+func paths(folders []*folder.Folder) []string {
+	res := make([]string, len(folders))
+	for i, f := range folders {
+		res[i] = f.OrgId.String() + "/" + f.Paths
+	}
+	return res
+}
+
+// Test_folder_Encodings tests conversion, subtree queries and moves on every alternative encoding.
+func Test_folder_Encodings(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	// IDs follow input order: alpha=1, bravo=2, charlie=3, delta=4, echo=5, foxtrot=6, golf=7
+	newFolders := func() []*folder.Folder {
+		return []*folder.Folder{
+			{Name: "alpha", OrgId: orgID1, Paths: "alpha"},
+			{Name: "bravo", OrgId: orgID1, Paths: "alpha.bravo"},
+			{Name: "charlie", OrgId: orgID1, Paths: "alpha.bravo.charlie"},
+			{Name: "delta", OrgId: orgID1, Paths: "alpha.delta"},
+			{Name: "echo", OrgId: orgID1, Paths: "alpha.delta.echo"},
+			{Name: "foxtrot", OrgId: orgID2, Paths: "foxtrot"},
+			{Name: "golf", OrgId: orgID1, Paths: "golf"},
+		}
+	}
+
+	for _, enc := range encodings {
+		enc := enc // capture range variable
+		t.Run(enc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Round trip through the encoding keeps every path
+			e, err := enc.new(newFolders())
+			assert.NoError(t, err)
+			assert.Equal(t, paths(newFolders()), paths(e.Folders()))
+
+			// Subtree queries
+			assert.ElementsMatch(t, []int{2, 3, 4, 5}, e.Subtree(1))
+			assert.ElementsMatch(t, []int{3}, e.Subtree(2))
+			assert.Empty(t, e.Subtree(3))
+
+			// Invalid moves are rejected with the same errors as MoveFolder
+			assert.EqualError(t, e.Move(2, 2), "cannot move folder 'bravo' to itself")
+			assert.EqualError(t, e.Move(2, 3), "cannot move folder 'bravo' to a child of itself")
+			assert.EqualError(t, e.Move(2, 6), "cannot move folder 'bravo' to a different organization")
+			assert.EqualError(t, e.Move(99, 1), "source folder id 99 does not exist")
+			assert.EqualError(t, e.Move(2, 99), "destination folder id 99 does not exist")
+
+			// Moving bravo under echo, then alpha under golf
+			assert.NoError(t, e.Move(2, 5))
+			assert.ElementsMatch(t, []int{2, 3}, e.Subtree(5))
+			assert.NoError(t, e.Move(1, 7))
+			assert.ElementsMatch(t, []int{1, 2, 3, 4, 5}, e.Subtree(7))
+
+			assert.Equal(t, []string{
+				orgID1.String() + "/golf.alpha",
+				orgID1.String() + "/golf.alpha.delta.echo.bravo",
+				orgID1.String() + "/golf.alpha.delta.echo.bravo.charlie",
+				orgID1.String() + "/golf.alpha.delta",
+				orgID1.String() + "/golf.alpha.delta.echo",
+				orgID2.String() + "/foxtrot",
+				orgID1.String() + "/golf",
+			}, paths(e.Folders()))
+		})
+	}
+}
+
+// Test_folder_Encodings_InvalidPaths tests that inconsistent Paths are rejected by every converter.
+func Test_folder_Encodings_InvalidPaths(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	tests := [...]struct {
+		name          string
+		folders       []*folder.Folder
+		expectedError string
+	}{
+		{
+			name: "Missing parent",
+			folders: []*folder.Folder{
+				{Name: "alpha", OrgId: orgID1, Paths: "alpha"},
+				{Name: "charlie", OrgId: orgID1, Paths: "alpha.bravo.charlie"},
+			},
+			expectedError: "parent path 'alpha.bravo' of folder 'charlie' does not exist",
+		},
+		{
+			name: "Parent in a different organization",
+			folders: []*folder.Folder{
+				{Name: "alpha", OrgId: orgID1, Paths: "alpha"},
+				{Name: "bravo", OrgId: orgID2, Paths: "alpha.bravo"},
+			},
+			expectedError: "parent path 'alpha' of folder 'bravo' does not exist",
+		},
+		{
+			name: "Duplicate path",
+			folders: []*folder.Folder{
+				{Name: "alpha", OrgId: orgID1, Paths: "alpha"},
+				{Name: "alpha", OrgId: orgID1, Paths: "alpha"},
+			},
+			expectedError: fmt.Sprintf("duplicate path 'alpha' in organization '%s'", orgID1),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		for _, enc := range encodings {
+			enc := enc
+			t.Run(tt.name+"/"+enc.name, func(t *testing.T) {
+				t.Parallel()
+				_, err := enc.new(tt.folders)
+				assert.EqualError(t, err, tt.expectedError)
+			})
+		}
+	}
+}
+
+// generatedFolders returns linked folders generated with the default config and a fixed seed.
+func generatedFolders(b *testing.B) []*folder.Folder {
+	config := folder.DefaultGeneratorConfig()
+	config.Seed = 1
+
+	folders, err := folder.GenerateDataWithConfig(config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return folders
+}
+
+// moveTargets picks a folder directly below a root, and another root of the same organization to move it to.
+func moveTargets(b *testing.B, folders []*folder.Folder) (source, root, dest int) {
+	index := make(map[*folder.Folder]int, len(folders))
+	for i, f := range folders {
+		index[f] = i + 1
+	}
+
+	for _, f := range folders {
+		if f.Parent == nil || f.Parent.Parent != nil {
+			continue
+		}
+		for _, g := range folders {
+			if g.Parent == nil && g.OrgId == f.OrgId && g != f.Parent {
+				return index[f], index[f.Parent], index[g]
+			}
+		}
+	}
+	b.Skip("generated data has no movable folder")
+	return 0, 0, 0
+}
+
+// BenchmarkEncodings compares subtree query and move cost of the Paths form and every alternative encoding.
+func BenchmarkEncodings(b *testing.B) {
+	folders := generatedFolders(b)
+	source, root, dest := moveTargets(b, folders)
+
+	for _, enc := range encodings {
+		enc := enc
+		e, err := enc.new(folders)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(enc.name+"/subtree", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				e.Subtree(root)
+			}
+		})
+		b.Run(enc.name+"/move", func(b *testing.B) {
+			parents := []int{dest, root}
+			for i := 0; i < b.N; i++ {
+				if err := e.Move(source, parents[i%2]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// The Paths form runs last as MoveFolder mutates the folders in place.
+	b.Run("paths/subtree", func(b *testing.B) {
+		d := folder.NewDriver(folders)
+		for i := 0; i < b.N; i++ {
+			d.GetAllChildFolders(context.Background(), folders[root-1].OrgId, folders[root-1].Name)
+		}
+	})
+	b.Run("paths/move", func(b *testing.B) {
+		d := folder.NewDriver(folders)
+		parents := []string{folders[dest-1].Name, folders[root-1].Name}
+		for i := 0; i < b.N; i++ {
+			if _, err := d.MoveFolder(context.Background(), folders[source-1].Name, parents[i%2]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
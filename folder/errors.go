@@ -0,0 +1,41 @@
+package folder
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of driver errors, to be matched with errors.Is.
+var (
+	// ErrFolderNotFound is returned when a source or destination folder does not exist.
+	ErrFolderNotFound = errors.New("folder not found")
+	// ErrInvalidMove is returned when a move would break the tree: onto itself, into its own subtree or across organizations.
+	// It is also returned for copies across organizations that were not allowed, and for moves by a name
+	// shared by several folders.
+	ErrInvalidMove = errors.New("invalid move")
+	// ErrFolderExists is returned when a folder would take the path of an existing folder.
+	ErrFolderExists = errors.New("folder already exists")
+	// ErrInvalidName is returned for folder names that are empty or contain '.'.
+	ErrInvalidName = errors.New("invalid folder name")
+	// ErrPermissionDenied is returned when a principal's role or organization doesn't allow an operation.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// kindError is an error with its own message that matches one of the error kinds.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// errorf formats an error like fmt.Errorf that matches kind with errors.Is.
+func errorf(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,281 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Event is a change of the folders, one of *FolderCreated, *FolderMoved, *FolderDeleted, *FolderRestored,
+// *FolderCopied, *FolderTransferred and *FolderPurged.
+type Event interface {
+	// Meta returns what every event has in common.
+	Meta() EventMeta
+	meta() *EventMeta
+}
+
+// EventMeta is what every event has in common.
+type EventMeta struct {
+	// Seq numbers the events of an organization from 1, without gaps, so consumers can detect missed events.
+	Seq       uint64
+	OrgID     uuid.UUID
+	Time      time.Time
+	Actor     string // the principal's ID, empty for trusted callers
+	RequestID string
+}
+
+func (m EventMeta) Meta() EventMeta {
+	return m
+}
+
+func (m *EventMeta) meta() *EventMeta {
+	return m
+}
+
+// FolderCreated is emitted when a folder is created.
+type FolderCreated struct {
+	EventMeta
+	Path string
+}
+
+// PathChange is the old and new path of a folder.
+type PathChange struct {
+	Old string
+	New string
+}
+
+// FolderMoved is emitted when a folder is moved or renamed, with the path change of the folder first,
+// followed by those of all of its descendants.
+type FolderMoved struct {
+	EventMeta
+	Changes []PathChange
+}
+
+// FolderDeleted is emitted when a folder is deleted, with the paths of the folder and all of its descendants.
+type FolderDeleted struct {
+	EventMeta
+	Paths []string
+}
+
+// FolderRestored is emitted when a folder is restored from the trash, with the paths of the folder and all of
+// its descendants where they were restored.
+type FolderRestored struct {
+	EventMeta
+	Paths []string
+}
+
+// FolderPurged is emitted when a folder is permanently dropped from the trash, with the paths the folder
+// and its descendants had when they were deleted.
+type FolderPurged struct {
+	EventMeta
+	Paths []string
+}
+
+// FolderCopied is emitted in the organization copied into when a folder is copied, with the path of the source
+// and the paths of the copies, the copy of the source first.
+type FolderCopied struct {
+	EventMeta
+	Source string
+	Paths  []string
+}
+
+// FolderTransferred is emitted in both organizations when a folder is transferred from SrcOrgID to DstOrgID,
+// with the path change of the folder first, followed by those of all of its descendants.
+type FolderTransferred struct {
+	EventMeta
+	SrcOrgID uuid.UUID
+	DstOrgID uuid.UUID
+	Changes  []PathChange
+}
+
+// Overflow is what a bus does when a subscriber's buffer is full.
+type Overflow int
+
+const (
+	// OverflowDisconnect closes the subscription, whose Err then returns ErrSlowConsumer.
+	OverflowDisconnect Overflow = iota
+	// OverflowDrop drops the event for the subscriber, which sees a gap in the sequence numbers.
+	OverflowDrop
+	// OverflowBlock makes the mutation wait until the subscriber receives the event.
+	OverflowBlock
+)
+
+// ErrSlowConsumer is the error of a subscription disconnected because its buffer was full.
+var ErrSlowConsumer = errors.New("slow consumer: event buffer full")
+
+// SubscribeOptions configures a subscription.
+type SubscribeOptions struct {
+	// OrgID limits the subscription to the events of one organization, if set.
+	OrgID uuid.UUID
+	// Buffer is the number of events buffered for a subscriber; 0 defaults to DefaultEventBuffer.
+	Buffer   int
+	Overflow Overflow
+}
+
+// DefaultEventBuffer is the buffer size of subscriptions that don't set one.
+const DefaultEventBuffer = 64
+
+// EventBus delivers the events of a driver to subscribers. Every subscriber receives events in the order
+// they were published, so the events of an organization arrive in the order of their sequence numbers.
+// It is safe for concurrent use.
+type EventBus struct {
+	// mu serializes publishing, which numbers the events and delivers them in the same order.
+	mu   sync.Mutex
+	seqs map[uuid.UUID]uint64
+
+	subsMu sync.Mutex
+	subs   map[*Subscription]struct{}
+}
+
+// NewEventBus returns a bus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{seqs: make(map[uuid.UUID]uint64), subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of a bus on C until it is closed.
+type Subscription struct {
+	// C is closed when the subscription is closed or disconnected.
+	C <-chan Event
+
+	bus  *EventBus
+	opts SubscribeOptions
+	ch   chan Event
+	done chan struct{}
+	once sync.Once
+
+	// mu guards the sends on ch against closing it.
+	mu      sync.Mutex
+	closed  bool
+	err     error
+	dropped uint64
+}
+
+// Subscribe returns a subscription to the events published from now on.
+func (b *EventBus) Subscribe(opts SubscribeOptions) *Subscription {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultEventBuffer
+	}
+	ch := make(chan Event, opts.Buffer)
+	s := &Subscription{C: ch, bus: b, opts: opts, ch: ch, done: make(chan struct{})}
+
+	b.subsMu.Lock()
+	defer b.subsMu.Unlock()
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Close stops the subscription and closes C. It unblocks a mutation waiting on the subscriber.
+func (s *Subscription) Close() {
+	s.close(nil)
+}
+
+// Err returns ErrSlowConsumer if the subscription was disconnected, or nil.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns the number of events dropped because the buffer was full, with OverflowDrop.
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// close closes the subscription once, recording err.
+func (s *Subscription) close(err error) {
+	s.bus.subsMu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.subsMu.Unlock()
+
+	s.once.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		s.err = err
+		close(s.ch)
+	})
+}
+
+// deliver sends an event to the subscriber, applying its overflow policy.
+func (s *Subscription) deliver(e Event) {
+	if s.opts.OrgID != uuid.Nil && e.Meta().OrgID != s.opts.OrgID {
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	select {
+	case s.ch <- e:
+		s.mu.Unlock()
+		return
+	default:
+	}
+
+	switch s.opts.Overflow {
+	case OverflowDrop:
+		s.dropped++
+		s.mu.Unlock()
+	case OverflowBlock:
+		// Close can't take mu while this waits, so it signals done first.
+		select {
+		case s.ch <- e:
+		case <-s.done:
+		}
+		s.mu.Unlock()
+	default:
+		s.mu.Unlock()
+		s.close(ErrSlowConsumer)
+	}
+}
+
+// publish numbers an event of a mutation made with ctx and delivers it to every subscriber.
+func (b *EventBus) publish(ctx context.Context, e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m := e.meta()
+	b.seqs[m.OrgID]++
+	m.Seq = b.seqs[m.OrgID]
+	m.Time = time.Now().UTC()
+	m.RequestID = RequestIDFrom(ctx)
+	if p, ok := PrincipalFrom(ctx); ok {
+		m.Actor = p.ID
+	}
+
+	b.subsMu.Lock()
+	subs := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.subsMu.Unlock()
+
+	for _, s := range subs {
+		s.deliver(e)
+	}
+}
+
+// publish publishes an event to the driver's bus, if it has one.
+func (f *driver) publish(ctx context.Context, e Event) {
+	if f.eventBus != nil {
+		f.eventBus.publish(ctx, e)
+	}
+}
+
+// pathsOf returns the paths of a folder and its descendants, following Children, in depth-first order.
+func pathsOf(folder *Folder) []string {
+	paths := []string{folder.Paths}
+	for _, child := range folder.Children {
+		paths = append(paths, pathsOf(child)...)
+	}
+	return paths
+}
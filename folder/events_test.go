@@ -0,0 +1,237 @@
+package folder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// receive returns the next event of a subscription, failing the test if none arrives.
+func receive(t *testing.T, sub *folder.Subscription) folder.Event {
+	t.Helper()
+	select {
+	case e, ok := <-sub.C:
+		assert.True(t, ok, "subscription closed")
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+// Test_folder_Events tests the events published for each mutation.
+func Test_folder_Events(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	ctx := folder.WithRequestID(context.Background(), "req-1")
+
+	tests := [...]struct {
+		name   string
+		mutate func(d folder.IDriver) error
+		want   folder.Event
+	}{
+		{
+			name: "Create",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.CreateFolder(ctx, orgID1, "hotel", "golf")
+				return err
+			},
+			want: &folder.FolderCreated{Path: "golf.hotel"},
+		},
+		{
+			// Every affected descendant is listed after the moved folder
+			name: "Move",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.MoveFolder(ctx, "bravo", "golf")
+				return err
+			},
+			want: &folder.FolderMoved{Changes: []folder.PathChange{
+				{Old: "alpha.bravo", New: "golf.bravo"},
+				{Old: "alpha.bravo.charlie", New: "golf.bravo.charlie"},
+			}},
+		},
+		{
+			name: "Rename",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.RenameFolder(ctx, orgID1, "alpha.delta", "hotel")
+				return err
+			},
+			want: &folder.FolderMoved{Changes: []folder.PathChange{
+				{Old: "alpha.delta", New: "alpha.hotel"},
+				{Old: "alpha.delta.echo", New: "alpha.hotel.echo"},
+			}},
+		},
+		{
+			name: "Delete",
+			mutate: func(d folder.IDriver) error {
+				_, err := d.DeleteFolder(ctx, orgID1, "alpha.bravo")
+				return err
+			},
+			want: &folder.FolderDeleted{Paths: []string{"alpha.bravo", "alpha.bravo.charlie"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folders, _ := initializeFolders(orgID1, orgID2)
+			bus := folder.NewEventBus()
+			sub := bus.Subscribe(folder.SubscribeOptions{})
+			defer sub.Close()
+
+			assert.NoError(t, tt.mutate(folder.NewDriver(folders, folder.WithEventBus(bus))))
+
+			got := receive(t, sub)
+			meta := got.Meta()
+			assert.Equal(t, uint64(1), meta.Seq)
+			assert.Equal(t, orgID1, meta.OrgID)
+			assert.Equal(t, "req-1", meta.RequestID)
+			assert.False(t, meta.Time.IsZero())
+
+			// Compare the payloads only
+			switch e := got.(type) {
+			case *folder.FolderCreated:
+				e.EventMeta = folder.EventMeta{}
+			case *folder.FolderMoved:
+				e.EventMeta = folder.EventMeta{}
+			case *folder.FolderDeleted:
+				e.EventMeta = folder.EventMeta{}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Failed mutations publish nothing", func(t *testing.T) {
+		t.Parallel()
+		folders, _ := initializeFolders(orgID1, orgID2)
+		bus := folder.NewEventBus()
+		sub := bus.Subscribe(folder.SubscribeOptions{})
+		defer sub.Close()
+
+		_, err := folder.NewDriver(folders, folder.WithEventBus(bus)).MoveFolder(ctx, "bravo", "charlie")
+		assert.Error(t, err)
+		assert.Empty(t, sub.C)
+	})
+}
+
+// Test_folder_EventBus tests ordering, filtering and the overflow policies of subscriptions.
+func Test_folder_EventBus(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	// create creates root folders with the given names in an organization.
+	create := func(t *testing.T, d folder.IDriver, orgID uuid.UUID, names ...string) {
+		for _, name := range names {
+			_, err := d.CreateFolder(context.Background(), orgID, name, "")
+			assert.NoError(t, err)
+		}
+	}
+
+	t.Run("Sequence numbers per organization", func(t *testing.T) {
+		t.Parallel()
+		bus := folder.NewEventBus()
+		all := bus.Subscribe(folder.SubscribeOptions{})
+		org2 := bus.Subscribe(folder.SubscribeOptions{OrgID: orgID2})
+		d := folder.NewDriver(nil, folder.WithEventBus(bus))
+
+		create(t, d, orgID1, "alpha")
+		create(t, d, orgID2, "bravo")
+		create(t, d, orgID1, "charlie")
+		create(t, d, orgID2, "delta")
+
+		var got []string
+		for i := 0; i < 4; i++ {
+			e := receive(t, all).(*folder.FolderCreated)
+			got = append(got, e.Path)
+			assert.Equal(t, uint64(i/2+1), e.Seq, "sequence of %s", e.Path)
+		}
+		assert.Equal(t, []string{"alpha", "bravo", "charlie", "delta"}, got)
+
+		assert.Equal(t, "bravo", receive(t, org2).(*folder.FolderCreated).Path)
+		assert.Equal(t, "delta", receive(t, org2).(*folder.FolderCreated).Path)
+		assert.Empty(t, org2.C)
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		t.Parallel()
+		bus := folder.NewEventBus()
+		sub := bus.Subscribe(folder.SubscribeOptions{Buffer: 1})
+		d := folder.NewDriver(nil, folder.WithEventBus(bus))
+
+		create(t, d, orgID1, "alpha", "bravo")
+
+		assert.Equal(t, "alpha", receive(t, sub).(*folder.FolderCreated).Path)
+		_, open := <-sub.C
+		assert.False(t, open)
+		assert.True(t, errors.Is(sub.Err(), folder.ErrSlowConsumer))
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		t.Parallel()
+		bus := folder.NewEventBus()
+		sub := bus.Subscribe(folder.SubscribeOptions{Buffer: 1, Overflow: folder.OverflowDrop})
+		defer sub.Close()
+		d := folder.NewDriver(nil, folder.WithEventBus(bus))
+
+		create(t, d, orgID1, "alpha", "bravo", "charlie")
+		assert.Equal(t, uint64(2), sub.Dropped())
+		assert.Equal(t, uint64(1), receive(t, sub).Meta().Seq)
+
+		// The gap in sequence numbers shows the dropped events
+		create(t, d, orgID1, "delta")
+		assert.Equal(t, uint64(4), receive(t, sub).Meta().Seq)
+		assert.NoError(t, sub.Err())
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		t.Parallel()
+		bus := folder.NewEventBus()
+		sub := bus.Subscribe(folder.SubscribeOptions{Buffer: 1, Overflow: folder.OverflowBlock})
+		d := folder.NewDriver(nil, folder.WithEventBus(bus))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			create(t, d, orgID1, "alpha", "bravo", "charlie")
+		}()
+
+		for _, want := range []string{"alpha", "bravo", "charlie"} {
+			assert.Equal(t, want, receive(t, sub).(*folder.FolderCreated).Path)
+		}
+		<-done
+		assert.Equal(t, uint64(0), sub.Dropped())
+		sub.Close()
+	})
+
+	t.Run("Close unblocks the publisher", func(t *testing.T) {
+		t.Parallel()
+		bus := folder.NewEventBus()
+		sub := bus.Subscribe(folder.SubscribeOptions{Buffer: 1, Overflow: folder.OverflowBlock})
+		d := folder.NewDriver(nil, folder.WithEventBus(bus))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			create(t, d, orgID1, "alpha", "bravo")
+		}()
+
+		// Wait for the first event to fill the buffer, then close while the second one blocks
+		assert.Eventually(t, func() bool { return len(sub.C) == 1 }, time.Second, time.Millisecond)
+		sub.Close()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("publisher still blocked")
+		}
+	})
+}
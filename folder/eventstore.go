@@ -0,0 +1,243 @@
+package folder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Record is a mutation in the log of an event store. Its fields depend on the operation:
+//   - create: Path is the parent's path, empty for a root folder, and Name the new folder's name
+//   - move: Name is the moved folder's name and Dst the destination's name, as given to MoveFolder,
+//     or, for MoveFolderByPath, Path is the moved folder's path and Dst the destination's path
+//   - delete: Path is the deleted folder's path
+//   - rename: Path is the renamed folder's path and Name its new name
+type Record struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	OrgID     uuid.UUID `json:"org_id"`
+	Op        AuditOp   `json:"op"`
+	Path      string    `json:"path,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Dst       string    `json:"dst,omitempty"`
+}
+
+// DefaultSnapshotInterval is the number of records between the snapshots of stores that don't set one.
+const DefaultSnapshotInterval = 100
+
+// snapshot is the state of the folders after a record, or the initial state at Seq 0.
+type snapshot struct {
+	seq     uint64
+	folders []Folder
+}
+
+// EventStore is a driver whose folders are derived from a log of records, so it can rebuild the folders
+// as they were after any record or at any time. Like the driver, it is not safe for concurrent use.
+type EventStore struct {
+	// current is the state after the last record; its options, such as an audit sink, apply to every mutation.
+	current   *driver
+	records   []Record
+	snapshots []snapshot
+	interval  int
+}
+
+// NewEventStore returns a store starting from folders, which are snapshotted every interval records,
+// or DefaultSnapshotInterval if interval is 0. The options configure the driver of the current state.
+func NewEventStore(folders []*Folder, interval int, opts ...Option) *EventStore {
+	if interval <= 0 {
+		interval = DefaultSnapshotInterval
+	}
+	return &EventStore{
+		current:   NewDriver(folders, opts...),
+		snapshots: []snapshot{{folders: copyFolders(folders)}},
+		interval:  interval,
+	}
+}
+
+// LoadEventStore returns a store starting from folders and replaying records, as returned by Records.
+func LoadEventStore(folders []*Folder, records []Record, interval int, opts ...Option) (*EventStore, error) {
+	s := NewEventStore(folders, interval)
+	for i, r := range records {
+		if r.Seq != uint64(i+1) {
+			return nil, fmt.Errorf("record %d has sequence number %d", i+1, r.Seq)
+		}
+		if err := apply(s.current, r); err != nil {
+			return nil, fmt.Errorf("record %d: %w", r.Seq, err)
+		}
+		s.append(r)
+	}
+	for _, opt := range opts {
+		opt(s.current)
+	}
+	return s, nil
+}
+
+// Records returns the log of the store.
+func (s *EventStore) Records() []Record {
+	return append([]Record(nil), s.records...)
+}
+
+// GetFoldersByOrgID returns the current folders of an organization.
+func (s *EventStore) GetFoldersByOrgID(ctx context.Context, orgID uuid.UUID) ([]*Folder, error) {
+	return s.current.GetFoldersByOrgID(ctx, orgID)
+}
+
+// GetAllChildFolders returns the current descendants of a folder.
+func (s *EventStore) GetAllChildFolders(ctx context.Context, orgID uuid.UUID, name string) ([]*Folder, error) {
+	return s.current.GetAllChildFolders(ctx, orgID, name)
+}
+
+// GetAllChildFoldersByPath returns the current descendants of the folder at path.
+func (s *EventStore) GetAllChildFoldersByPath(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error) {
+	return s.current.GetAllChildFoldersByPath(ctx, orgID, path)
+}
+
+// MoveFolder moves a folder and records the move.
+func (s *EventStore) MoveFolder(ctx context.Context, name string, dst string) ([]*Folder, error) {
+	// Find the moved folder's organization as the driver does.
+	principal, scoped := PrincipalFrom(ctx)
+	var orgID uuid.UUID
+	for _, f := range s.current.folders {
+		if f.Name == name && (!scoped || f.OrgId == principal.OrgID) {
+			orgID = f.OrgId
+		}
+	}
+
+	folders, err := s.current.MoveFolder(ctx, name, dst)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, Record{OrgID: orgID, Op: AuditMove, Name: name, Dst: dst})
+	return folders, nil
+}
+
+// MoveFolderByPath moves the folder at path and records the move.
+func (s *EventStore) MoveFolderByPath(ctx context.Context, orgID uuid.UUID, path string, dstPath string) ([]*Folder, error) {
+	folders, err := s.current.MoveFolderByPath(ctx, orgID, path, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, Record{OrgID: orgID, Op: AuditMove, Path: path, Dst: dstPath})
+	return folders, nil
+}
+
+// CreateFolder creates a folder and records the creation.
+func (s *EventStore) CreateFolder(ctx context.Context, orgID uuid.UUID, name string, parentPath string) (*Folder, error) {
+	folder, err := s.current.CreateFolder(ctx, orgID, name, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, Record{OrgID: orgID, Op: AuditCreate, Path: parentPath, Name: name})
+	return folder, nil
+}
+
+// DeleteFolder deletes a folder and records the deletion.
+func (s *EventStore) DeleteFolder(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error) {
+	deleted, err := s.current.DeleteFolder(ctx, orgID, path)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, Record{OrgID: orgID, Op: AuditDelete, Path: path})
+	return deleted, nil
+}
+
+// RenameFolder renames a folder and records the renaming.
+func (s *EventStore) RenameFolder(ctx context.Context, orgID uuid.UUID, path string, name string) (*Folder, error) {
+	folder, err := s.current.RenameFolder(ctx, orgID, path, name)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, Record{OrgID: orgID, Op: AuditRename, Path: path, Name: name})
+	return folder, nil
+}
+
+// StateAt returns a copy of the folders as they were after the record seq, or initially for 0.
+func (s *EventStore) StateAt(seq uint64) ([]*Folder, error) {
+	if seq > uint64(len(s.records)) {
+		return nil, fmt.Errorf("no record %d, the last one is %d", seq, len(s.records))
+	}
+
+	// The latest snapshot at or before seq.
+	i := sort.Search(len(s.snapshots), func(i int) bool { return s.snapshots[i].seq > seq }) - 1
+	snap := s.snapshots[i]
+
+	folders := make([]*Folder, len(snap.folders))
+	for i := range snap.folders {
+		f := snap.folders[i]
+		folders[i] = &f
+	}
+	if err := linkFolders(folders); err != nil {
+		return nil, err
+	}
+
+	state := NewDriver(folders)
+	for _, r := range s.records[snap.seq:seq] {
+		if err := apply(state, r); err != nil {
+			return nil, fmt.Errorf("record %d: %w", r.Seq, err)
+		}
+	}
+	return state.folders, nil
+}
+
+// StateAsOf returns a copy of the folders as they were at t, after the records made until then.
+func (s *EventStore) StateAsOf(t time.Time) ([]*Folder, error) {
+	seq := sort.Search(len(s.records), func(i int) bool { return s.records[i].Time.After(t) })
+	return s.StateAt(uint64(seq))
+}
+
+// record appends a mutation made with ctx to the log, taking a snapshot every interval records.
+func (s *EventStore) record(ctx context.Context, r Record) {
+	r.Seq = uint64(len(s.records) + 1)
+	r.Time = time.Now().UTC()
+	r.RequestID = RequestIDFrom(ctx)
+	if p, ok := PrincipalFrom(ctx); ok {
+		r.Actor = p.ID
+	}
+	s.append(r)
+}
+
+// append appends a record applied to the current state.
+func (s *EventStore) append(r Record) {
+	s.records = append(s.records, r)
+	if len(s.records)%s.interval == 0 {
+		s.snapshots = append(s.snapshots, snapshot{seq: r.Seq, folders: copyFolders(s.current.folders)})
+	}
+}
+
+// apply replays a record on a driver.
+func apply(d *driver, r Record) error {
+	ctx := context.Background()
+	var err error
+	switch r.Op {
+	case AuditCreate:
+		_, err = d.CreateFolder(ctx, r.OrgID, r.Name, r.Path)
+	case AuditMove:
+		if r.Path != "" {
+			_, err = d.MoveFolderByPath(ctx, r.OrgID, r.Path, r.Dst)
+			break
+		}
+		// Moves find folders by name, so only look in the organization of the recorded move
+		_, err = d.MoveFolder(WithPrincipal(ctx, Principal{OrgID: r.OrgID}), r.Name, r.Dst)
+	case AuditDelete:
+		_, err = d.DeleteFolder(ctx, r.OrgID, r.Path)
+	case AuditRename:
+		_, err = d.RenameFolder(ctx, r.OrgID, r.Path, r.Name)
+	default:
+		err = fmt.Errorf("unknown operation '%s'", r.Op)
+	}
+	return err
+}
+
+// copyFolders copies the names, organizations and paths of folders, without their links.
+func copyFolders(folders []*Folder) []Folder {
+	res := make([]Folder, len(folders))
+	for i, f := range folders {
+		res[i] = Folder{Name: f.Name, OrgId: f.OrgId, Paths: f.Paths}
+	}
+	return res
+}
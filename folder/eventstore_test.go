@@ -0,0 +1,218 @@
+package folder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_EventStore tests that the state after every record can be rebuilt, whatever the snapshot interval.
+func Test_folder_EventStore(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	ctx := context.Background()
+
+	mutations := []func(s *folder.EventStore) error{
+		func(s *folder.EventStore) error {
+			_, err := s.MoveFolder(ctx, "bravo", "golf")
+			return err
+		},
+		func(s *folder.EventStore) error {
+			_, err := s.CreateFolder(ctx, orgID1, "hotel", "golf.bravo")
+			return err
+		},
+		func(s *folder.EventStore) error {
+			_, err := s.RenameFolder(ctx, orgID1, "alpha.delta", "india")
+			return err
+		},
+		func(s *folder.EventStore) error {
+			_, err := s.DeleteFolder(ctx, orgID2, "foxtrot")
+			return err
+		},
+		func(s *folder.EventStore) error {
+			_, err := s.MoveFolder(ctx, "golf", "india")
+			return err
+		},
+	}
+
+	tests := [...]struct {
+		name     string
+		interval int
+	}{
+		{name: "Snapshot after every record", interval: 1},
+		{name: "Snapshot every 2 records", interval: 2},
+		{name: "Default snapshot interval", interval: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folders, _ := initializeFolders(orgID1, orgID2)
+			store := folder.NewEventStore(folders, tt.interval)
+
+			// The expected state after every record, taken from the current state.
+			want := [][]string{paths(folders)}
+			var marks []time.Time
+			for _, mutate := range mutations {
+				assert.NoError(t, mutate(store))
+				current, err := store.StateAt(uint64(len(want)))
+				assert.NoError(t, err)
+				want = append(want, paths(current))
+				marks = append(marks, time.Now())
+			}
+			assert.ElementsMatch(t, []string{
+				orgID1.String() + "/alpha",
+				orgID1.String() + "/alpha.india",
+				orgID1.String() + "/alpha.india.echo",
+				orgID1.String() + "/alpha.india.golf",
+				orgID1.String() + "/alpha.india.golf.bravo",
+				orgID1.String() + "/alpha.india.golf.bravo.charlie",
+				orgID1.String() + "/alpha.india.golf.bravo.hotel",
+			}, want[len(mutations)])
+
+			for seq := range want {
+				got, err := store.StateAt(uint64(seq))
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, want[seq], paths(got), "state at %d", seq)
+			}
+			for i, mark := range marks {
+				got, err := store.StateAsOf(mark)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, want[i+1], paths(got), "state as of mutation %d", i+1)
+			}
+
+			got, err := store.StateAsOf(time.Now().Add(-time.Hour))
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, want[0], paths(got), "state before any record")
+
+			_, err = store.StateAt(uint64(len(want)))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("States are copies", func(t *testing.T) {
+		t.Parallel()
+		folders, folderMap := initializeFolders(orgID1, orgID2)
+		store := folder.NewEventStore(folders, 1)
+		assert.NoError(t, mutations[0](store))
+
+		state, err := store.StateAt(1)
+		assert.NoError(t, err)
+		_, err = folder.NewDriver(state).MoveFolder(ctx, "bravo", "alpha")
+		assert.NoError(t, err)
+		assert.Equal(t, "golf.bravo", folderMap["bravo"].Paths)
+
+		again, err := store.StateAt(1)
+		assert.NoError(t, err)
+		assert.Contains(t, paths(again), orgID1.String()+"/golf.bravo")
+	})
+
+	t.Run("Failed mutations are not recorded", func(t *testing.T) {
+		t.Parallel()
+		folders, _ := initializeFolders(orgID1, orgID2)
+		store := folder.NewEventStore(folders, 0)
+
+		_, err := store.MoveFolder(ctx, "alpha", "charlie")
+		assert.True(t, errors.Is(err, folder.ErrInvalidMove))
+		assert.Empty(t, store.Records())
+	})
+
+	t.Run("Loaded from records", func(t *testing.T) {
+		t.Parallel()
+		folders, _ := initializeFolders(orgID1, orgID2)
+		store := folder.NewEventStore(folders, 0)
+		ctx := folder.WithPrincipal(ctx, folder.Principal{ID: "alice", OrgID: orgID1})
+		_, err := store.MoveFolder(ctx, "bravo", "golf")
+		assert.NoError(t, err)
+		_, err = store.CreateFolder(ctx, orgID1, "hotel", "")
+		assert.NoError(t, err)
+
+		records := store.Records()
+		assert.Equal(t, folder.Record{Seq: 1, Time: records[0].Time, Actor: "alice", OrgID: orgID1, Op: folder.AuditMove, Name: "bravo", Dst: "golf"}, records[0])
+
+		initial, _ := initializeFolders(orgID1, orgID2)
+		loaded, err := folder.LoadEventStore(initial, records, 0)
+		assert.NoError(t, err)
+		got, err := loaded.GetFoldersByOrgID(context.Background(), orgID1)
+		assert.NoError(t, err)
+		want, err := store.GetFoldersByOrgID(context.Background(), orgID1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, paths(want), paths(got))
+
+		_, err = folder.LoadEventStore(initial, records[1:], 0)
+		assert.EqualError(t, err, "record 1 has sequence number 2")
+	})
+}
+
+// Test_folder_EventStore_CollidingNames tests that moves are replayed in their organization when others have the same names.
+func Test_folder_EventStore_CollidingNames(t *testing.T) {
+	t.Parallel()
+
+	orgA := uuid.Must(uuid.NewV4())
+	orgB := uuid.Must(uuid.NewV4())
+	folders := []*folder.Folder{
+		{Name: "x", OrgId: orgA, Paths: "x"},
+		{Name: "y", OrgId: orgA, Paths: "y"},
+		{Name: "x", OrgId: orgB, Paths: "x"},
+		{Name: "y", OrgId: orgB, Paths: "y"},
+	}
+	store := folder.NewEventStore(folders, 0)
+	ctx := folder.WithPrincipal(context.Background(), folder.Principal{OrgID: orgA})
+
+	_, err := store.MoveFolder(ctx, "x", "y")
+	assert.NoError(t, err)
+	want := []string{orgA.String() + "/y.x", orgA.String() + "/y", orgB.String() + "/x", orgB.String() + "/y"}
+	assert.ElementsMatch(t, want, paths(orgFolders(t, store, orgA, orgB)))
+
+	state, err := store.StateAt(1)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, want, paths(state))
+
+	loaded, err := folder.LoadEventStore([]*folder.Folder{
+		{Name: "x", OrgId: orgA, Paths: "x"},
+		{Name: "y", OrgId: orgA, Paths: "y"},
+		{Name: "x", OrgId: orgB, Paths: "x"},
+		{Name: "y", OrgId: orgB, Paths: "y"},
+	}, store.Records(), 0)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, want, paths(orgFolders(t, loaded, orgA, orgB)))
+}
+
+// Test_folder_EventStore_MoveByPath tests that moves by path are recorded and replayed by path.
+func Test_folder_EventStore_MoveByPath(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.Must(uuid.NewV4())
+	initial := func() []*folder.Folder {
+		folders, err := folder.LoadDataset([]byte(`[
+			{"name": "a", "org_id": "` + orgID.String() + `", "paths": "a"},
+			{"name": "x", "org_id": "` + orgID.String() + `", "paths": "a.x"},
+			{"name": "b", "org_id": "` + orgID.String() + `", "paths": "b"},
+			{"name": "x", "org_id": "` + orgID.String() + `", "paths": "b.x"},
+			{"name": "c", "org_id": "` + orgID.String() + `", "paths": "c"}
+		]`))
+		assert.NoError(t, err)
+		return folders
+	}
+	store := folder.NewEventStore(initial(), 0)
+
+	_, err := store.MoveFolderByPath(context.Background(), orgID, "b.x", "c")
+	assert.NoError(t, err)
+	records := store.Records()
+	assert.Len(t, records, 1)
+	assert.Equal(t, "b.x", records[0].Path)
+	assert.Equal(t, "c", records[0].Dst)
+
+	want := []string{orgID.String() + "/a", orgID.String() + "/a.x", orgID.String() + "/b", orgID.String() + "/c", orgID.String() + "/c.x"}
+	loaded, err := folder.LoadEventStore(initial(), records, 0)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, want, paths(orgFolders(t, loaded, orgID)))
+}
@@ -1,22 +1,40 @@
 package folder
 
 import (
-	"fmt"
+	"context"
+	"time"
+
 	"github.com/gofrs/uuid"
 )
 
+// IDriver is the folder store. Every method takes a context: a principal it carries limits the call to
+// the principal's organization, its cancellation stops long traversals, and its request ID prefixes the logs.
 type IDriver interface {
 	// GetFoldersByOrgID returns all folders that belong to a specific orgID.
-	GetFoldersByOrgID(orgID uuid.UUID) []*Folder
+	GetFoldersByOrgID(ctx context.Context, orgID uuid.UUID) ([]*Folder, error)
 	// component 1
 	// Implement the following methods:
 	// GetAllChildFolders returns all child folders of a specific folder.
-	GetAllChildFolders(orgID uuid.UUID, name string) []*Folder
+	GetAllChildFolders(ctx context.Context, orgID uuid.UUID, name string) ([]*Folder, error)
+	// GetAllChildFoldersByPath returns all child folders of the folder at path, also when several folders share its name.
+	GetAllChildFoldersByPath(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error)
 
 	// component 2
 	// Implement the following methods:
 	// MoveFolder moves a folder to a new destination.
-	MoveFolder(name string, dst string) ([]*Folder, error)
+	// With a principal in ctx, only the folders of the principal's organization are looked up.
+	// A name shared by several of the folders looked up is rejected with ErrInvalidMove.
+	MoveFolder(ctx context.Context, name string, dst string) ([]*Folder, error)
+	// MoveFolderByPath moves the folder at path to the folder at dstPath of the same organization.
+	MoveFolderByPath(ctx context.Context, orgID uuid.UUID, path string, dstPath string) ([]*Folder, error)
+
+	// CreateFolder creates a folder under the folder at parentPath, or a root folder if parentPath is empty.
+	CreateFolder(ctx context.Context, orgID uuid.UUID, name string, parentPath string) (*Folder, error)
+	// DeleteFolder deletes the folder at path with its subtree and returns the deleted folders.
+	// Drivers with a trash keep the subtree there, out of the other methods' results, until it is purged.
+	DeleteFolder(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error)
+	// RenameFolder renames the folder at path, updating the paths of its subtree.
+	RenameFolder(ctx context.Context, orgID uuid.UUID, path string, name string) (*Folder, error)
 }
 
 type driver struct {
@@ -26,48 +44,38 @@ type driver struct {
 
 	// example: feel free to change the data structure, if slice is not what you want
 	folders []*Folder
-}
 
-func NewDriver(folders []*Folder) *driver {
-	return &driver{
-		// initialize attributes here
-		folders: folders,
-	}
-}
+	auditSink AuditSink
+	eventBus  *EventBus
 
-// PrintFolders recursively prints the folder tree structure
-func PrintFolders(folders []*Folder) {
-    fmt.Printf("\n\n\n*********************************************************************************************\n")
-    for _, folder := range folders {
-        printFolder(folder, 0)
-        fmt.Printf("\n\n")
-    }
-    fmt.Printf("*********************************************************************************************\n\n\n")
+	trash          map[uuid.UUID][]*TrashItem // nil without WithTrash
+	trashRetention time.Duration
 }
 
-// Helper function to print a folder and its children
-func printFolder(folder *Folder, level int) {
-    indent := ""
-    for i := 0; i < level; i++ {
-        indent += "  "
-    }
+// Option configures a driver.
+type Option func(*driver)
 
-    fmt.Printf("%sFolder Name: %s, Path: %s, OrgId: %s, Address: %p\n", indent, folder.Name, folder.Paths, folder.OrgId, folder)
+// WithAuditSink records every mutation to sink before it is applied.
+func WithAuditSink(sink AuditSink) Option {
+	return func(f *driver) {
+		f.auditSink = sink
+	}
+}
 
-    // Print parent information if available
-    if folder.Parent != nil {
-        fmt.Printf("%s  Parent: %s\n", indent, folder.Parent.Name)
-    } else {
-        fmt.Printf("%s  Parent: None (root folder)\n", indent)
-    }
+// WithEventBus publishes an event to bus after every mutation.
+func WithEventBus(bus *EventBus) Option {
+	return func(f *driver) {
+		f.eventBus = bus
+	}
+}
 
-    // Recursively print child folders
-    if len(folder.Children) > 0 {
-        fmt.Printf("%s  Children:\n", indent)
-        for _, child := range folder.Children {
-            printFolder(child, level+1)
-        }
-    } else {
-        fmt.Printf("%s  Children: None\n", indent)
-    }
+func NewDriver(folders []*Folder, opts ...Option) *driver {
+	f := &driver{
+		// initialize attributes here
+		folders: folders,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
@@ -0,0 +1,259 @@
+package folder
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"github.com/gofrs/uuid"
+	"github.com/lucasepe/codename"
+)
+
+// Shape selects how GenerateDataWithConfig grows each tree.
+type Shape int
+
+const (
+	// ShapeBalanced gives every folder above the depth limit a number of children drawn from Branching.
+	ShapeBalanced Shape = iota
+	// ShapeSkewed is like ShapeBalanced, but only the first child of each folder has children.
+	ShapeSkewed
+	// ShapeWide gives every folder Branching.Max children, in trees Depth.Min levels deep.
+	ShapeWide
+	// ShapeDeep gives every folder Branching.Min children, in trees Depth.Max levels deep.
+	ShapeDeep
+)
+
+var shapeNames = []string{"balanced", "skewed", "wide", "deep"}
+
+func (s Shape) String() string {
+	if s < 0 || int(s) >= len(shapeNames) {
+		return fmt.Sprintf("Shape(%d)", int(s))
+	}
+	return shapeNames[s]
+}
+
+// ParseShape returns the Shape with the given name.
+func ParseShape(name string) (Shape, error) {
+	for i, shapeName := range shapeNames {
+		if shapeName == name {
+			return Shape(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown shape '%s'", name)
+}
+
+// Distribution is an inclusive range of integers, sampled uniformly.
+type Distribution struct {
+	Min int
+	Max int
+}
+
+func (d Distribution) sample(rng *rand.Rand) int {
+	return d.Min + rng.Intn(d.Max-d.Min+1)
+}
+
+// GeneratorConfig configures GenerateDataWithConfig.
+// The same config, seed included, always generates the same folders.
+type GeneratorConfig struct {
+	Seed int64
+	// Orgs is the number of organizations; the first one is DefaultOrgID.
+	Orgs int
+	// RootsPerOrg is the number of root folders of each organization.
+	RootsPerOrg Distribution
+	// Branching is the number of children of each folder above the depth limit.
+	Branching Distribution
+	// Depth is the number of levels of each tree, counting its root.
+	Depth Distribution
+	Shape Shape
+	// CollisionRate is the probability that a folder reuses the name of another folder
+	// of its organization; siblings never share a name, so paths stay unique.
+	// At 0, every name is unique across the dataset.
+	CollisionRate float64
+}
+
+// DefaultGeneratorConfig returns the config matching MaxRootSet, MaxChild and MaxDepth.
+func DefaultGeneratorConfig() GeneratorConfig {
+	return GeneratorConfig{
+		Orgs:        3,
+		RootsPerOrg: Distribution{Min: 1, Max: MaxRootSet / 2},
+		Branching:   Distribution{Min: 1, Max: MaxChild},
+		Depth:       Distribution{Min: MaxDepth, Max: MaxDepth},
+	}
+}
+
+// Validate checks that the config describes a dataset that can be generated.
+func (c GeneratorConfig) Validate() error {
+	var errs []error
+	if c.Orgs < 0 {
+		errs = append(errs, fmt.Errorf("orgs must not be negative, got %d", c.Orgs))
+	}
+	for _, d := range []struct {
+		name string
+		min  int
+		Distribution
+	}{
+		{"roots per org", 0, c.RootsPerOrg},
+		{"branching", 0, c.Branching},
+		{"depth", 1, c.Depth},
+	} {
+		if d.Min < d.min || d.Max < d.Min {
+			errs = append(errs, fmt.Errorf("%s must be a range from at least %d, got %d-%d", d.name, d.min, d.Min, d.Max))
+		}
+	}
+	if c.Shape < ShapeBalanced || c.Shape > ShapeDeep {
+		errs = append(errs, fmt.Errorf("unknown shape %d", int(c.Shape)))
+	}
+	if c.CollisionRate < 0 || c.CollisionRate > 1 {
+		errs = append(errs, fmt.Errorf("collision rate must be between 0 and 1, got %g", c.CollisionRate))
+	}
+	return errors.Join(errs...)
+}
+
+// slabSize is the number of folders allocated at once by the generator.
+const slabSize = 4096
+
+// generator holds the state of a single GenerateDataWithConfig call.
+type generator struct {
+	config GeneratorConfig
+	rng    *rand.Rand
+	uses   map[string]int // number of times each codename was generated, across organizations
+	names  []string       // names used in the current organization, for collisions
+	roots  []*Folder      // roots of the current organization, the siblings of a new root
+	slab   []Folder       // folders are allocated in slabs rather than one at a time
+	res    []*Folder
+}
+
+// GenerateDataWithConfig generates folders, listed depth first and linked through Parent and Children,
+// as described by config. Names are unique across the dataset, so MoveFolder can move any folder by name,
+// except for the ones deliberately reused at CollisionRate, which MoveFolderByPath moves.
+func GenerateDataWithConfig(config GeneratorConfig) ([]*Folder, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		config: config,
+		rng:    rand.New(rand.NewSource(config.Seed)),
+		uses:   make(map[string]int),
+	}
+	for i := 0; i < config.Orgs; i++ {
+		orgID := uuid.FromStringOrNil(DefaultOrgID)
+		if i > 0 {
+			orgID = g.orgID()
+		}
+
+		g.names, g.roots = g.names[:0], g.roots[:0]
+		roots := config.RootsPerOrg.sample(g.rng)
+		for j := 0; j < roots; j++ {
+			g.roots = append(g.roots, g.generateTree(nil, orgID, g.name(g.roots), 1, g.depth()))
+		}
+	}
+
+	return g.res, nil
+}
+
+// generateTree appends a folder under parent and its subtree, down to maxDepth levels, and returns the folder.
+func (g *generator) generateTree(parent *Folder, orgID uuid.UUID, name string, depth, maxDepth int) *Folder {
+	folder := g.alloc()
+	folder.Name, folder.OrgId, folder.Paths, folder.Parent = name, orgID, name, parent
+	if parent != nil {
+		folder.Paths = parent.Paths + "." + name
+		parent.Children = append(parent.Children, folder)
+	}
+	g.res = append(g.res, folder)
+	if depth >= maxDepth {
+		return folder
+	}
+
+	children := g.branching()
+	if children > 0 {
+		folder.Children = make([]*Folder, 0, children)
+	}
+	for i := 0; i < children; i++ {
+		childDepth := maxDepth
+		if g.config.Shape == ShapeSkewed && i > 0 {
+			childDepth = depth + 1
+		}
+		g.generateTree(folder, orgID, g.name(folder.Children), depth+1, childDepth)
+	}
+	return folder
+}
+
+// alloc returns a zero folder from the current slab.
+func (g *generator) alloc() *Folder {
+	if len(g.slab) == cap(g.slab) {
+		g.slab = make([]Folder, 0, slabSize)
+	}
+	g.slab = g.slab[:len(g.slab)+1]
+	return &g.slab[len(g.slab)-1]
+}
+
+// depth returns the number of levels of a new tree.
+func (g *generator) depth() int {
+	switch g.config.Shape {
+	case ShapeWide:
+		return g.config.Depth.Min
+	case ShapeDeep:
+		return g.config.Depth.Max
+	}
+	return g.config.Depth.sample(g.rng)
+}
+
+// branching returns the number of children of a new folder.
+func (g *generator) branching() int {
+	switch g.config.Shape {
+	case ShapeWide:
+		return g.config.Branching.Max
+	case ShapeDeep:
+		return g.config.Branching.Min
+	}
+	return g.config.Branching.sample(g.rng)
+}
+
+// name returns a name not used by the siblings. At CollisionRate it reuses a name of the organization,
+// otherwise it returns a name not used anywhere yet.
+func (g *generator) name(siblings []*Folder) string {
+	if len(g.names) > 0 && g.rng.Float64() < g.config.CollisionRate {
+		name := g.names[g.rng.Intn(len(g.names))]
+		if !hasChild(siblings, name) {
+			g.names = append(g.names, name)
+			return name
+		}
+	}
+
+	name := g.uniqueName()
+	g.names = append(g.names, name)
+	return name
+}
+
+// hasChild reports whether one of the folders is named name.
+func hasChild(folders []*Folder, name string) bool {
+	for _, folder := range folders {
+		if folder.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns a codename, suffixed with the number of times it was generated before if any.
+// Codenames never end with a digit, so suffixed names never clash with other codenames.
+func (g *generator) uniqueName() string {
+	base := codename.Generate(g.rng, 0)
+	uses := g.uses[base]
+	g.uses[base] = uses + 1
+	if uses == 0 {
+		return base
+	}
+	return base + "-" + strconv.Itoa(uses+1)
+}
+
+// orgID returns a version 4 UUID drawn from the generator's source.
+func (g *generator) orgID() uuid.UUID {
+	var id uuid.UUID
+	g.rng.Read(id[:])
+	id.SetVersion(uuid.V4)
+	id.SetVariant(uuid.VariantRFC4122)
+	return id
+}
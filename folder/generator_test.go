@@ -0,0 +1,305 @@
+package folder_test
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// generated generates folders with config.
+func generated(t *testing.T, config folder.GeneratorConfig) []*folder.Folder {
+	folders, err := folder.GenerateDataWithConfig(config)
+	assert.NoError(t, err)
+	return folders
+}
+
+// treeStats returns the number of children of every folder and the deepest level of the folders.
+func treeStats(folders []*folder.Folder) (map[string]int, int) {
+	children := make(map[string]int)
+	depth := 0
+	for _, f := range folders {
+		levels := strings.Count(f.Paths, ".") + 1
+		if levels > depth {
+			depth = levels
+		}
+		if idx := strings.LastIndex(f.Paths, "."); idx >= 0 {
+			children[f.OrgId.String()+"/"+f.Paths[:idx]]++
+		}
+	}
+	return children, depth
+}
+
+// Test_folder_GenerateDataWithConfig tests reproducibility and the shapes of generated data.
+func Test_folder_GenerateDataWithConfig(t *testing.T) {
+	t.Parallel()
+
+	config := folder.GeneratorConfig{
+		Seed:        42,
+		Orgs:        3,
+		RootsPerOrg: folder.Distribution{Min: 2, Max: 3},
+		Branching:   folder.Distribution{Min: 1, Max: 3},
+		Depth:       folder.Distribution{Min: 2, Max: 4},
+	}
+
+	t.Run("same seed, same data", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, paths(generated(t, config)), paths(generated(t, config)))
+
+		other := config
+		other.Seed = 43
+		assert.NotEqual(t, paths(generated(t, config)), paths(generated(t, other)))
+	})
+
+	t.Run("valid dataset", func(t *testing.T) {
+		t.Parallel()
+		folders := generated(t, config)
+		data, err := folder.MarshalDataset(folders)
+		assert.NoError(t, err)
+		_, err = folder.LoadDataset(data)
+		assert.NoError(t, err)
+	})
+
+	t.Run("organizations", func(t *testing.T) {
+		t.Parallel()
+		orgs := make(map[uuid.UUID]bool)
+		roots := 0
+		for _, f := range generated(t, config) {
+			orgs[f.OrgId] = true
+			if !strings.Contains(f.Paths, ".") {
+				roots++
+			}
+		}
+		assert.Len(t, orgs, 3)
+		assert.True(t, orgs[uuid.FromStringOrNil(folder.DefaultOrgID)])
+		assert.GreaterOrEqual(t, roots, 6)
+		assert.LessOrEqual(t, roots, 9)
+	})
+
+	tests := [...]struct {
+		name  string
+		shape folder.Shape
+		check func(t *testing.T, children map[string]int, depth int)
+	}{
+		{
+			name:  "balanced",
+			shape: folder.ShapeBalanced,
+			check: func(t *testing.T, children map[string]int, depth int) {
+				assert.LessOrEqual(t, depth, 4)
+				for parent, n := range children {
+					assert.True(t, n >= 1 && n <= 3, "%s has %d children", parent, n)
+				}
+			},
+		},
+		{
+			// Every folder has 3 children, in trees of 2 levels
+			name:  "wide",
+			shape: folder.ShapeWide,
+			check: func(t *testing.T, children map[string]int, depth int) {
+				assert.Equal(t, 2, depth)
+				for parent, n := range children {
+					assert.Equal(t, 3, n, parent)
+				}
+			},
+		},
+		{
+			// Every folder has a single child, in trees of 4 levels
+			name:  "deep",
+			shape: folder.ShapeDeep,
+			check: func(t *testing.T, children map[string]int, depth int) {
+				assert.Equal(t, 4, depth)
+				for parent, n := range children {
+					assert.Equal(t, 1, n, parent)
+				}
+			},
+		},
+		{
+			// At most one child of every folder has children itself
+			name:  "skewed",
+			shape: folder.ShapeSkewed,
+			check: func(t *testing.T, children map[string]int, depth int) {
+				parents := make(map[string]int)
+				for parent := range children {
+					if idx := strings.LastIndex(parent, "."); idx >= 0 {
+						parents[parent[:idx]]++
+					}
+				}
+				for grandparent, n := range parents {
+					assert.Equal(t, 1, n, grandparent)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			shaped := config
+			shaped.Shape = tt.shape
+			children, depth := treeStats(generated(t, shaped))
+			tt.check(t, children, depth)
+		})
+	}
+}
+
+// Test_folder_GenerateDataWithConfig_Collisions tests that CollisionRate reuses names within an organization.
+func Test_folder_GenerateDataWithConfig_Collisions(t *testing.T) {
+	t.Parallel()
+
+	config := folder.GeneratorConfig{
+		Seed:          7,
+		Orgs:          2,
+		RootsPerOrg:   folder.Distribution{Min: 3, Max: 3},
+		Branching:     folder.Distribution{Min: 2, Max: 2},
+		Depth:         folder.Distribution{Min: 4, Max: 4},
+		CollisionRate: 0.5,
+	}
+	folders := generated(t, config)
+
+	names := make(map[string]map[string]bool) // org -> name -> seen
+	collisions := 0
+	for _, f := range folders {
+		org := f.OrgId.String()
+		if names[org] == nil {
+			names[org] = make(map[string]bool)
+		}
+		if names[org][f.Name] {
+			collisions++
+		}
+		names[org][f.Name] = true
+	}
+	assert.Greater(t, collisions, len(folders)/4)
+
+	// Paths stay unique
+	_, err := folder.ToAdjacencyList(folders)
+	assert.NoError(t, err)
+}
+
+// Test_folder_GeneratorConfig_Validate tests the errors of invalid configs.
+func Test_folder_GeneratorConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name    string
+		config  func(c *folder.GeneratorConfig)
+		wantErr string
+	}{
+		{
+			name:   "Default",
+			config: func(c *folder.GeneratorConfig) {},
+		},
+		{
+			name:    "Negative orgs",
+			config:  func(c *folder.GeneratorConfig) { c.Orgs = -1 },
+			wantErr: "orgs must not be negative, got -1",
+		},
+		{
+			name:    "Empty range",
+			config:  func(c *folder.GeneratorConfig) { c.Branching = folder.Distribution{Min: 3, Max: 2} },
+			wantErr: "branching must be a range from at least 0, got 3-2",
+		},
+		{
+			// Trees have at least their root
+			name:    "Zero depth",
+			config:  func(c *folder.GeneratorConfig) { c.Depth = folder.Distribution{} },
+			wantErr: "depth must be a range from at least 1, got 0-0",
+		},
+		{
+			name: "Several errors",
+			config: func(c *folder.GeneratorConfig) {
+				c.Shape = folder.Shape(9)
+				c.CollisionRate = 1.5
+			},
+			wantErr: "unknown shape 9\ncollision rate must be between 0 and 1, got 1.5",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			config := folder.DefaultGeneratorConfig()
+			tt.config(&config)
+
+			_, err := folder.GenerateDataWithConfig(config)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_folder_GenerateDataWithConfig_Linked tests that generated folders are linked and uniquely named.
+func Test_folder_GenerateDataWithConfig_Linked(t *testing.T) {
+	t.Parallel()
+
+	// Well beyond the point where codenames start to repeat
+	config := folder.GeneratorConfig{
+		Seed:        3,
+		Orgs:        4,
+		RootsPerOrg: folder.Distribution{Min: 5, Max: 5},
+		Branching:   folder.Distribution{Min: 10, Max: 10},
+		Depth:       folder.Distribution{Min: 3, Max: 3},
+		Shape:       folder.ShapeWide,
+	}
+	folders := generated(t, config)
+	assert.Len(t, folders, 4*5*111)
+
+	names := make(map[string]bool, len(folders))
+	suffixed := 0
+	for _, f := range folders {
+		assert.False(t, names[f.Name], "duplicate name %s", f.Name)
+		names[f.Name] = true
+		if strings.Count(f.Name, "-") > 1 {
+			suffixed++
+		}
+
+		if f.Parent == nil {
+			assert.Equal(t, f.Name, f.Paths)
+		} else {
+			assert.Equal(t, f.Parent.Paths+"."+f.Name, f.Paths)
+			assert.Equal(t, f.Parent.OrgId, f.OrgId)
+			assert.Contains(t, f.Parent.Children, f)
+		}
+		for _, child := range f.Children {
+			assert.Same(t, f, child.Parent)
+		}
+	}
+	assert.Greater(t, suffixed, 0)
+
+	// Names are unique across organizations, so the driver can move any folder by name
+	source, destination := folders[1], folders[111] // a child of the first root, and the second root
+	_, err := folder.NewDriver(folders).MoveFolder(context.Background(), source.Name, destination.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, destination.Name+"."+source.Name, source.Paths)
+}
+
+var generateFolders = flag.Int("generate-folders", 1000000, "approximate number of folders generated by BenchmarkGenerateDataWithConfig")
+
+// BenchmarkGenerateDataWithConfig generates about -generate-folders folders in trees of 11111 folders.
+func BenchmarkGenerateDataWithConfig(b *testing.B) {
+	config := folder.GeneratorConfig{
+		Orgs:        10,
+		RootsPerOrg: folder.Distribution{Min: *generateFolders / 111110, Max: *generateFolders / 111110},
+		Branching:   folder.Distribution{Min: 10, Max: 10},
+		Depth:       folder.Distribution{Min: 5, Max: 5},
+		Shape:       folder.ShapeWide,
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		config.Seed = int64(i)
+		folders, err := folder.GenerateDataWithConfig(config)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(len(folders)), "folders/op")
+	}
+}
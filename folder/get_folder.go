@@ -1,7 +1,7 @@
 package folder
 
 import (
-	"log" // Import log package to log errors
+	"context"
 	"strings"
 
 	"github.com/gofrs/uuid"
@@ -11,17 +11,34 @@ func GetAllFolders() []*Folder {
 	return GetSampleData()
 }
 
-func (f *driver) GetFoldersByOrgID(orgID uuid.UUID) []*Folder {
+func (f *driver) GetFoldersByOrgID(ctx context.Context, orgID uuid.UUID) ([]*Folder, error) {
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
 	folders := f.folders
 
 	res := []*Folder{}
-	for _, f := range folders {
+	for i, f := range folders {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
 		if f.OrgId == orgID {
 			res = append(res, f)
 		}
 	}
 
-	return res
+	return res, nil
+}
+
+// FindByPath returns the folder of an organization at the given path among folders, or nil.
+// Paths are unique within an organization, unlike names.
+func FindByPath(folders []*Folder, orgID uuid.UUID, path string) *Folder {
+	for _, folder := range folders {
+		if folder.OrgId == orgID && folder.Paths == path {
+			return folder
+		}
+	}
+	return nil
 }
 
 // func (f *driver) GetAllChildFolders(orgID uuid.UUID, name string) []Folder {
@@ -30,17 +47,20 @@ func (f *driver) GetFoldersByOrgID(orgID uuid.UUID) []*Folder {
 // 	return []Folder{}
 // }
 
-func (f *driver) GetAllChildFolders(orgID uuid.UUID, name string) []*Folder {
+func (f *driver) GetAllChildFolders(ctx context.Context, orgID uuid.UUID, name string) ([]*Folder, error) {
 	// Retrieve all folders belonging to the given organization ID.
-	folders := f.GetFoldersByOrgID(orgID)
+	folders, err := f.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if no folders exist for the orgID.
 	if len(folders) == 0 {
-		log.Printf("Error: No folders found for orgID '%s'", orgID)
-		return []*Folder{}
+		logf(ctx, "Error: No folders found for orgID '%s'", orgID)
+		return []*Folder{}, nil
 	}
 
-	// Find the base folder by the provided name.
+	// Find the base folder by the provided name; the first one if several folders share it.
 	var baseFolder *Folder
 	for _, folder := range folders {
 		if folder.Name == name {
@@ -51,8 +71,8 @@ func (f *driver) GetAllChildFolders(orgID uuid.UUID, name string) []*Folder {
 
 	// If the base folder doesn't exist, log the error and return an empty list.
 	if baseFolder == nil {
-		log.Printf("Error: Folder '%s' does not exist in orgID '%s'", name, orgID)
-		return []*Folder{}
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", name, orgID)
+		return []*Folder{}, nil
 	}
 
 	// Prepare the list to store all child folders.
@@ -62,7 +82,10 @@ func (f *driver) GetAllChildFolders(orgID uuid.UUID, name string) []*Folder {
 	basePath := baseFolder.Paths + "."
 
 	// Find all folders whose paths start with the base folder's path.
-	for _, folder := range folders {
+	for i, folder := range folders {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
 		// Ensure folder is a descendant (starts with basePath) but is not the base folder itself.
 		if strings.HasPrefix(folder.Paths, basePath) {
 			childFolders = append(childFolders, folder)
@@ -71,9 +94,33 @@ func (f *driver) GetAllChildFolders(orgID uuid.UUID, name string) []*Folder {
 
 	// If no child folders are found, log that the folder has no children.
 	if len(childFolders) == 0 {
-		log.Printf("Info: Folder '%s' has no child folders in orgID '%s'", name, orgID)
-		return []*Folder{}
+		logf(ctx, "Info: Folder '%s' has no child folders in orgID '%s'", name, orgID)
+		return []*Folder{}, nil
 	}
 
-	return childFolders
+	return childFolders, nil
+}
+
+// GetAllChildFoldersByPath returns all descendants of the folder at path, which, unlike a name, identifies
+// a single folder of the organization.
+func (f *driver) GetAllChildFoldersByPath(ctx context.Context, orgID uuid.UUID, path string) ([]*Folder, error) {
+	folders, err := f.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if FindByPath(folders, orgID, path) == nil {
+		logf(ctx, "Error: Folder '%s' does not exist in orgID '%s'", path, orgID)
+		return nil, errorf(ErrFolderNotFound, "folder '%s' does not exist", path)
+	}
+
+	res := []*Folder{}
+	for i, folder := range folders {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(folder.Paths, path+".") {
+			res = append(res, folder)
+		}
+	}
+	return res, nil
 }
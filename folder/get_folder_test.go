@@ -1,6 +1,7 @@
 package folder_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/georgechieng-sc/interns-2022/folder"
@@ -109,7 +110,8 @@ func Test_folder_GetFoldersByOrgID(t *testing.T) {
 			f := folder.NewDriver(tt.folders)
 
 			// Call GetFoldersByOrgID with the provided orgID
-			got := f.GetFoldersByOrgID(tt.orgID)
+			got, err := f.GetFoldersByOrgID(context.Background(), tt.orgID)
+			assert.NoError(t, err)
 
 			// Assert that the result matches the expected output
 			assert.Equal(t, tt.want, got)
@@ -278,10 +280,36 @@ func Test_folder_GetAllChildFolders(t *testing.T) {
 			f := folder.NewDriver(tt.folders)
 
 			// Call GetAllChildFolders with the provided orgID and base folder name
-			got := f.GetAllChildFolders(tt.orgID, tt.base)
+			got, err := f.GetAllChildFolders(context.Background(), tt.orgID, tt.base)
+			assert.NoError(t, err)
 
 			// Assert that the result matches the expected output
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+// Test_folder_GetAllChildFoldersByPath tests that descendants are found by path, also when names repeat.
+func Test_folder_GetAllChildFoldersByPath(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.Must(uuid.NewV4())
+	folders := []*folder.Folder{
+		{Name: "alpha", OrgId: orgID, Paths: "alpha"},
+		{Name: "x", OrgId: orgID, Paths: "alpha.x"},
+		{Name: "bravo", OrgId: orgID, Paths: "bravo"},
+		{Name: "x", OrgId: orgID, Paths: "bravo.x"},
+		{Name: "charlie", OrgId: orgID, Paths: "bravo.x.charlie"},
+	}
+	f := folder.NewDriver(folders)
+
+	got, err := f.GetAllChildFoldersByPath(context.Background(), orgID, "bravo.x")
+	assert.NoError(t, err)
+	assert.Equal(t, []*folder.Folder{folders[4]}, got)
+
+	got, err = f.GetAllChildFoldersByPath(context.Background(), orgID, "alpha.x")
+	assert.NoError(t, err)
+	assert.Equal(t, []*folder.Folder{}, got)
+
+	_, err = f.GetAllChildFoldersByPath(context.Background(), orgID, "x")
+	assert.ErrorIs(t, err, folder.ErrFolderNotFound)
+}
@@ -0,0 +1,180 @@
+package folder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// MoveHighlight marks a move in a graph export: the source folder with its subtree and the destination.
+type MoveHighlight struct {
+	OrgID       uuid.UUID
+	Source      string // path of the folder being moved
+	Destination string // path of the new parent
+}
+
+// GraphOptions configures WriteDOT and WriteMermaid.
+type GraphOptions struct {
+	// Highlight, if set, styles the folders affected by a move and draws the new edge.
+	Highlight *MoveHighlight
+}
+
+// Roles of a folder in a highlighted move.
+const (
+	roleNone = iota
+	roleSource
+	roleSubtree
+	roleDestination
+)
+
+// graph is the export-independent form of folders: nodes n<ID> grouped by organization, with parent edges.
+type graph struct {
+	orgs    []uuid.UUID
+	byOrg   map[uuid.UUID][]Node
+	parents []int
+	roles   []int
+	move    [2]int // destination and source IDs of the highlighted move, 0 if none
+}
+
+// buildGraph resolves the parent of every folder and the roles of a highlighted move.
+func buildGraph(folders []*Folder, opts GraphOptions) (*graph, error) {
+	nodes, parents, err := indexFolders(folders)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &graph{byOrg: make(map[uuid.UUID][]Node), parents: parents, roles: make([]int, len(folders))}
+	for _, node := range nodes {
+		if _, exists := g.byOrg[node.OrgId]; !exists {
+			g.orgs = append(g.orgs, node.OrgId)
+		}
+		g.byOrg[node.OrgId] = append(g.byOrg[node.OrgId], node)
+	}
+
+	if h := opts.Highlight; h != nil {
+		source, destination := 0, 0
+		for i, folder := range folders {
+			if folder.OrgId != h.OrgID {
+				continue
+			}
+			switch {
+			case folder.Paths == h.Source:
+				source = i + 1
+				g.roles[i] = roleSource
+			case strings.HasPrefix(folder.Paths, h.Source+"."):
+				g.roles[i] = roleSubtree
+			}
+			if folder.Paths == h.Destination {
+				destination = i + 1
+			}
+		}
+		if source == 0 {
+			return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist in orgID '%s'", h.Source, h.OrgID)
+		}
+		if destination == 0 {
+			return nil, errorf(ErrFolderNotFound, "destination folder '%s' does not exist in orgID '%s'", h.Destination, h.OrgID)
+		}
+		// A destination inside the subtree keeps its subtree role, the move would be rejected anyway.
+		if g.roles[destination-1] == roleNone {
+			g.roles[destination-1] = roleDestination
+		}
+		g.move = [2]int{destination, source}
+	}
+
+	return g, nil
+}
+
+// WriteDOT exports folders as a Graphviz digraph with one cluster per organization.
+func WriteDOT(w io.Writer, folders []*Folder, opts GraphOptions) error {
+	g, err := buildGraph(folders, opts)
+	if err != nil {
+		return err
+	}
+
+	styles := map[int]string{
+		roleSource:      `, style=filled, fillcolor="#ff9966"`,
+		roleSubtree:     `, style=filled, fillcolor="#ffe0cc"`,
+		roleDestination: `, style=filled, fillcolor="#99ccff"`,
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph folders {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=folder];")
+	for i, orgID := range g.orgs {
+		fmt.Fprintf(bw, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(bw, "\t\tlabel=\"org %s\";\n", orgID)
+		for _, node := range g.byOrg[orgID] {
+			fmt.Fprintf(bw, "\t\tn%d [label=\"%s\"%s];\n", node.ID, dotEscape(node.Name), styles[g.roles[node.ID-1]])
+		}
+		for _, node := range g.byOrg[orgID] {
+			if parent := g.parents[node.ID-1]; parent != 0 {
+				fmt.Fprintf(bw, "\t\tn%d -> n%d;\n", parent, node.ID)
+			}
+		}
+		fmt.Fprintln(bw, "\t}")
+	}
+	if g.move[0] != 0 {
+		fmt.Fprintf(bw, "\tn%d -> n%d [style=dashed, color=\"#ff6600\", label=\"move\"];\n", g.move[0], g.move[1])
+	}
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// WriteMermaid exports folders as a Mermaid flowchart with one subgraph per organization.
+func WriteMermaid(w io.Writer, folders []*Folder, opts GraphOptions) error {
+	g, err := buildGraph(folders, opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+	for i, orgID := range g.orgs {
+		fmt.Fprintf(bw, "\tsubgraph org_%d [\"org %s\"]\n", i, orgID)
+		for _, node := range g.byOrg[orgID] {
+			fmt.Fprintf(bw, "\t\tn%d[\"%s\"]\n", node.ID, mermaidEscape(node.Name))
+		}
+		for _, node := range g.byOrg[orgID] {
+			if parent := g.parents[node.ID-1]; parent != 0 {
+				fmt.Fprintf(bw, "\t\tn%d --> n%d\n", parent, node.ID)
+			}
+		}
+		fmt.Fprintln(bw, "\tend")
+	}
+
+	if g.move[0] != 0 {
+		fmt.Fprintf(bw, "\tn%d -.->|move| n%d\n", g.move[0], g.move[1])
+		fmt.Fprintln(bw, "\tclassDef source fill:#ff9966")
+		fmt.Fprintln(bw, "\tclassDef subtree fill:#ffe0cc")
+		fmt.Fprintln(bw, "\tclassDef destination fill:#99ccff")
+		classes := map[int]string{roleSource: "source", roleSubtree: "subtree", roleDestination: "destination"}
+		for _, role := range []int{roleSource, roleSubtree, roleDestination} {
+			var ids []string
+			for i, r := range g.roles {
+				if r == role {
+					ids = append(ids, fmt.Sprintf("n%d", i+1))
+				}
+			}
+			if len(ids) > 0 {
+				fmt.Fprintf(bw, "\tclass %s %s\n", strings.Join(ids, ","), classes[role])
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// dotEscape escapes the characters that end a quoted DOT string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// mermaidEscape escapes the characters that end a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
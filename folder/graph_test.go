@@ -0,0 +1,114 @@
+package folder_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_GraphExport compares WriteDOT and WriteMermaid output with the golden files in testdata/graph.
+func Test_folder_GraphExport(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.FromStringOrNil("c1556e17-b7c0-45a3-a6ae-9546248fb17a")
+	orgID2 := uuid.FromStringOrNil("38b9879b-f73b-4b0e-b9d9-4fc4c23643a7")
+	folders, _ := initializeFolders(orgID1, orgID2)
+	move := &folder.MoveHighlight{OrgID: orgID1, Source: "alpha.bravo", Destination: "alpha.delta"}
+
+	writers := map[string]func(io.Writer, []*folder.Folder, folder.GraphOptions) error{
+		"dot":     folder.WriteDOT,
+		"mermaid": folder.WriteMermaid,
+	}
+
+	tests := [...]struct {
+		name string
+		opts folder.GraphOptions
+	}{
+		{
+			name: "plain",
+		},
+		{
+			// bravo and charlie are highlighted as the moved subtree, delta as the destination
+			name: "move",
+			opts: folder.GraphOptions{Highlight: move},
+		},
+	}
+
+	for _, tt := range tests {
+		for format, write := range writers {
+			tt, format, write := tt, format, write
+			t.Run(tt.name+"-"+format, func(t *testing.T) {
+				t.Parallel()
+				var buf bytes.Buffer
+				assert.NoError(t, write(&buf, folders, tt.opts))
+
+				golden := filepath.Join("testdata", "graph", tt.name+"."+format)
+				if *update {
+					assert.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+				}
+				want, err := os.ReadFile(golden)
+				assert.NoError(t, err)
+				assert.Equal(t, string(want), buf.String())
+			})
+		}
+	}
+}
+
+// Test_folder_GraphExport_Errors tests the errors of the graph exporters.
+func Test_folder_GraphExport_Errors(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, _ := initializeFolders(orgID1, orgID2)
+
+	tests := [...]struct {
+		name    string
+		folders []*folder.Folder
+		opts    folder.GraphOptions
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "Missing source",
+			folders: folders,
+			opts:    folder.GraphOptions{Highlight: &folder.MoveHighlight{OrgID: orgID1, Source: "alpha.zulu", Destination: "golf"}},
+			wantErr: folder.ErrFolderNotFound,
+			wantMsg: "source folder 'alpha.zulu' does not exist in orgID '" + orgID1.String() + "'",
+		},
+		{
+			// The destination must be in the highlighted organization
+			name:    "Destination in another organization",
+			folders: folders,
+			opts:    folder.GraphOptions{Highlight: &folder.MoveHighlight{OrgID: orgID1, Source: "golf", Destination: "foxtrot"}},
+			wantErr: folder.ErrFolderNotFound,
+			wantMsg: "destination folder 'foxtrot' does not exist in orgID '" + orgID1.String() + "'",
+		},
+		{
+			name:    "Missing parent",
+			folders: []*folder.Folder{{Name: "bravo", OrgId: orgID1, Paths: "alpha.bravo"}},
+			wantMsg: "parent path 'alpha' of folder 'bravo' does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for _, write := range []func(io.Writer, []*folder.Folder, folder.GraphOptions) error{folder.WriteDOT, folder.WriteMermaid} {
+				err := write(io.Discard, tt.folders, tt.opts)
+				assert.EqualError(t, err, tt.wantMsg)
+				if tt.wantErr != nil {
+					assert.True(t, errors.Is(err, tt.wantErr))
+				}
+			}
+		})
+	}
+}
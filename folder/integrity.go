@@ -0,0 +1,443 @@
+package folder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// IssueCode identifies a kind of inconsistency found by Validate.
+type IssueCode string
+
+const (
+	// IssueInvalidName is a name that is empty or contains '.'.
+	IssueInvalidName IssueCode = "invalid_name"
+	// IssueInvalidPath is a path with an empty label.
+	IssueInvalidPath IssueCode = "invalid_path"
+	// IssueNameMismatch is a path whose last label isn't the folder's name.
+	IssueNameMismatch IssueCode = "name_mismatch"
+	// IssueDuplicatePath is a path already used by another folder of the organization.
+	IssueDuplicatePath IssueCode = "duplicate_path"
+	// IssueMissingParent is a path whose parent path has no folder.
+	IssueMissingParent IssueCode = "missing_parent"
+	// IssueParentMismatch is a Parent pointer that disagrees with the path.
+	IssueParentMismatch IssueCode = "parent_mismatch"
+	// IssueChildMismatch is a Children list that disagrees with the Parent pointers.
+	IssueChildMismatch IssueCode = "child_mismatch"
+	// IssueDanglingParent is a Parent pointer to a folder outside the dataset.
+	IssueDanglingParent IssueCode = "dangling_parent"
+	// IssueCrossOrg is a Parent pointer to a folder of another organization.
+	IssueCrossOrg IssueCode = "cross_org"
+	// IssueCycle is a folder that is its own ancestor through Parent pointers.
+	IssueCycle IssueCode = "cycle"
+)
+
+// Issue is an inconsistency of the folder at Index in the validated slice.
+type Issue struct {
+	Code  IssueCode
+	Index int
+	Path  string
+	Msg   string
+}
+
+func (i Issue) Error() string {
+	return fmt.Sprintf("%s: folder %d '%s': %s", i.Code, i.Index, i.Path, i.Msg)
+}
+
+// Validate reports every inconsistency between the names, paths and pointers of folders.
+// Pointers are only checked if at least one folder has a Parent or Children,
+// so that folders decoded without linking aren't reported as inconsistent.
+func Validate(folders []*Folder) []Issue {
+	var issues []Issue
+	report := func(code IssueCode, i int, format string, args ...interface{}) {
+		issues = append(issues, Issue{Code: code, Index: i, Path: folders[i].Paths, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	byPath := make(map[string]int, len(folders))
+	for i, folder := range folders {
+		key := pathKey(folder.OrgId, folder.Paths)
+		if first, exists := byPath[key]; exists {
+			report(IssueDuplicatePath, i, "path is also used by folder %d", first)
+			continue
+		}
+		byPath[key] = i
+	}
+
+	linked := false
+	index := make(map[*Folder]int, len(folders))
+	for i, folder := range folders {
+		index[folder] = i
+		linked = linked || folder.Parent != nil || len(folder.Children) > 0
+	}
+
+	for i, folder := range folders {
+		if folder.Name == "" || strings.Contains(folder.Name, ".") {
+			report(IssueInvalidName, i, "invalid name '%s'", folder.Name)
+		}
+		if !validPath(folder.Paths) {
+			report(IssueInvalidPath, i, "path has an empty label")
+			continue
+		}
+
+		idx := strings.LastIndex(folder.Paths, ".")
+		if folder.Paths[idx+1:] != folder.Name {
+			report(IssueNameMismatch, i, "last label of the path is not the name '%s'", folder.Name)
+		}
+
+		var expected *Folder
+		if idx >= 0 {
+			parent, exists := byPath[pathKey(folder.OrgId, folder.Paths[:idx])]
+			if !exists {
+				report(IssueMissingParent, i, "parent path '%s' does not exist", folder.Paths[:idx])
+			} else {
+				expected = folders[parent]
+			}
+		}
+		if !linked {
+			continue
+		}
+
+		switch parent := folder.Parent; {
+		case parent != nil && !containsFolder(index, parent):
+			report(IssueDanglingParent, i, "parent '%s' is not in the dataset", parent.Paths)
+		case parent != nil && parent.OrgId != folder.OrgId:
+			report(IssueCrossOrg, i, "parent '%s' belongs to organization '%s'", parent.Paths, parent.OrgId)
+		case parent != expected && (expected != nil || idx < 0):
+			report(IssueParentMismatch, i, "parent is %s, path implies %s", describe(parent), describe(expected))
+		}
+
+		if parent := folder.Parent; parent != nil && !hasChildPointer(parent, folder) {
+			report(IssueChildMismatch, i, "missing from the children of its parent '%s'", parent.Paths)
+		}
+		for _, child := range folder.Children {
+			if child.Parent != folder {
+				report(IssueChildMismatch, i, "child '%s' has parent %s", child.Paths, describe(child.Parent))
+			}
+		}
+	}
+
+	for _, i := range cycleStarts(folders, index) {
+		report(IssueCycle, i, "folder is its own ancestor")
+	}
+
+	return issues
+}
+
+// RepairPolicy selects which side Repair trusts when paths and pointers disagree.
+type RepairPolicy int
+
+const (
+	// TrustPaths rebuilds the pointers from the paths.
+	TrustPaths RepairPolicy = iota
+	// TrustPointers rebuilds the paths from the Parent pointers.
+	TrustPointers
+)
+
+// DefaultLostAndFound is the conventional name of the folder orphans are re-rooted under.
+const DefaultLostAndFound = "lost+found"
+
+// RepairOptions configures Repair.
+type RepairOptions struct {
+	Policy RepairPolicy
+	// LostAndFound is the name of the root folder, created in each organization as needed,
+	// that orphans are moved under. Empty makes orphans roots.
+	LostAndFound string
+}
+
+// Repair fixes the folders so that Validate reports no issue, and returns the issues found before.
+// Folders are fixed in place; the returned slice drops duplicate paths and appends any lost+found folders.
+//
+// Orphans are folders whose parent is missing with TrustPaths, and folders whose Parent is outside
+// the dataset, in another organization or in a cycle with TrustPointers. Invalid names are replaced
+// by the last label of the path or sanitized, and names taken by a sibling get a numeric suffix.
+func Repair(folders []*Folder, opts RepairOptions) ([]*Folder, []Issue, error) {
+	if opts.LostAndFound != "" {
+		if err := validateName(opts.LostAndFound); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	issues := Validate(folders)
+	r := &repairer{opts: opts, lostAndFound: make(map[uuid.UUID]*Folder)}
+	switch opts.Policy {
+	case TrustPaths:
+		r.trustPaths(folders)
+	case TrustPointers:
+		r.trustPointers(folders)
+	default:
+		return nil, nil, fmt.Errorf("unknown repair policy %d", opts.Policy)
+	}
+
+	// Orphans and their subtrees get paths once every folder is linked.
+	for _, orphan := range r.orphans {
+		r.reroot(orphan)
+	}
+	repaired := append(r.folders, r.created...)
+	for _, folder := range repaired {
+		if folder.Parent == nil {
+			setPaths(folder, "")
+		}
+	}
+
+	return repaired, issues, nil
+}
+
+// repairer holds the state of a single Repair call.
+type repairer struct {
+	opts         RepairOptions
+	folders      []*Folder
+	orphans      []*Folder
+	roots        map[uuid.UUID]map[string]bool // root names of each organization
+	lostAndFound map[uuid.UUID]*Folder
+	created      []*Folder
+}
+
+// trustPaths keeps the first folder of every path and links the folders from their paths.
+func (r *repairer) trustPaths(folders []*Folder) {
+	byPath := make(map[string]*Folder, len(folders))
+	for i, folder := range folders {
+		var labels []string
+		for _, label := range strings.Split(folder.Paths, ".") {
+			if label != "" {
+				labels = append(labels, label)
+			}
+		}
+		if len(labels) == 0 {
+			labels = []string{sanitizeName(folder.Name, i)}
+		}
+		folder.Paths = strings.Join(labels, ".")
+		folder.Name = labels[len(labels)-1]
+
+		key := pathKey(folder.OrgId, folder.Paths)
+		if _, exists := byPath[key]; exists {
+			continue
+		}
+		byPath[key] = folder
+		folder.Parent, folder.Children = nil, nil
+		r.folders = append(r.folders, folder)
+	}
+
+	for _, folder := range r.folders {
+		idx := strings.LastIndex(folder.Paths, ".")
+		if idx < 0 {
+			continue
+		}
+		if parent, exists := byPath[pathKey(folder.OrgId, folder.Paths[:idx])]; exists {
+			folder.Parent = parent
+			parent.Children = append(parent.Children, folder)
+		} else {
+			r.orphans = append(r.orphans, folder)
+		}
+	}
+	r.indexRoots()
+}
+
+// trustPointers detaches the folders whose Parent can't be trusted and rebuilds the children from the rest.
+func (r *repairer) trustPointers(folders []*Folder) {
+	index := make(map[*Folder]int, len(folders))
+	for i, folder := range folders {
+		if _, exists := index[folder]; exists {
+			continue
+		}
+		index[folder] = i
+		r.folders = append(r.folders, folder)
+	}
+
+	detached := make(map[*Folder]bool)
+	for _, folder := range r.folders {
+		if parent := folder.Parent; parent != nil && (!containsFolder(index, parent) || parent.OrgId != folder.OrgId) {
+			detached[folder] = true
+		}
+	}
+	for _, i := range cycleStarts(folders, index) {
+		detached[folders[i]] = true
+	}
+
+	for _, folder := range r.folders {
+		if folder.Name == "" || strings.Contains(folder.Name, ".") {
+			label := folder.Paths[strings.LastIndex(folder.Paths, ".")+1:]
+			if label != "" {
+				folder.Name = label
+			} else {
+				folder.Name = sanitizeName(folder.Name, index[folder])
+			}
+		}
+		folder.Children = nil
+		if detached[folder] {
+			folder.Parent = nil
+			r.orphans = append(r.orphans, folder)
+		}
+	}
+	for _, folder := range r.folders {
+		if folder.Parent != nil {
+			folder.Parent.Children = append(folder.Parent.Children, folder)
+		}
+	}
+	r.indexRoots()
+}
+
+// indexRoots records the root names of every organization, renaming roots that share a name.
+func (r *repairer) indexRoots() {
+	r.roots = make(map[uuid.UUID]map[string]bool)
+	orphan := make(map[*Folder]bool, len(r.orphans))
+	for _, folder := range r.orphans {
+		orphan[folder] = true
+	}
+
+	for _, folder := range r.folders {
+		if folder.Parent != nil || orphan[folder] {
+			continue
+		}
+		if r.roots[folder.OrgId] == nil {
+			r.roots[folder.OrgId] = make(map[string]bool)
+		}
+		folder.Name = freeName(r.roots[folder.OrgId], folder.Name)
+	}
+}
+
+// reroot moves an orphan under the lost+found folder of its organization, or makes it a root.
+func (r *repairer) reroot(orphan *Folder) {
+	if r.opts.LostAndFound == "" {
+		if r.roots[orphan.OrgId] == nil {
+			r.roots[orphan.OrgId] = make(map[string]bool)
+		}
+		orphan.Name = freeName(r.roots[orphan.OrgId], orphan.Name)
+		orphan.Parent = nil
+		return
+	}
+
+	parent := r.lostAndFoundOf(orphan.OrgId)
+	taken := make(map[string]bool, len(parent.Children))
+	for _, child := range parent.Children {
+		taken[child.Name] = true
+	}
+	orphan.Name = freeName(taken, orphan.Name)
+	orphan.Parent = parent
+	parent.Children = append(parent.Children, orphan)
+}
+
+// lostAndFoundOf returns the lost+found root of an organization, creating it if needed.
+func (r *repairer) lostAndFoundOf(orgID uuid.UUID) *Folder {
+	if folder, exists := r.lostAndFound[orgID]; exists {
+		return folder
+	}
+
+	var folder *Folder
+	for _, f := range r.folders {
+		if f.OrgId == orgID && f.Parent == nil && f.Name == r.opts.LostAndFound {
+			folder = f
+			break
+		}
+	}
+	if folder == nil {
+		folder = &Folder{Name: r.opts.LostAndFound, OrgId: orgID, Paths: r.opts.LostAndFound}
+		if r.roots[orgID] == nil {
+			r.roots[orgID] = make(map[string]bool)
+		}
+		r.roots[orgID][folder.Name] = true
+		r.created = append(r.created, folder)
+	}
+
+	r.lostAndFound[orgID] = folder
+	return folder
+}
+
+// setPaths sets the path of a folder under parentPath and of its subtree,
+// renaming children that share a name with an earlier sibling.
+func setPaths(folder *Folder, parentPath string) {
+	folder.Paths = folder.Name
+	if parentPath != "" {
+		folder.Paths = parentPath + "." + folder.Name
+	}
+
+	taken := make(map[string]bool, len(folder.Children))
+	for _, child := range folder.Children {
+		child.Name = freeName(taken, child.Name)
+		setPaths(child, folder.Paths)
+	}
+}
+
+// freeName returns name, or name with the first free numeric suffix, and marks it as taken.
+func freeName(taken map[string]bool, name string) string {
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		unique = name + "-" + strconv.Itoa(n)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// sanitizeName turns an invalid name into a valid one, using the folder's index if it's empty.
+func sanitizeName(name string, index int) string {
+	name = strings.ReplaceAll(name, ".", "_")
+	if name == "" {
+		name = fmt.Sprintf("folder-%d", index)
+	}
+	return name
+}
+
+// cycleStarts returns the index of the first folder, in input order, of every cycle of Parent pointers.
+func cycleStarts(folders []*Folder, index map[*Folder]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[*Folder]int, len(folders))
+
+	var starts []int
+	for _, folder := range folders {
+		var chain []*Folder
+		current := folder
+		for current != nil && state[current] == unvisited && containsFolder(index, current) {
+			state[current] = visiting
+			chain = append(chain, current)
+			current = current.Parent
+		}
+
+		if current != nil && state[current] == visiting {
+			// The chain from current onwards is a new cycle
+			first := index[current]
+			for j := len(chain) - 1; chain[j] != current; j-- {
+				if index[chain[j]] < first {
+					first = index[chain[j]]
+				}
+			}
+			starts = append(starts, first)
+		}
+		for _, f := range chain {
+			state[f] = done
+		}
+	}
+
+	return starts
+}
+
+// validPath reports whether a path is non-empty and has no empty label.
+func validPath(path string) bool {
+	return path != "" && !strings.HasPrefix(path, ".") && !strings.HasSuffix(path, ".") && !strings.Contains(path, "..")
+}
+
+func containsFolder(index map[*Folder]int, folder *Folder) bool {
+	_, exists := index[folder]
+	return exists
+}
+
+func hasChildPointer(parent, child *Folder) bool {
+	for _, c := range parent.Children {
+		if c == child {
+			return true
+		}
+	}
+	return false
+}
+
+// describe names a folder in issue messages.
+func describe(folder *Folder) string {
+	if folder == nil {
+		return "none"
+	}
+	return fmt.Sprintf("'%s'", folder.Paths)
+}
@@ -0,0 +1,246 @@
+package folder_test
+
+import (
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// issueCodes returns the code and path of every issue.
+func issueCodes(issues []folder.Issue) []string {
+	res := []string{}
+	for _, issue := range issues {
+		res = append(res, string(issue.Code)+"@"+issue.Path)
+	}
+	return res
+}
+
+// corruptions break the linked folders of initializeFolders in a way that Validate reports.
+var corruptions = [...]struct {
+	name    string
+	corrupt func(folders []*folder.Folder, m map[string]*folder.Folder, orgID2 uuid.UUID) []*folder.Folder
+	want    []string
+}{
+	{
+		name: "Name mismatch",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["charlie"].Name = "zulu"
+			return folders
+		},
+		want: []string{"name_mismatch@alpha.bravo.charlie"},
+	},
+	{
+		name: "Invalid name",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["golf"].Name = ""
+			return folders
+		},
+		want: []string{"invalid_name@golf", "name_mismatch@golf"},
+	},
+	{
+		// bravo is removed, charlie keeps its path and its pointer to bravo
+		name: "Missing parent",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["alpha"].Children = m["alpha"].Children[1:]
+			m["bravo"].Parent = nil
+			return append(folders[:1], folders[2:]...)
+		},
+		want: []string{"missing_parent@alpha.bravo.charlie", "dangling_parent@alpha.bravo.charlie"},
+	},
+	{
+		name: "Duplicate path",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			return append(folders, &folder.Folder{Name: "golf", OrgId: m["golf"].OrgId, Paths: "golf"})
+		},
+		want: []string{"duplicate_path@golf"},
+	},
+	{
+		// Pointers say delta is the parent of bravo, paths say alpha
+		name: "Paths disagree with pointers",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["alpha"].Children = m["alpha"].Children[1:]
+			m["bravo"].Parent = m["delta"]
+			m["delta"].Children = append(m["delta"].Children, m["bravo"])
+			return folders
+		},
+		want: []string{"parent_mismatch@alpha.bravo"},
+	},
+	{
+		name: "Child missing from its parent",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["delta"].Children = nil
+			return folders
+		},
+		want: []string{"child_mismatch@alpha.delta.echo"},
+	},
+	{
+		name: "Dangling parent",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["golf"].Parent = &folder.Folder{Name: "hotel", OrgId: m["golf"].OrgId, Paths: "hotel"}
+			return folders
+		},
+		want: []string{"dangling_parent@golf", "child_mismatch@golf"},
+	},
+	{
+		name: "Children across organizations",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["foxtrot"].Parent = m["golf"]
+			m["golf"].Children = []*folder.Folder{m["foxtrot"]}
+			return folders
+		},
+		want: []string{"cross_org@foxtrot"},
+	},
+	{
+		name: "Cycle",
+		corrupt: func(folders []*folder.Folder, m map[string]*folder.Folder, _ uuid.UUID) []*folder.Folder {
+			m["alpha"].Parent = m["charlie"]
+			m["charlie"].Children = []*folder.Folder{m["alpha"]}
+			return folders
+		},
+		want: []string{"parent_mismatch@alpha", "cycle@alpha"},
+	},
+}
+
+// Test_folder_Validate tests the issues reported for consistent and corrupted folders.
+func Test_folder_Validate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Consistent", func(t *testing.T) {
+		t.Parallel()
+		folders, _ := initializeFolders(uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()))
+		assert.Empty(t, folder.Validate(folders))
+	})
+
+	t.Run("Unlinked", func(t *testing.T) {
+		t.Parallel()
+		orgID := uuid.Must(uuid.NewV4())
+		assert.Empty(t, folder.Validate([]*folder.Folder{
+			{Name: "alpha", OrgId: orgID, Paths: "alpha"},
+			{Name: "bravo", OrgId: orgID, Paths: "alpha.bravo"},
+		}))
+	})
+
+	for _, tt := range corruptions {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			orgID2 := uuid.Must(uuid.NewV4())
+			folders, m := initializeFolders(uuid.Must(uuid.NewV4()), orgID2)
+			folders = tt.corrupt(folders, m, orgID2)
+			assert.Equal(t, tt.want, issueCodes(folder.Validate(folders)))
+		})
+	}
+}
+
+// Test_folder_Repair tests that every policy repairs every corruption.
+func Test_folder_Repair(t *testing.T) {
+	t.Parallel()
+
+	options := map[string]folder.RepairOptions{
+		"trust paths":                {Policy: folder.TrustPaths},
+		"trust paths, lost+found":    {Policy: folder.TrustPaths, LostAndFound: folder.DefaultLostAndFound},
+		"trust pointers":             {Policy: folder.TrustPointers},
+		"trust pointers, lost+found": {Policy: folder.TrustPointers, LostAndFound: folder.DefaultLostAndFound},
+	}
+
+	for _, tt := range corruptions {
+		for name, opts := range options {
+			tt, opts := tt, opts
+			t.Run(tt.name+", "+name, func(t *testing.T) {
+				t.Parallel()
+				orgID2 := uuid.Must(uuid.NewV4())
+				folders, m := initializeFolders(uuid.Must(uuid.NewV4()), orgID2)
+				folders = tt.corrupt(folders, m, orgID2)
+
+				repaired, issues, err := folder.Repair(folders, opts)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, issueCodes(issues))
+				assert.Empty(t, issueCodes(folder.Validate(repaired)))
+			})
+		}
+	}
+}
+
+// Test_folder_Repair_Policies tests where each policy puts the folders it repairs.
+func Test_folder_Repair_Policies(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name    string
+		corrupt int // index in corruptions
+		opts    folder.RepairOptions
+		want    []string
+	}{
+		{
+			// bravo's subtree follows the pointers to delta
+			name:    "Trust pointers",
+			corrupt: 4,
+			opts:    folder.RepairOptions{Policy: folder.TrustPointers},
+			want:    []string{"alpha", "alpha.delta.bravo", "alpha.delta.bravo.charlie", "alpha.delta", "alpha.delta.echo", "foxtrot", "golf"},
+		},
+		{
+			// Pointers are rebuilt, bravo goes back under alpha
+			name:    "Trust paths",
+			corrupt: 4,
+			opts:    folder.RepairOptions{Policy: folder.TrustPaths},
+			want:    []string{"alpha", "alpha.bravo", "alpha.bravo.charlie", "alpha.delta", "alpha.delta.echo", "foxtrot", "golf"},
+		},
+		{
+			name:    "Orphan as a root",
+			corrupt: 2,
+			opts:    folder.RepairOptions{Policy: folder.TrustPaths},
+			want:    []string{"alpha", "charlie", "alpha.delta", "alpha.delta.echo", "foxtrot", "golf"},
+		},
+		{
+			name:    "Orphan in lost+found",
+			corrupt: 2,
+			opts:    folder.RepairOptions{Policy: folder.TrustPaths, LostAndFound: "lost+found"},
+			want:    []string{"alpha", "lost+found.charlie", "alpha.delta", "alpha.delta.echo", "foxtrot", "golf", "lost+found"},
+		},
+		{
+			// The cycle is broken at alpha, whose subtree moves to lost+found
+			name:    "Cycle in lost+found",
+			corrupt: 8,
+			opts:    folder.RepairOptions{Policy: folder.TrustPointers, LostAndFound: "lost+found"},
+			want:    []string{"lost+found.alpha", "lost+found.alpha.bravo", "lost+found.alpha.bravo.charlie", "lost+found.alpha.delta", "lost+found.alpha.delta.echo", "foxtrot", "golf", "lost+found"},
+		},
+		{
+			// The duplicate is dropped, the name taken by a sibling gets a suffix
+			name:    "Duplicate trusting pointers",
+			corrupt: 3,
+			opts:    folder.RepairOptions{Policy: folder.TrustPointers},
+			want:    []string{"alpha", "alpha.bravo", "alpha.bravo.charlie", "alpha.delta", "alpha.delta.echo", "foxtrot", "golf", "golf-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			orgID2 := uuid.Must(uuid.NewV4())
+			folders, m := initializeFolders(uuid.Must(uuid.NewV4()), orgID2)
+			folders = corruptions[tt.corrupt].corrupt(folders, m, orgID2)
+
+			repaired, _, err := folder.Repair(folders, tt.opts)
+			assert.NoError(t, err)
+			got := make([]string, len(repaired))
+			for i, f := range repaired {
+				got[i] = f.Paths
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Test_folder_Repair_InvalidOptions tests the errors of invalid repair options.
+func Test_folder_Repair_InvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := folder.Repair(nil, folder.RepairOptions{LostAndFound: "lost.found"})
+	assert.ErrorIs(t, err, folder.ErrInvalidName)
+
+	_, _, err = folder.Repair(nil, folder.RepairOptions{Policy: folder.RepairPolicy(7)})
+	assert.EqualError(t, err, "unknown repair policy 7")
+}
@@ -0,0 +1,104 @@
+package folder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// MarshalTreeJson converts folders to the nested JSON form, one entry per organization:
+// [{"org_id": ..., "folders": [{"name": ..., "children": [...]}]}].
+func MarshalTreeJson(folders []*Folder) ([]byte, error) {
+	trees, err := ToTrees(folders)
+	if err != nil {
+		return nil, err
+	}
+	if trees == nil {
+		trees = []*Tree{}
+	}
+
+	return json.MarshalIndent(trees, "", "\t")
+}
+
+// UnmarshalFolders loads linked folders from JSON in either the flat form of sample.json
+// ([{"name", "org_id", "paths"}]) or the nested form produced by MarshalTreeJson.
+func UnmarshalFolders(data []byte) ([]*Folder, error) {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse folders: %w", err)
+	}
+
+	nested := 0
+	for _, item := range items {
+		if _, exists := item["folders"]; exists {
+			nested++
+		}
+	}
+	if nested > 0 && nested < len(items) {
+		return nil, errors.New("failed to parse folders: flat and nested entries can't be mixed")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if nested > 0 {
+		var trees []*Tree
+		if err := decoder.Decode(&trees); err != nil {
+			return nil, fmt.Errorf("failed to parse nested folders: %w", err)
+		}
+		if err := validateTrees(trees); err != nil {
+			return nil, err
+		}
+		return FromTrees(trees), nil
+	}
+
+	folders := []*Folder{}
+	if err := decoder.Decode(&folders); err != nil {
+		return nil, fmt.Errorf("failed to parse flat folders: %w", err)
+	}
+	if err := linkFolders(folders); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// validateTrees checks the names of nested folders, reporting the JSON path of every invalid folder.
+func validateTrees(trees []*Tree) error {
+	var errs []error
+
+	var visit func(nodes []*TreeNode, location string, siblings map[string]bool)
+	visit = func(nodes []*TreeNode, location string, siblings map[string]bool) {
+		for i, node := range nodes {
+			at := fmt.Sprintf("%s[%d]", location, i)
+			switch {
+			case node == nil || node.Name == "":
+				errs = append(errs, fmt.Errorf("%s: name is required", at))
+				continue
+			case strings.Contains(node.Name, "."):
+				errs = append(errs, fmt.Errorf("%s: name '%s' must not contain '.'", at, node.Name))
+				continue
+			case siblings[node.Name]:
+				errs = append(errs, fmt.Errorf("%s: duplicate folder '%s'", at, node.Name))
+				continue
+			}
+			siblings[node.Name] = true
+			visit(node.Children, at+".children", make(map[string]bool))
+		}
+	}
+
+	// Roots are siblings across every entry of the same organization.
+	roots := make(map[uuid.UUID]map[string]bool)
+	for i, tree := range trees {
+		if roots[tree.OrgId] == nil {
+			roots[tree.OrgId] = make(map[string]bool)
+		}
+		visit(tree.Folders, fmt.Sprintf("[%d].folders", i), roots[tree.OrgId])
+	}
+
+	return errors.Join(errs...)
+}
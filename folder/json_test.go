@@ -0,0 +1,122 @@
+package folder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_TreeJson_RoundTrip tests that sample.json survives the nested JSON form.
+func Test_folder_TreeJson_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sample := folder.GetSampleData()
+
+	data, err := folder.MarshalTreeJson(sample)
+	assert.NoError(t, err)
+
+	got, err := folder.UnmarshalFolders(data)
+	assert.NoError(t, err)
+	assert.Equal(t, paths(sample), paths(got))
+}
+
+// Test_folder_MarshalJson_LinkedFolders tests that linked folders serialise without Parent and Children.
+func Test_folder_MarshalJson_LinkedFolders(t *testing.T) {
+	t.Parallel()
+
+	got, err := folder.UnmarshalFolders([]byte(`[
+		{"name": "alpha", "org_id": "c1556e17-b7c0-45a3-a6ae-9546248fb17a", "paths": "alpha"},
+		{"name": "bravo", "org_id": "c1556e17-b7c0-45a3-a6ae-9546248fb17a", "paths": "alpha.bravo"}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, got[0], got[1].Parent, "flat folders should be linked")
+
+	assert.JSONEq(t, `[
+		{"name": "alpha", "org_id": "c1556e17-b7c0-45a3-a6ae-9546248fb17a", "paths": "alpha"},
+		{"name": "bravo", "org_id": "c1556e17-b7c0-45a3-a6ae-9546248fb17a", "paths": "alpha.bravo"}
+	]`, string(folder.MarshalJson(got)))
+
+	nested, err := folder.MarshalTreeJson(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"org_id": "c1556e17-b7c0-45a3-a6ae-9546248fb17a", "folders": [{"name": "alpha", "children": [{"name": "bravo"}]}]}
+	]`, string(nested))
+}
+
+// Test_folder_UnmarshalFolders tests both accepted shapes and their validation errors.
+func Test_folder_UnmarshalFolders(t *testing.T) {
+	t.Parallel()
+
+	const org = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+
+	tests := [...]struct {
+		name          string
+		input         string
+		want          []string
+		expectedError string
+	}{
+		{
+			name:  "Empty list",
+			input: `[]`,
+			want:  []string{},
+		},
+		{
+			name:  "Flat form",
+			input: `[{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"}, {"name": "bravo", "org_id": "` + org + `", "paths": "alpha.bravo"}]`,
+			want:  []string{org + "/alpha", org + "/alpha.bravo"},
+		},
+		{
+			name:  "Nested form",
+			input: `[{"org_id": "` + org + `", "folders": [{"name": "alpha", "children": [{"name": "bravo"}]}, {"name": "charlie"}]}]`,
+			want:  []string{org + "/alpha", org + "/alpha.bravo", org + "/charlie"},
+		},
+		{
+			name:          "Flat form with a missing parent",
+			input:         `[{"name": "bravo", "org_id": "` + org + `", "paths": "alpha.bravo"}]`,
+			expectedError: "parent path 'alpha' of folder 'bravo' does not exist",
+		},
+		{
+			name:          "Mixed forms",
+			input:         `[{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"}, {"org_id": "` + org + `", "folders": []}]`,
+			expectedError: "failed to parse folders: flat and nested entries can't be mixed",
+		},
+		{
+			name: "Nested form with invalid names",
+			input: `[
+				{"org_id": "` + org + `", "folders": [{"name": "alpha", "children": [{"name": ""}, {"name": "a.b"}]}]},
+				{"org_id": "` + org + `", "folders": [{"name": "alpha"}]}
+			]`,
+			expectedError: strings.Join([]string{
+				"[0].folders[0].children[0]: name is required",
+				"[0].folders[0].children[1]: name 'a.b' must not contain '.'",
+				"[1].folders[0]: duplicate folder 'alpha'",
+			}, "\n"),
+		},
+		{
+			name:          "Unknown field",
+			input:         `[{"name": "alpha", "org_id": "` + org + `", "path": "alpha"}]`,
+			expectedError: `failed to parse flat folders: json: unknown field "path"`,
+		},
+		{
+			name:          "Not a list",
+			input:         `{"name": "alpha"}`,
+			expectedError: "failed to parse folders: json: cannot unmarshal object",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := folder.UnmarshalFolders([]byte(tt.input))
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, paths(got))
+		})
+	}
+}
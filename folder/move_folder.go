@@ -1,8 +1,10 @@
 package folder
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"strings"
+
+	"github.com/gofrs/uuid"
 )
 
 // isDescendant checks if dest is a descendant of source
@@ -39,12 +41,32 @@ func updatePaths(folder *Folder, parentPath string) {
 	}
 }
 
+// pathChanges pairs the paths of the same folders before and after a change
+func pathChanges(oldPaths, newPaths []string) []PathChange {
+	changes := make([]PathChange, len(oldPaths))
+	for i := range oldPaths {
+		changes[i] = PathChange{Old: oldPaths[i], New: newPaths[i]}
+	}
+	return changes
+}
+
 // MoveFolder moves a folder and its subtree to a new destination folder
-func (f *driver) MoveFolder(name string, dst string) ([]*Folder, error) {
-	// Build a map of folder names to folder pointers for quick lookup
+// With a principal in ctx, folders of other organizations are neither found nor returned
+// Names shared by several of the folders looked up are rejected, as they don't tell which folder to move
+func (f *driver) MoveFolder(ctx context.Context, name string, dst string) ([]*Folder, error) {
+	principal, scoped := PrincipalFrom(ctx)
+
+	// Build a map of folder names to folder pointers for quick lookup, counting the folders of each name
 	folderMap := make(map[string]*Folder)
-	for _, folder := range f.folders {
-		folderMap[folder.Name] = folder
+	nameCount := make(map[string]int)
+	for i, folder := range f.folders {
+		if err := checkCanceled(ctx, i); err != nil {
+			return nil, err
+		}
+		if !scoped || folder.OrgId == principal.OrgID {
+			folderMap[folder.Name] = folder
+			nameCount[folder.Name]++
+		}
 	}
 
 	// Get the source and destination folders
@@ -53,51 +75,116 @@ func (f *driver) MoveFolder(name string, dst string) ([]*Folder, error) {
 
 	// Error handling
 	if !sourceExists {
-		log.Printf("Error: Source folder '%s' does not exist", name)
-		return nil, fmt.Errorf("source folder '%s' does not exist", name)
+		logf(ctx, "Error: Source folder '%s' does not exist", name)
+		return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist", name)
 	}
 	if !destExists {
-		log.Printf("Error: Destination folder '%s' does not exist", dst)
-		return nil, fmt.Errorf("destination folder '%s' does not exist", dst)
+		logf(ctx, "Error: Destination folder '%s' does not exist", dst)
+		return nil, errorf(ErrFolderNotFound, "destination folder '%s' does not exist", dst)
 	}
 
+	// Error handling for names shared by several folders, which only MoveFolderByPath can tell apart
+	for _, n := range []string{name, dst} {
+		if nameCount[n] > 1 {
+			logf(ctx, "Error: Folder name '%s' is shared by %d folders, move them by path", n, nameCount[n])
+			return nil, errorf(ErrInvalidMove, "folder name '%s' is shared by %d folders, move them by path", n, nameCount[n])
+		}
+	}
+
+	if err := f.move(ctx, sourceFolder, destFolder, name); err != nil {
+		return nil, err
+	}
+
+	// Return the updated folder structure, limited to the principal's organization
+	if scoped {
+		return f.GetFoldersByOrgID(ctx, principal.OrgID)
+	}
+	return f.folders, nil
+}
+
+// move moves the source folder and its subtree under the destination folder, naming the source
+// by label in errors
+func (f *driver) move(ctx context.Context, sourceFolder, destFolder *Folder, label string) error {
 	// Error handling for moving to itself
 	if sourceFolder == destFolder {
-		log.Printf("Error: Cannot move folder '%s' to itself", name)
-		return nil, fmt.Errorf("cannot move folder '%s' to itself", name)
+		logf(ctx, "Error: Cannot move folder '%s' to itself", label)
+		return errorf(ErrInvalidMove, "cannot move folder '%s' to itself", label)
 	}
 
 	// Error handling for moving to a child of itself
 	if isDescendant(sourceFolder, destFolder) {
-		log.Printf("Error: Cannot move folder '%s' to a child of itself", name)
-		return nil, fmt.Errorf("cannot move folder '%s' to a child of itself", name)
+		logf(ctx, "Error: Cannot move folder '%s' to a child of itself", label)
+		return errorf(ErrInvalidMove, "cannot move folder '%s' to a child of itself", label)
 	}
 
 	// Error handling for moving to a different organization
 	if sourceFolder.OrgId != destFolder.OrgId {
-		log.Printf("Error: Cannot move folder '%s' to a different organization", name)
-		return nil, fmt.Errorf("cannot move folder '%s' to a different organization", name)
+		logf(ctx, "Error: Cannot move folder '%s' to a different organization", label)
+		return errorf(ErrInvalidMove, "cannot move folder '%s' to a different organization", label)
 	}
 
+	// Error handling for moving onto the path of another folder, which would make paths ambiguous
+	newPath := destFolder.Paths + "." + sourceFolder.Name
+	if other := FindByPath(f.folders, destFolder.OrgId, newPath); other != nil && other != sourceFolder {
+		logf(ctx, "Error: Folder '%s' already exists", newPath)
+		return errorf(ErrFolderExists, "folder '%s' already exists", newPath)
+	}
+
+	// Record the move before applying it
+	if err := f.audit(ctx, AuditEntry{
+		OrgID:       sourceFolder.OrgId,
+		Op:          AuditMove,
+		OldPath:     sourceFolder.Paths,
+		NewPath:     newPath,
+		Descendants: countDescendants(sourceFolder),
+	}); err != nil {
+		return err
+	}
+
+	oldPaths := pathsOf(sourceFolder)
+
 	// Remove the source folder from its current parent's children
 	if sourceFolder.Parent != nil {
 		removeChild(sourceFolder.Parent, sourceFolder)
 	}
-	// fmt.Printf("Remove the source folder from its current parent's children\n")
-	// PrintFolders(f.folders)
 
 	// Update the parent of the source folder to be the destination folder
 	sourceFolder.Parent = destFolder
 	destFolder.Children = append(destFolder.Children, sourceFolder)
 
-	// fmt.Printf("Update the parent of the source folder to be the destination folder\n")
-	// PrintFolders(f.folders)
-
 	// Update the paths of the source folder and its descendants
 	updatePaths(sourceFolder, destFolder.Paths)
-	// fmt.Printf("Update the paths of the source folder and its descendants\n")
-	// PrintFolders(f.folders)
 
-	// Return the updated folder structure
-	return f.folders, nil
+	f.publish(ctx, &FolderMoved{EventMeta: EventMeta{OrgID: sourceFolder.OrgId}, Changes: pathChanges(oldPaths, pathsOf(sourceFolder))})
+
+	return nil
+}
+
+// MovedPath returns the path the folder at path has once MoveFolderByPath moved it under dstPath.
+// The moved folder keeps its name under the destination.
+func MovedPath(path string, dstPath string) string {
+	return dstPath + "." + path[strings.LastIndex(path, ".")+1:]
+}
+
+// MoveFolderByPath moves the folder at path and its subtree under the folder at dstPath of the same
+// organization. Unlike names, paths identify a single folder. It returns the folders of the organization.
+func (f *driver) MoveFolderByPath(ctx context.Context, orgID uuid.UUID, path string, dstPath string) ([]*Folder, error) {
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	sourceFolder := FindByPath(f.folders, orgID, path)
+	if sourceFolder == nil {
+		logf(ctx, "Error: Source folder '%s' does not exist in orgID '%s'", path, orgID)
+		return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist", path)
+	}
+	destFolder := FindByPath(f.folders, orgID, dstPath)
+	if destFolder == nil {
+		logf(ctx, "Error: Destination folder '%s' does not exist in orgID '%s'", dstPath, orgID)
+		return nil, errorf(ErrFolderNotFound, "destination folder '%s' does not exist", dstPath)
+	}
+
+	if err := f.move(ctx, sourceFolder, destFolder, path); err != nil {
+		return nil, err
+	}
+	return f.GetFoldersByOrgID(ctx, orgID)
 }
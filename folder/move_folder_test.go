@@ -1,6 +1,8 @@
 package folder_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/georgechieng-sc/interns-2022/folder"
@@ -333,11 +335,12 @@ func Test_folder_MoveFolder(t *testing.T) {
 			folders, folderMap := initializeFolders(orgID1, orgID2)
 			driver := folder.NewDriver(folders)
 
-			_, err := driver.MoveFolder(tt.source, tt.dest)
+			_, err := driver.MoveFolder(context.Background(), tt.source, tt.dest)
 
 			if tt.expectError {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.True(t, errors.Is(err, folder.ErrFolderNotFound) || errors.Is(err, folder.ErrInvalidMove), "error should match a driver error kind")
 			} else {
 				assert.NoError(t, err)
 
@@ -361,7 +364,7 @@ func Test_folder_MoveFolder_MultipleOperations(t *testing.T) {
 	driver := folder.NewDriver(folders)
 
 	// Perform the first MoveFolder operation
-	_, err := driver.MoveFolder("bravo", "delta")
+	_, err := driver.MoveFolder(context.Background(), "bravo", "delta")
 	assert.NoError(t, err, "First move (bravo to delta) should succeed")
 
 	// Validate the folder structure after the first operation
@@ -391,7 +394,7 @@ func Test_folder_MoveFolder_MultipleOperations(t *testing.T) {
 	}
 
 	// Perform the second MoveFolder operation
-	_, err = driver.MoveFolder("delta", "golf")
+	_, err = driver.MoveFolder(context.Background(), "delta", "golf")
 	assert.NoError(t, err, "Second move (delta to golf) should succeed")
 
 	// Validate the folder structure after the second operation
@@ -426,7 +429,7 @@ func Test_folder_MoveFolder_MultipleOperations(t *testing.T) {
 	}
 
 	// Perform the third MoveFolder operation
-	_, err = driver.MoveFolder("echo", "bravo")
+	_, err = driver.MoveFolder(context.Background(), "echo", "bravo")
 	assert.NoError(t, err, "Third move (echo to bravo) should succeed")
 
 	// Validate the folder structure after the third operation
@@ -455,3 +458,40 @@ func Test_folder_MoveFolder_MultipleOperations(t *testing.T) {
 		}
 	}
 }
+
+// Test_folder_MoveFolderByPath tests that moves by path move the folder at the path when names repeat.
+func Test_folder_MoveFolderByPath(t *testing.T) {
+	t.Parallel()
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1.String() + `", "paths": "alpha"},
+		{"name": "x", "org_id": "` + orgID1.String() + `", "paths": "alpha.x"},
+		{"name": "bravo", "org_id": "` + orgID1.String() + `", "paths": "bravo"},
+		{"name": "x", "org_id": "` + orgID1.String() + `", "paths": "bravo.x"},
+		{"name": "y", "org_id": "` + orgID1.String() + `", "paths": "bravo.x.y"},
+		{"name": "charlie", "org_id": "` + orgID1.String() + `", "paths": "charlie"},
+		{"name": "delta", "org_id": "` + orgID2.String() + `", "paths": "delta"}
+	]`))
+	assert.NoError(t, err)
+	driver := folder.NewDriver(folders)
+	ctx := context.Background()
+
+	got, err := driver.MoveFolderByPath(ctx, orgID1, "bravo.x", "charlie")
+	assert.NoError(t, err)
+	assert.Len(t, got, 6)
+	assert.Equal(t, "alpha.x", folders[1].Paths)
+	assert.Equal(t, "charlie.x", folders[3].Paths)
+	assert.Equal(t, "charlie.x.y", folders[4].Paths)
+	assert.Equal(t, folders[5], folders[3].Parent)
+	assert.Equal(t, folders[3], folder.FindByPath(got, orgID1, folder.MovedPath("bravo.x", "charlie")))
+
+	_, err = driver.MoveFolderByPath(ctx, orgID1, "charlie", "charlie.x.y")
+	assert.ErrorIs(t, err, folder.ErrInvalidMove)
+	_, err = driver.MoveFolderByPath(ctx, orgID1, "charlie.x", "alpha")
+	assert.ErrorIs(t, err, folder.ErrFolderExists)
+	_, err = driver.MoveFolderByPath(ctx, orgID1, "alpha.x", "delta")
+	assert.ErrorIs(t, err, folder.ErrFolderNotFound)
+	_, err = driver.MoveFolderByPath(folder.WithPrincipal(ctx, folder.Principal{OrgID: orgID2}), orgID1, "alpha.x", "bravo")
+	assert.ErrorIs(t, err, folder.ErrPermissionDenied)
+}
@@ -0,0 +1,106 @@
+package folder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// RenderOptions configures RenderTree.
+type RenderOptions struct {
+	// MaxDepth is the number of levels rendered, counting roots as level 1; 0 means unlimited.
+	MaxDepth int
+	// ASCII draws the branches with ASCII characters instead of Unicode box-drawing characters.
+	ASCII bool
+	// Color highlights folders with children and dims the branches and counts with ANSI escapes.
+	Color bool
+	// Counts appends the number of descendants to every folder that has children.
+	Counts bool
+}
+
+// branches are the prefixes drawn before a folder and below it, as in `tree`.
+type branches struct {
+	middle, last, line, blank string
+}
+
+var (
+	unicodeBranches = branches{middle: "├── ", last: "└── ", line: "│   ", blank: "    "}
+	asciiBranches   = branches{middle: "|-- ", last: "`-- ", line: "|   ", blank: "    "}
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiFolder = "\x1b[1;34m"
+	ansiDim    = "\x1b[2m"
+)
+
+// RenderTree writes the folder trees rooted at folders in `tree` style, following Children.
+// A folder whose parent is also in folders is rendered under its parent only,
+// so folders may hold either the roots or every folder of a tree.
+func RenderTree(w io.Writer, folders []*Folder, opts RenderOptions) error {
+	r := &renderer{w: bufio.NewWriter(w), opts: opts, branches: unicodeBranches}
+	if opts.ASCII {
+		r.branches = asciiBranches
+	}
+
+	listed := make(map[*Folder]bool, len(folders))
+	for _, folder := range folders {
+		listed[folder] = true
+	}
+	for _, folder := range folders {
+		if folder.Parent == nil || !listed[folder.Parent] {
+			r.render(folder, "", "", 1)
+		}
+	}
+
+	return r.w.Flush()
+}
+
+// renderer holds the state of a single RenderTree call.
+type renderer struct {
+	w        *bufio.Writer
+	opts     RenderOptions
+	branches branches
+}
+
+// render writes a folder after the branch drawn before it, then its children with indent before their branches.
+func (r *renderer) render(folder *Folder, branch, indent string, depth int) {
+	r.w.WriteString(r.paint(ansiDim, branch))
+	if len(folder.Children) > 0 {
+		r.w.WriteString(r.paint(ansiFolder, folder.Name))
+	} else {
+		r.w.WriteString(folder.Name)
+	}
+	if r.opts.Counts && len(folder.Children) > 0 {
+		r.w.WriteString(r.paint(ansiDim, fmt.Sprintf(" (%d)", countDescendants(folder))))
+	}
+	r.w.WriteByte('\n')
+
+	if r.opts.MaxDepth > 0 && depth >= r.opts.MaxDepth {
+		return
+	}
+	for i, child := range folder.Children {
+		if i == len(folder.Children)-1 {
+			r.render(child, indent+r.branches.last, indent+r.branches.blank, depth+1)
+		} else {
+			r.render(child, indent+r.branches.middle, indent+r.branches.line, depth+1)
+		}
+	}
+}
+
+// paint wraps s in an ANSI escape when colour is enabled.
+func (r *renderer) paint(escape, s string) string {
+	if !r.opts.Color || s == "" {
+		return s
+	}
+	return escape + s + ansiReset
+}
+
+// countDescendants returns the number of folders below folder.
+func countDescendants(folder *Folder) int {
+	count := 0
+	for _, child := range folder.Children {
+		count += 1 + countDescendants(child)
+	}
+	return count
+}
@@ -0,0 +1,93 @@
+package folder_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Test_folder_RenderTree compares RenderTree output with the golden files in testdata/render.
+func Test_folder_RenderTree(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.FromStringOrNil("c1556e17-b7c0-45a3-a6ae-9546248fb17a")
+	orgID2 := uuid.FromStringOrNil("38b9879b-f73b-4b0e-b9d9-4fc4c23643a7")
+	folders, folderMap := initializeFolders(orgID1, orgID2)
+
+	tests := [...]struct {
+		name    string
+		folders []*folder.Folder
+		opts    folder.RenderOptions
+	}{
+		{
+			name:    "unicode",
+			folders: folders,
+		},
+		{
+			name:    "ascii",
+			folders: folders,
+			opts:    folder.RenderOptions{ASCII: true},
+		},
+		{
+			// Counts include the descendants hidden by the depth limit
+			name:    "depth-counts",
+			folders: folders,
+			opts:    folder.RenderOptions{MaxDepth: 2, Counts: true},
+		},
+		{
+			name:    "color",
+			folders: folders,
+			opts:    folder.RenderOptions{Color: true, Counts: true},
+		},
+		{
+			// Only roots are given, their subtrees are rendered through Children
+			name:    "subtree",
+			folders: []*folder.Folder{folderMap["delta"], folderMap["bravo"]},
+		},
+		{
+			name:    "empty",
+			folders: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			assert.NoError(t, folder.RenderTree(&buf, tt.folders, tt.opts))
+
+			golden := filepath.Join("testdata", "render", tt.name+".golden")
+			if *update {
+				assert.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), buf.String())
+		})
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// Test_folder_RenderTree_WriteError tests that write errors are returned.
+func Test_folder_RenderTree_WriteError(t *testing.T) {
+	t.Parallel()
+
+	folders, _ := initializeFolders(uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()))
+	assert.EqualError(t, folder.RenderTree(failingWriter{}, folders, folder.RenderOptions{}), "disk full")
+}
@@ -3,7 +3,6 @@ package folder
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -17,13 +16,13 @@ import (
 // There's no real need for you to be editting these, but feel free to tweak it to suit your needs.
 // If you do make changes here, be ready to discuss why these changes were made.
 
-// how many trees you want to generate
+// how many trees you want to generate, used by DefaultGeneratorConfig
 const MaxRootSet = 4
 
-// maximum possible children per node
+// maximum possible children per node, used by DefaultGeneratorConfig
 const MaxChild = 4
 
-// max depth of the tree
+// max depth of the tree, used by DefaultGeneratorConfig
 const MaxDepth = 5
 
 // the default orgID that we will be using for testing
@@ -33,65 +32,21 @@ type Folder struct {
 	Name     string    `json:"name"`
 	OrgId    uuid.UUID `json:"org_id"`
 	Paths    string    `json:"paths"`
-	Parent   *Folder   // Pointer to the parent folder
-	Children []*Folder // List of child folders (for tree-like structure)
+	Parent   *Folder   `json:"-"` // Pointer to the parent folder
+	Children []*Folder `json:"-"` // List of child folders (for tree-like structure)
 }
 
-func GenerateData() []Folder {
-	rng, _ := codename.DefaultRNG()
-	tree := []Folder{}
-
-	for i := 0; i < MaxRootSet; i++ {
-		orgId := uuid.FromStringOrNil(DefaultOrgID)
-		if i%3 == 0 {
-			orgId = uuid.Must(uuid.NewV4())
-		}
-
-		name := codename.Generate(rng, 0)
-
-		subtree := make(chan []Folder)
-		go func() {
-			subtree <- generateTree(1, []Folder{
-				{
-					Name:  name,
-					OrgId: orgId,
-					Paths: name,
-				},
-			})
-		}()
-		tree = append(tree, <-subtree...)
-	}
-
-	return tree
-}
-
-func generateTree(depth int, tree []Folder) []Folder {
-	rng, _ := codename.DefaultRNG()
+// GenerateData generates folders with DefaultGeneratorConfig and a random seed.
+// Use GenerateDataWithConfig for reproducible datasets.
+func GenerateData() []*Folder {
+	config := DefaultGeneratorConfig()
+	config.Seed, _ = codename.NewCryptoSeed()
 
-	if depth >= MaxDepth {
-		return tree
-	}
-
-	for _, t := range tree {
-		numOfChild := rng.Int()%MaxChild + 1
-		for i := 0; i < numOfChild; i++ {
-			name := codename.Generate(rng, 0)
-
-			childTree := make(chan []Folder)
-			go func() {
-				childTree <- generateTree(depth+1, []Folder{
-					{
-						Name:  name,
-						OrgId: t.OrgId,
-						Paths: t.Paths + "." + name,
-					},
-				})
-			}()
-			tree = append(tree, <-childTree...)
-		}
+	folders, err := GenerateDataWithConfig(config)
+	if err != nil {
+		panic(err)
 	}
-
-	return tree
+	return folders
 }
 
 func MarshalJson(b interface{}) []byte {
@@ -119,14 +74,7 @@ func GetSampleData() []*Folder {
 	}
 	defer file.Close()
 
-	jsonByte, err := io.ReadAll(file)
-	if err != nil {
-		panic(err)
-	}
-
-	// Declare folders as []*Folder
-	var folders []*Folder
-	err = json.Unmarshal(jsonByte, &folders)
+	folders, err := ReadDataset(file)
 	if err != nil {
 		panic(err)
 	}
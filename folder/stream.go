@@ -0,0 +1,243 @@
+package folder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// folderOverhead estimates the memory held per loaded folder besides its strings:
+// the Folder struct, its pointer in the result and its entries in the path index.
+const folderOverhead = 200
+
+// DefaultProgressInterval is how many folders are loaded between progress reports by default.
+const DefaultProgressInterval = 100000
+
+// StreamOptions configures LoadDatasetStream.
+type StreamOptions struct {
+	// MemoryBudget is the estimated number of bytes the loaded folders may hold; 0 means unlimited.
+	MemoryBudget int64
+	// Progress is called every ProgressInterval folders and once at the end,
+	// with the number of folders loaded and bytes read so far.
+	Progress func(folders int, bytes int64)
+	// ProgressInterval defaults to DefaultProgressInterval.
+	ProgressInterval int
+}
+
+// ErrMemoryBudgetExceeded is returned when a dataset doesn't fit in StreamOptions.MemoryBudget.
+var ErrMemoryBudgetExceeded = errors.New("memory budget exceeded")
+
+// streamLoader holds the indexes built while folders are decoded one at a time.
+type streamLoader struct {
+	opts    StreamOptions
+	decoder *json.Decoder
+	folders []*Folder
+	byPath  map[string]*Folder
+	pending map[string][]*Folder // children waiting for their parent path to appear
+	memory  int64
+	prefix  string // JSON pointer of the folders array, for errors found once it is read
+	// unsupported is set when the dataset is valid in a shape only LoadDataset can load.
+	unsupported bool
+}
+
+// LoadDatasetStream loads a flat dataset, either a bare array or the current envelope,
+// decoding one folder at a time instead of reading the whole file into memory.
+// Folders are linked as they arrive, so parents may appear before or after their children.
+// Nested datasets are not supported; use LoadDataset for those.
+func LoadDatasetStream(r io.Reader, opts StreamOptions) ([]*Folder, error) {
+	return newStreamLoader(r, opts).load()
+}
+
+// ReadDataset loads a dataset of any known version from r. Flat datasets are streamed with
+// LoadDatasetStream; r is only read whole, and given to LoadDataset, when the dataset is nested or
+// of another version, whose upgrade needs the whole document.
+func ReadDataset(r io.ReadSeeker) ([]*Folder, error) {
+	l := newStreamLoader(r, StreamOptions{})
+	folders, err := l.load()
+	if err == nil || !l.unsupported {
+		return folders, err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return LoadDataset(data)
+}
+
+func newStreamLoader(r io.Reader, opts StreamOptions) *streamLoader {
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = DefaultProgressInterval
+	}
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	return &streamLoader{
+		opts:    opts,
+		decoder: decoder,
+		byPath:  make(map[string]*Folder),
+		pending: make(map[string][]*Folder),
+	}
+}
+
+// load reads the dataset and returns its linked folders.
+func (l *streamLoader) load() ([]*Folder, error) {
+	token, err := l.decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dataset: %w", err)
+	}
+
+	switch token {
+	case json.Delim('['):
+		err = l.readFolders("")
+	case json.Delim('{'):
+		err = l.readEnvelope()
+	default:
+		err = &SchemaError{Path: "", Msg: "expected an array or an object"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l.pending) > 0 {
+		// Report the first folder whose parent never appeared, as LoadDataset does.
+		for i, folder := range l.folders {
+			if idx := strings.LastIndex(folder.Paths, "."); idx >= 0 && folder.Parent == nil {
+				return nil, &SchemaError{Path: fmt.Sprintf("%s/%d/paths", l.prefix, i), Msg: fmt.Sprintf("parent path '%s' does not exist", folder.Paths[:idx])}
+			}
+		}
+	}
+	if l.opts.Progress != nil {
+		l.opts.Progress(len(l.folders), l.decoder.InputOffset())
+	}
+
+	return l.folders, nil
+}
+
+// readEnvelope reads the properties of a dataset envelope after its opening brace.
+func (l *streamLoader) readEnvelope() error {
+	var version *int
+	hasFolders := false
+	for l.decoder.More() {
+		token, err := l.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse dataset: %w", err)
+		}
+
+		switch key := token.(string); key {
+		case "format_version":
+			if err := l.decoder.Decode(&version); err != nil || version == nil {
+				return &SchemaError{Path: "/format_version", Msg: "expected integer"}
+			}
+			if *version != FormatVersion {
+				l.unsupported = true
+				return fmt.Errorf("format_version %d is not supported by the streaming loader, expected %d", *version, FormatVersion)
+			}
+		case "folders":
+			token, err := l.decoder.Token()
+			if err != nil {
+				return fmt.Errorf("failed to parse dataset: %w", err)
+			}
+			if token != json.Delim('[') {
+				return &SchemaError{Path: "/folders", Msg: "expected array"}
+			}
+			if err := l.readFolders("/folders"); err != nil {
+				return err
+			}
+			hasFolders = true
+		default:
+			return &SchemaError{Path: "/" + key, Msg: "unknown property"}
+		}
+	}
+
+	if version == nil {
+		return &SchemaError{Path: "", Msg: "missing required property 'format_version'"}
+	}
+	if !hasFolders {
+		return &SchemaError{Path: "", Msg: "missing required property 'folders'"}
+	}
+	return nil
+}
+
+// readFolders decodes and indexes the folders of an array after its opening bracket.
+func (l *streamLoader) readFolders(path string) error {
+	l.prefix = path
+	for i := 0; l.decoder.More(); i++ {
+		at := fmt.Sprintf("%s/%d", path, i)
+
+		var item struct {
+			Folder
+			Folders json.RawMessage `json:"folders"`
+		}
+		if err := l.decoder.Decode(&item); err != nil {
+			return &SchemaError{Path: at, Msg: err.Error()}
+		}
+		if item.Folders != nil {
+			l.unsupported = true
+			return &SchemaError{Path: at, Msg: "nested folders are not supported by the streaming loader"}
+		}
+
+		folder := item.Folder
+		if err := l.add(&folder, at); err != nil {
+			return err
+		}
+
+		if l.opts.Progress != nil && len(l.folders)%l.opts.ProgressInterval == 0 {
+			l.opts.Progress(len(l.folders), l.decoder.InputOffset())
+		}
+	}
+
+	// Closing bracket
+	if _, err := l.decoder.Token(); err != nil {
+		return fmt.Errorf("failed to parse dataset: %w", err)
+	}
+	return nil
+}
+
+// add validates a folder and links it with its parent and any children already loaded.
+func (l *streamLoader) add(folder *Folder, at string) error {
+	switch {
+	case folder.OrgId == uuid.Nil:
+		return &SchemaError{Path: at + "/org_id", Msg: "missing required property 'org_id'"}
+	case folder.Name == "" || strings.Contains(folder.Name, "."):
+		return &SchemaError{Path: at + "/name", Msg: fmt.Sprintf("invalid name '%s'", folder.Name)}
+	case folder.Paths != folder.Name && !strings.HasSuffix(folder.Paths, "."+folder.Name):
+		return &SchemaError{Path: at + "/name", Msg: fmt.Sprintf("name '%s' is not the last label of paths '%s'", folder.Name, folder.Paths)}
+	}
+
+	l.memory += folderOverhead + int64(len(folder.Name)+2*len(folder.Paths))
+	if l.opts.MemoryBudget > 0 && l.memory > l.opts.MemoryBudget {
+		return fmt.Errorf("%w after %d folders: %d bytes", ErrMemoryBudgetExceeded, len(l.folders), l.opts.MemoryBudget)
+	}
+
+	key := pathKey(folder.OrgId, folder.Paths)
+	if _, exists := l.byPath[key]; exists {
+		return &SchemaError{Path: at + "/paths", Msg: fmt.Sprintf("duplicate path '%s'", folder.Paths)}
+	}
+	l.byPath[key] = folder
+	l.folders = append(l.folders, folder)
+
+	if idx := strings.LastIndex(folder.Paths, "."); idx >= 0 {
+		parentKey := pathKey(folder.OrgId, folder.Paths[:idx])
+		if parent, exists := l.byPath[parentKey]; exists {
+			folder.Parent = parent
+			parent.Children = append(parent.Children, folder)
+		} else {
+			l.pending[parentKey] = append(l.pending[parentKey], folder)
+		}
+	}
+
+	for _, child := range l.pending[key] {
+		child.Parent = folder
+		folder.Children = append(folder.Children, child)
+	}
+	delete(l.pending, key)
+
+	return nil
+}
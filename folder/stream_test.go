@@ -0,0 +1,246 @@
+package folder_test
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/stretchr/testify/assert"
+)
+
+var streamFolders = flag.Int("stream-folders", 5000000, "number of folders in the file generated for BenchmarkLoadDatasetStream")
+
+// Test_folder_LoadDatasetStream_Sample tests that the streaming loader matches LoadDataset on sample.json.
+func Test_folder_LoadDatasetStream_Sample(t *testing.T) {
+	t.Parallel()
+
+	sample := folder.GetSampleData()
+	data, err := folder.MarshalDataset(sample)
+	assert.NoError(t, err)
+
+	var reports []int
+	got, err := folder.LoadDatasetStream(strings.NewReader(string(data)), folder.StreamOptions{
+		ProgressInterval: 10,
+		Progress:         func(folders int, bytes int64) { reports = append(reports, folders) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, paths(sample), paths(got))
+
+	// Progress is reported every 10 folders and once at the end
+	assert.Len(t, reports, len(sample)/10+1)
+	assert.Equal(t, len(sample), reports[len(reports)-1])
+}
+
+// Test_folder_LoadDatasetStream tests the shapes, linking and errors of LoadDatasetStream.
+func Test_folder_LoadDatasetStream(t *testing.T) {
+	t.Parallel()
+
+	const org = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+	entry := func(name, paths string) string {
+		return `{"name": "` + name + `", "org_id": "` + org + `", "paths": "` + paths + `"}`
+	}
+
+	tests := [...]struct {
+		name          string
+		input         string
+		budget        int64
+		want          []string
+		expectedError string
+	}{
+		{
+			name:  "Bare array",
+			input: `[` + entry("alpha", "alpha") + `,` + entry("bravo", "alpha.bravo") + `]`,
+			want:  []string{org + "/alpha", org + "/alpha.bravo"},
+		},
+		{
+			name:  "Envelope with children before their parents",
+			input: `{"folders": [` + entry("charlie", "alpha.bravo.charlie") + `,` + entry("bravo", "alpha.bravo") + `,` + entry("alpha", "alpha") + `], "format_version": 1}`,
+			want:  []string{org + "/alpha.bravo.charlie", org + "/alpha.bravo", org + "/alpha"},
+		},
+		{
+			name:          "Missing parent",
+			input:         `[` + entry("bravo", "alpha.bravo") + `]`,
+			expectedError: "/0/paths: parent path 'alpha' does not exist",
+		},
+		{
+			name:          "Name does not match paths",
+			input:         `[` + entry("alpha", "alpha") + `,` + entry("bravo", "alpha.charlie") + `]`,
+			expectedError: "/1/name: name 'bravo' is not the last label of paths 'alpha.charlie'",
+		},
+		{
+			name:          "Duplicate path",
+			input:         `{"format_version": 1, "folders": [` + entry("alpha", "alpha") + `,` + entry("alpha", "alpha") + `]}`,
+			expectedError: "/folders/1/paths: duplicate path 'alpha'",
+		},
+		{
+			name:          "Unknown property",
+			input:         `[{"name": "alpha", "org_id": "` + org + `", "paths": "alpha", "parent": "root"}]`,
+			expectedError: "/0: json: unknown field \"parent\"",
+		},
+		{
+			name:          "Missing org ID",
+			input:         `[{"name": "alpha", "paths": "alpha"}]`,
+			expectedError: "/0/org_id: missing required property 'org_id'",
+		},
+		{
+			name:          "Missing folders",
+			input:         `{"format_version": 1}`,
+			expectedError: "/: missing required property 'folders'",
+		},
+		{
+			name:          "Nested folders",
+			input:         `[{"org_id": "` + org + `", "folders": []}]`,
+			expectedError: "/0: nested folders are not supported by the streaming loader",
+		},
+		{
+			name:          "Unsupported version",
+			input:         `{"format_version": 2, "folders": []}`,
+			expectedError: "format_version 2 is not supported by the streaming loader, expected 1",
+		},
+		{
+			name:          "Missing version",
+			input:         `{"folders": []}`,
+			expectedError: "/: missing required property 'format_version'",
+		},
+		{
+			name:          "Memory budget exceeded",
+			input:         `[` + entry("alpha", "alpha") + `,` + entry("bravo", "alpha.bravo") + `]`,
+			budget:        300,
+			expectedError: "memory budget exceeded after 1 folders: 300 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := folder.LoadDatasetStream(strings.NewReader(tt.input), folder.StreamOptions{MemoryBudget: tt.budget})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, paths(got))
+
+			// Every folder with a parent path is linked, whatever the order
+			for _, f := range got {
+				if f.Parent != nil {
+					assert.Equal(t, f.Parent.Paths+"."+f.Name, f.Paths)
+				}
+			}
+		})
+	}
+
+	_, err := folder.LoadDatasetStream(strings.NewReader(`[`+entry("alpha", "alpha")+`]`), folder.StreamOptions{MemoryBudget: 10})
+	assert.True(t, errors.Is(err, folder.ErrMemoryBudgetExceeded))
+}
+
+// Test_folder_ReadDataset tests that ReadDataset streams flat datasets and reads the others whole.
+func Test_folder_ReadDataset(t *testing.T) {
+	t.Parallel()
+
+	const org = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+	tests := [...]struct {
+		name          string
+		input         string
+		want          []string
+		expectedError string
+	}{
+		{
+			name:  "Flat envelope",
+			input: `{"format_version": 1, "folders": [{"name": "alpha", "org_id": "` + org + `", "paths": "alpha"}]}`,
+			want:  []string{org + "/alpha"},
+		},
+		{
+			name:  "Bare nested array is upgraded",
+			input: `[{"org_id": "` + org + `", "folders": [{"name": "alpha", "children": [{"name": "bravo"}]}]}]`,
+			want:  []string{org + "/alpha", org + "/alpha.bravo"},
+		},
+		{
+			name:          "Newer version",
+			input:         `{"format_version": 2, "folders": []}`,
+			expectedError: "format_version 2 is newer than the supported version 1",
+		},
+		{
+			name:          "Invalid flat dataset",
+			input:         `{"format_version": 1, "folders": [{"name": "alpha", "org_id": "` + org + `", "paths": "zulu.alpha"}]}`,
+			expectedError: "/folders/0/paths: parent path 'zulu' does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := folder.ReadDataset(strings.NewReader(tt.input))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, paths(got))
+		})
+	}
+}
+
+// writeGeneratedDataset writes a dataset of n folders, 100 children per folder, breadth first.
+func writeGeneratedDataset(path string, n int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprint(w, `{"format_version": 1, "folders": [`)
+	paths := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("f%d", i)
+		p := name
+		if i > 0 {
+			p = paths[(i-1)/100] + "." + name
+		}
+		paths = append(paths, p)
+		if i > 0 {
+			fmt.Fprint(w, ",")
+		}
+		fmt.Fprintf(w, "\n\t{\"name\": %q, \"org_id\": %q, \"paths\": %q}", name, folder.DefaultOrgID, p)
+	}
+	fmt.Fprint(w, "\n]}\n")
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// BenchmarkLoadDatasetStream loads a generated file of -stream-folders folders, 5M by default.
+func BenchmarkLoadDatasetStream(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "folders.json")
+	if err := writeGeneratedDataset(path, *streamFolders); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		folders, err := folder.LoadDatasetStream(bufio.NewReader(file), folder.StreamOptions{})
+		file.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(folders) != *streamFolders {
+			b.Fatalf("loaded %d folders, want %d", len(folders), *streamFolders)
+		}
+	}
+}
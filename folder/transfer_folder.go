@@ -0,0 +1,100 @@
+package folder
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+)
+
+// setPath sets the path of a folder, updating the paths of its descendants
+func setPath(folder *Folder, path string) {
+	folder.Paths = path
+	for _, child := range folder.Children {
+		updatePaths(child, path)
+	}
+}
+
+// setOrg sets the organization of a folder and its descendants
+func setOrg(folder *Folder, orgID uuid.UUID) {
+	folder.OrgId = orgID
+	for _, child := range folder.Children {
+		setOrg(child, orgID)
+	}
+}
+
+// TransferFolder moves the folder at path in srcOrgID and its subtree to another organization, under the
+// folder at dstPath in dstOrgID or to its root if dstPath is empty. Transfers keep the folders and their names,
+// which descendants may share with folders of dstOrgID; like copies, those are then moved by path.
+// Principals only have access to their own organization, so only trusted callers, without a principal in ctx,
+// may transfer, or principals through AuthorizedDriver.TransferFolder. It returns the transferred folders,
+// the folder first.
+func (f *driver) TransferFolder(ctx context.Context, srcOrgID uuid.UUID, path string, dstOrgID uuid.UUID, dstPath string) ([]*Folder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p, ok := PrincipalFrom(ctx); ok && !transferAuthorized(ctx) {
+		logf(ctx, "Error: '%s' of orgID '%s' may not transfer folders between organizations", p.ID, p.OrgID)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not transfer folders between organizations", p.ID)
+	}
+	if srcOrgID == dstOrgID {
+		logf(ctx, "Error: Cannot transfer folder '%s' within orgID '%s'", path, srcOrgID)
+		return nil, errorf(ErrInvalidMove, "cannot transfer folder '%s' within its organization, move it instead", path)
+	}
+
+	folder := FindByPath(f.folders, srcOrgID, path)
+	if folder == nil {
+		logf(ctx, "Error: Source folder '%s' does not exist in orgID '%s'", path, srcOrgID)
+		return nil, errorf(ErrFolderNotFound, "source folder '%s' does not exist", path)
+	}
+	var parent *Folder
+	if dstPath != "" {
+		if parent = FindByPath(f.folders, dstOrgID, dstPath); parent == nil {
+			logf(ctx, "Error: Destination folder '%s' does not exist in orgID '%s'", dstPath, dstOrgID)
+			return nil, errorf(ErrFolderNotFound, "destination folder '%s' does not exist", dstPath)
+		}
+	}
+
+	newPath := folder.Name
+	if parent != nil {
+		newPath = parent.Paths + "." + folder.Name
+	}
+	if FindByPath(f.folders, dstOrgID, newPath) != nil {
+		logf(ctx, "Error: Folder '%s' already exists in orgID '%s'", newPath, dstOrgID)
+		return nil, errorf(ErrFolderExists, "folder '%s' already exists in the destination organization", newPath)
+	}
+
+	transferred := f.subtree(srcOrgID, path)
+	if err := f.audit(ctx, AuditEntry{
+		OrgID:       srcOrgID,
+		DstOrgID:    &dstOrgID,
+		Op:          AuditTransfer,
+		OldPath:     path,
+		NewPath:     newPath,
+		Descendants: len(transferred) - 1,
+	}); err != nil {
+		return nil, err
+	}
+
+	oldPaths := pathsOf(folder)
+	removeChild(folder.Parent, folder)
+	folder.Parent = parent
+	if parent != nil {
+		parent.Children = append(parent.Children, folder)
+	}
+	setPath(folder, newPath)
+	setOrg(folder, dstOrgID)
+	changes := pathChanges(oldPaths, pathsOf(folder))
+
+	// Both organizations see the transfer, each in its own sequence of events
+	for _, orgID := range []uuid.UUID{srcOrgID, dstOrgID} {
+		f.publish(ctx, &FolderTransferred{EventMeta: EventMeta{OrgID: orgID}, SrcOrgID: srcOrgID, DstOrgID: dstOrgID, Changes: changes})
+	}
+
+	res := []*Folder{folder}
+	for _, d := range transferred {
+		if d != folder {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
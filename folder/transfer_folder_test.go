@@ -0,0 +1,215 @@
+package folder_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_TransferFolder tests the TransferFolder method.
+func Test_folder_TransferFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	tests := [...]struct {
+		name         string
+		ctx          context.Context
+		srcOrgID     uuid.UUID
+		path         string
+		dstOrgID     uuid.UUID
+		dstPath      string
+		existing     string // a folder created under foxtrot before transferring
+		wantPaths    []string
+		expectedKind error
+	}{
+		{
+			name:      "Under a folder",
+			srcOrgID:  orgID1,
+			path:      "alpha.bravo",
+			dstOrgID:  orgID2,
+			dstPath:   "foxtrot",
+			wantPaths: []string{"foxtrot.bravo", "foxtrot.bravo.charlie"},
+		},
+		{
+			name:      "To the root",
+			srcOrgID:  orgID1,
+			path:      "alpha",
+			dstOrgID:  orgID2,
+			wantPaths: []string{"alpha", "alpha.bravo", "alpha.bravo.charlie", "alpha.delta", "alpha.delta.echo"},
+		},
+		{
+			// Transfers keep names rather than renaming like copies
+			name:         "Name taken in the destination",
+			srcOrgID:     orgID1,
+			path:         "alpha.bravo",
+			dstOrgID:     orgID2,
+			dstPath:      "foxtrot",
+			existing:     "bravo",
+			expectedKind: folder.ErrFolderExists,
+		},
+		{
+			name:         "Destination does not exist",
+			srcOrgID:     orgID1,
+			path:         "alpha.bravo",
+			dstOrgID:     orgID2,
+			dstPath:      "golf",
+			expectedKind: folder.ErrFolderNotFound,
+		},
+		{
+			name:         "Source does not exist",
+			srcOrgID:     orgID2,
+			path:         "alpha.bravo",
+			dstOrgID:     orgID1,
+			dstPath:      "golf",
+			expectedKind: folder.ErrFolderNotFound,
+		},
+		{
+			name:         "Within the organization",
+			srcOrgID:     orgID1,
+			path:         "alpha.bravo",
+			dstOrgID:     orgID1,
+			dstPath:      "golf",
+			expectedKind: folder.ErrInvalidMove,
+		},
+		{
+			// Even a principal of the destination organization is refused
+			name:         "As a principal",
+			ctx:          folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID2}),
+			srcOrgID:     orgID1,
+			path:         "alpha.bravo",
+			dstOrgID:     orgID2,
+			dstPath:      "foxtrot",
+			expectedKind: folder.ErrPermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			folders, folderMap := initializeFolders(orgID1, orgID2)
+			driver := folder.NewDriver(folders)
+			if tt.existing != "" {
+				_, err := driver.CreateFolder(context.Background(), orgID2, tt.existing, "foxtrot")
+				assert.NoError(t, err)
+			}
+			before1 := paths(orgFolders(t, driver, orgID1))
+			before2 := paths(orgFolders(t, driver, orgID2))
+
+			transferred, err := driver.TransferFolder(ctx, tt.srcOrgID, tt.path, tt.dstOrgID, tt.dstPath)
+			if tt.expectedKind != nil {
+				assert.True(t, errors.Is(err, tt.expectedKind), "got error %v", err)
+				assert.Equal(t, before1, paths(orgFolders(t, driver, orgID1)), "no folder should be transferred")
+				assert.Equal(t, before2, paths(orgFolders(t, driver, orgID2)), "no folder should be transferred")
+				return
+			}
+
+			assert.NoError(t, err)
+			var got []string
+			for _, f := range transferred {
+				got = append(got, f.Paths)
+				assert.Equal(t, orgID2, f.OrgId)
+			}
+			assert.Equal(t, tt.wantPaths, got)
+
+			// The folders themselves change organization
+			assert.Same(t, folderMap["bravo"], transferred[indexOf(got, "bravo")])
+			assert.Len(t, orgFolders(t, driver, orgID1), len(before1)-len(tt.wantPaths))
+			assert.Subset(t, paths(orgFolders(t, driver, orgID2)), paths(transferred))
+			if parent := transferred[0].Parent; parent != nil {
+				assert.Contains(t, parent.Children, transferred[0])
+			}
+		})
+	}
+}
+
+// indexOf returns the index of the path ending in name, or -1.
+func indexOf(paths []string, name string) int {
+	for i, p := range paths {
+		if p == name || strings.HasSuffix(p, "."+name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Test_folder_TransferFolder_Recorded tests that transfers are audited and published apart from moves.
+func Test_folder_TransferFolder_Recorded(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, _ := initializeFolders(orgID1, orgID2)
+	sink := &memorySink{}
+	bus := folder.NewEventBus()
+	sub1 := bus.Subscribe(folder.SubscribeOptions{OrgID: orgID1})
+	defer sub1.Close()
+	sub2 := bus.Subscribe(folder.SubscribeOptions{OrgID: orgID2})
+	defer sub2.Close()
+	driver := folder.NewDriver(folders, folder.WithAuditSink(sink), folder.WithEventBus(bus))
+
+	_, err := driver.TransferFolder(context.Background(), orgID1, "alpha.bravo", orgID2, "foxtrot")
+	assert.NoError(t, err)
+
+	// One audit entry, found from either organization
+	assert.Len(t, sink.entries, 1)
+	entry := sink.entries[0]
+	assert.Equal(t, folder.AuditTransfer, entry.Op)
+	assert.Equal(t, orgID1, entry.OrgID)
+	assert.Equal(t, orgID2, *entry.DstOrgID)
+	assert.Equal(t, "alpha.bravo", entry.OldPath)
+	assert.Equal(t, "foxtrot.bravo", entry.NewPath)
+	assert.Equal(t, 1, entry.Descendants)
+	assert.True(t, folder.AuditQuery{OrgID: orgID1}.Matches(entry))
+	assert.True(t, folder.AuditQuery{OrgID: orgID2}.Matches(entry))
+
+	// An event in each organization
+	for _, sub := range []*folder.Subscription{sub1, sub2} {
+		e, ok := receive(t, sub).(*folder.FolderTransferred)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(1), e.Seq)
+		assert.Equal(t, orgID1, e.SrcOrgID)
+		assert.Equal(t, orgID2, e.DstOrgID)
+		assert.Equal(t, []folder.PathChange{
+			{Old: "alpha.bravo", New: "foxtrot.bravo"},
+			{Old: "alpha.bravo.charlie", New: "foxtrot.bravo.charlie"},
+		}, e.Changes)
+	}
+}
+
+// Test_folder_TransferFolder_SameNames tests that transferred folders sharing names with the destination's are moved by path.
+func Test_folder_TransferFolder_SameNames(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, folderMap := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders)
+	ctx := context.Background()
+	charlie, err := driver.CreateFolder(ctx, orgID2, "charlie", "foxtrot")
+	assert.NoError(t, err)
+
+	// Only the transferred folder's own path must be free
+	_, err = driver.TransferFolder(ctx, orgID1, "alpha.bravo", orgID2, "")
+	assert.NoError(t, err)
+
+	org2 := folder.WithPrincipal(ctx, folder.Principal{OrgID: orgID2})
+	_, err = driver.MoveFolder(org2, "charlie", "foxtrot")
+	assert.True(t, errors.Is(err, folder.ErrInvalidMove), "got error %v", err)
+
+	_, err = driver.MoveFolderByPath(org2, orgID2, "bravo.charlie", "foxtrot.charlie")
+	assert.NoError(t, err)
+	assert.Equal(t, "foxtrot.charlie.charlie", folderMap["charlie"].Paths)
+	assert.Equal(t, "foxtrot.charlie", charlie.Paths)
+}
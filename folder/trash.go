@@ -0,0 +1,237 @@
+package folder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// TrashDriver is a driver whose deletes keep the deleted subtrees in a trash, from which they can be
+// restored until they are purged.
+type TrashDriver interface {
+	IDriver
+	// Trash returns the trash of an organization, oldest deletion first.
+	Trash(ctx context.Context, orgID uuid.UUID) ([]*TrashItem, error)
+	// AllTrash returns the trash of every organization, for trusted callers saving it.
+	AllTrash(ctx context.Context) ([]*TrashItem, error)
+	// RestoreFolder moves a subtree out of the trash, under the folder at parentPath or, if parentPath
+	// is empty, back to its original path. It returns the restored folders.
+	RestoreFolder(ctx context.Context, orgID uuid.UUID, id uuid.UUID, parentPath string) ([]*Folder, error)
+	// PurgeTrash permanently drops the trash items deleted longer than the retention before now, and returns them.
+	PurgeTrash(ctx context.Context, now time.Time) ([]*TrashItem, error)
+}
+
+var _ TrashDriver = (*driver)(nil)
+
+// TrashItem is a deleted subtree, kept in the trash of its organization until it is restored or purged.
+type TrashItem struct {
+	ID        uuid.UUID `json:"id"`
+	OrgID     uuid.UUID `json:"org_id"`
+	Path      string    `json:"path"` // the path of the deleted folder when it was deleted
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by,omitempty"` // the principal's ID, empty for trusted callers
+	// Folders are the deleted folder, first, and its descendants, still linked to each other.
+	Folders []*Folder `json:"folders"`
+}
+
+// WithTrash makes DeleteFolder move subtrees to the trash of their organization instead of dropping them.
+// The trash starts with items, such as those saved with the dataset and read by LoadTrash.
+// PurgeTrash drops the items deleted longer than retention ago; with a retention of 0 they are kept.
+func WithTrash(retention time.Duration, items ...*TrashItem) Option {
+	return func(f *driver) {
+		f.trash = make(map[uuid.UUID][]*TrashItem)
+		f.trashRetention = retention
+		for _, item := range items {
+			f.trash[item.OrgID] = append(f.trash[item.OrgID], item)
+		}
+	}
+}
+
+// TrashFile returns the file the trash of a dataset file is saved in, next to it:
+// folders.trash.json for folders.json.
+func TrashFile(datasetFile string) string {
+	ext := filepath.Ext(datasetFile)
+	return strings.TrimSuffix(datasetFile, ext) + ".trash" + ext
+}
+
+// MarshalTrash converts trash items to the trash file format, a JSON list of items with their folders.
+func MarshalTrash(items []*TrashItem) ([]byte, error) {
+	if items == nil {
+		items = []*TrashItem{}
+	}
+	return json.MarshalIndent(items, "", "\t")
+}
+
+// LoadTrash reads a trash file written by MarshalTrash, and links the folders of every item.
+func LoadTrash(data []byte) ([]*TrashItem, error) {
+	var items []*TrashItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse trash: %w", err)
+	}
+
+	for i, item := range items {
+		switch {
+		case item.ID == uuid.Nil || item.OrgID == uuid.Nil:
+			return nil, fmt.Errorf("trash item %d: missing id or org_id", i)
+		case len(item.Folders) == 0 || item.Folders[0].Paths != item.Path:
+			return nil, fmt.Errorf("trash item '%s': the first folder must be the deleted folder '%s'", item.ID, item.Path)
+		}
+
+		byPath := make(map[string]*Folder, len(item.Folders))
+		for _, folder := range item.Folders {
+			if folder.OrgId != item.OrgID || (folder.Paths != folder.Name && !strings.HasSuffix(folder.Paths, "."+folder.Name)) {
+				return nil, fmt.Errorf("trash item '%s': invalid folder '%s' of orgID '%s'", item.ID, folder.Paths, folder.OrgId)
+			}
+			byPath[folder.Paths] = folder
+		}
+		for _, folder := range item.Folders[1:] {
+			parent := byPath[folder.Paths[:strings.LastIndex(folder.Paths, ".")]]
+			if !inSubtree(folder.Paths, item.Path) || parent == nil {
+				return nil, fmt.Errorf("trash item '%s': folder '%s' is not in the deleted subtree", item.ID, folder.Paths)
+			}
+			folder.Parent = parent
+			parent.Children = append(parent.Children, folder)
+		}
+	}
+	return items, nil
+}
+
+// trashDeleted puts a subtree deleted with ctx in the trash, if the driver has one.
+func (f *driver) trashDeleted(ctx context.Context, orgID uuid.UUID, path string, deleted []*Folder) {
+	if f.trash == nil {
+		return
+	}
+	item := &TrashItem{ID: uuid.Must(uuid.NewV4()), OrgID: orgID, Path: path, DeletedAt: time.Now().UTC()}
+	if p, ok := PrincipalFrom(ctx); ok {
+		item.DeletedBy = p.ID
+	}
+	for _, d := range deleted {
+		if d.Paths == path {
+			item.Folders = append([]*Folder{d}, item.Folders...)
+		} else {
+			item.Folders = append(item.Folders, d)
+		}
+	}
+	f.trash[orgID] = append(f.trash[orgID], item)
+}
+
+// Trash returns the trash of an organization, oldest deletion first.
+func (f *driver) Trash(ctx context.Context, orgID uuid.UUID) ([]*TrashItem, error) {
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return append([]*TrashItem(nil), f.trash[orgID]...), nil
+}
+
+// AllTrash returns the trash of every organization, oldest deletion first. Callers with a principal
+// are denied, as the trash of other organizations would leak.
+func (f *driver) AllTrash(ctx context.Context) ([]*TrashItem, error) {
+	if p, ok := PrincipalFrom(ctx); ok {
+		logf(ctx, "Error: '%s' may not access the trash of every organization", p.ID)
+		return nil, errorf(ErrPermissionDenied, "'%s' may not access the trash of every organization", p.ID)
+	}
+	var res []*TrashItem
+	for _, items := range f.trash {
+		res = append(res, items...)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].DeletedAt.Before(res[j].DeletedAt) })
+	return res, nil
+}
+
+// RestoreFolder moves a subtree out of the trash, under the folder at parentPath or, if parentPath is empty,
+// back to its original path. It returns the restored folders.
+func (f *driver) RestoreFolder(ctx context.Context, orgID uuid.UUID, id uuid.UUID, parentPath string) ([]*Folder, error) {
+	if err := authorizeOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	idx := -1
+	for i, item := range f.trash[orgID] {
+		if item.ID == id {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		logf(ctx, "Error: Trash item '%s' does not exist in orgID '%s'", id, orgID)
+		return nil, errorf(ErrFolderNotFound, "trash item '%s' does not exist", id)
+	}
+	item := f.trash[orgID][idx]
+	folder := item.Folders[0]
+
+	var parent *Folder
+	if parentPath != "" {
+		if parent = FindByPath(f.folders, orgID, parentPath); parent == nil {
+			logf(ctx, "Error: Parent folder '%s' does not exist in orgID '%s'", parentPath, orgID)
+			return nil, errorf(ErrFolderNotFound, "parent folder '%s' does not exist", parentPath)
+		}
+	} else if i := strings.LastIndex(item.Path, "."); i >= 0 {
+		if parent = FindByPath(f.folders, orgID, item.Path[:i]); parent == nil {
+			logf(ctx, "Error: Original parent folder '%s' of '%s' no longer exists in orgID '%s'", item.Path[:i], item.Path, orgID)
+			return nil, errorf(ErrFolderNotFound, "original parent folder '%s' no longer exists", item.Path[:i])
+		}
+	}
+
+	newPath := folder.Name
+	if parent != nil {
+		newPath = parent.Paths + "." + folder.Name
+	}
+	if FindByPath(f.folders, orgID, newPath) != nil {
+		logf(ctx, "Error: Folder '%s' already exists in orgID '%s'", newPath, orgID)
+		return nil, errorf(ErrFolderExists, "folder '%s' already exists", newPath)
+	}
+
+	if err := f.audit(ctx, AuditEntry{OrgID: orgID, Op: AuditRestore, OldPath: item.Path, NewPath: newPath, Descendants: len(item.Folders) - 1}); err != nil {
+		return nil, err
+	}
+
+	setPath(folder, newPath)
+	folder.Parent = parent
+	if parent != nil {
+		parent.Children = append(parent.Children, folder)
+	}
+	f.folders = append(f.folders, item.Folders...)
+	f.trash[orgID] = append(f.trash[orgID][:idx:idx], f.trash[orgID][idx+1:]...)
+	f.publish(ctx, &FolderRestored{EventMeta: EventMeta{OrgID: orgID}, Paths: pathsOf(folder)})
+
+	return item.Folders, nil
+}
+
+// PurgeTrash permanently drops the trash items of every organization deleted longer than the retention
+// before now, and returns them. Every purge is audited and published like other mutations; an item whose
+// purge can't be audited is kept, and its error returned with the items purged until then.
+func (f *driver) PurgeTrash(ctx context.Context, now time.Time) ([]*TrashItem, error) {
+	if f.trashRetention <= 0 {
+		return nil, nil
+	}
+	var purged []*TrashItem
+	for orgID, items := range f.trash {
+		kept := items[:0:0]
+		var err error
+		for _, item := range items {
+			if err != nil || now.Sub(item.DeletedAt) < f.trashRetention {
+				kept = append(kept, item)
+				continue
+			}
+			err = f.audit(ctx, AuditEntry{OrgID: orgID, Op: AuditPurge, OldPath: item.Path, Descendants: len(item.Folders) - 1})
+			if err != nil {
+				kept = append(kept, item)
+				continue
+			}
+			purged = append(purged, item)
+			f.publish(ctx, &FolderPurged{EventMeta: EventMeta{OrgID: orgID}, Paths: pathsOf(item.Folders[0])})
+		}
+		f.trash[orgID] = kept
+		if err != nil {
+			return purged, err
+		}
+	}
+	if len(purged) > 0 {
+		logf(ctx, "Info: Purged %d trash items", len(purged))
+	}
+	return purged, nil
+}
@@ -0,0 +1,303 @@
+package folder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_Trash tests that deleted subtrees are kept in the trash of their organization, out of queries.
+func Test_folder_Trash(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, _ := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders, folder.WithTrash(0))
+	ctx := folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID1})
+
+	deleted, err := driver.DeleteFolder(ctx, orgID1, "alpha.bravo")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bravo", "charlie"}, names(deleted))
+
+	// Trashed folders are excluded from queries
+	assert.Equal(t, []string{"alpha", "delta", "echo", "golf"}, names(orgFolders(t, driver, orgID1)))
+	children, err := driver.GetAllChildFolders(ctx, orgID1, "alpha")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"delta", "echo"}, names(children))
+
+	trash, err := driver.Trash(ctx, orgID1)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, "alpha.bravo", trash[0].Path)
+	assert.Equal(t, "alice", trash[0].DeletedBy)
+	assert.Equal(t, []string{"bravo", "charlie"}, names(trash[0].Folders))
+
+	// The trash is per organization
+	trash, err = driver.Trash(context.Background(), orgID2)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	_, err = driver.Trash(ctx, orgID2)
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+
+	// Without a trash, deletions are final
+	folders, _ = initializeFolders(orgID1, orgID2)
+	plain := folder.NewDriver(folders)
+	_, err = plain.DeleteFolder(ctx, orgID1, "alpha.bravo")
+	assert.NoError(t, err)
+	trash, err = plain.Trash(ctx, orgID1)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+// Test_folder_RestoreFolder tests restoring to the original path or under another parent.
+func Test_folder_RestoreFolder(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+
+	tests := [...]struct {
+		name         string
+		delete       []string // deleted in order, the first is restored
+		create       string   // a path created after the deletions
+		orgID        uuid.UUID
+		parent       string
+		unknownID    bool
+		wantPaths    []string
+		expectedKind error
+	}{
+		{
+			name:      "Original path",
+			delete:    []string{"alpha.bravo"},
+			orgID:     orgID1,
+			wantPaths: []string{"alpha.bravo", "alpha.bravo.charlie"},
+		},
+		{
+			name:      "Root folder",
+			delete:    []string{"golf"},
+			orgID:     orgID1,
+			wantPaths: []string{"golf"},
+		},
+		{
+			name:      "Another parent",
+			delete:    []string{"alpha.bravo"},
+			orgID:     orgID1,
+			parent:    "golf",
+			wantPaths: []string{"golf.bravo", "golf.bravo.charlie"},
+		},
+		{
+			// Restoring a child before its deleted parent needs another parent
+			name:      "Original parent deleted, another parent",
+			delete:    []string{"alpha.bravo.charlie", "alpha.bravo"},
+			orgID:     orgID1,
+			parent:    "alpha.delta",
+			wantPaths: []string{"alpha.delta.charlie"},
+		},
+		{
+			name:         "Original parent deleted",
+			delete:       []string{"alpha.bravo.charlie", "alpha.bravo"},
+			orgID:        orgID1,
+			expectedKind: folder.ErrFolderNotFound,
+		},
+		{
+			name:         "Parent does not exist",
+			delete:       []string{"alpha.bravo"},
+			orgID:        orgID1,
+			parent:       "alpha.zulu",
+			expectedKind: folder.ErrFolderNotFound,
+		},
+		{
+			// A folder created at the original path since the deletion takes precedence
+			name:         "Original path taken",
+			delete:       []string{"alpha.bravo"},
+			create:       "bravo",
+			orgID:        orgID1,
+			expectedKind: folder.ErrFolderExists,
+		},
+		{
+			name:         "Unknown item",
+			delete:       []string{"alpha.bravo"},
+			orgID:        orgID1,
+			unknownID:    true,
+			expectedKind: folder.ErrFolderNotFound,
+		},
+		{
+			name:         "Item of another organization",
+			delete:       []string{"alpha.bravo"},
+			orgID:        orgID2,
+			expectedKind: folder.ErrFolderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			folders, _ := initializeFolders(orgID1, orgID2)
+			bus := folder.NewEventBus()
+			driver := folder.NewDriver(folders, folder.WithTrash(0), folder.WithEventBus(bus))
+
+			for _, path := range tt.delete {
+				_, err := driver.DeleteFolder(ctx, orgID1, path)
+				assert.NoError(t, err)
+			}
+			if tt.create != "" {
+				_, err := driver.CreateFolder(ctx, orgID1, tt.create, "alpha")
+				assert.NoError(t, err)
+			}
+			trash, err := driver.Trash(ctx, orgID1)
+			assert.NoError(t, err)
+			id := trash[0].ID
+			if tt.unknownID {
+				id = uuid.Must(uuid.NewV4())
+			}
+			sub := bus.Subscribe(folder.SubscribeOptions{})
+			defer sub.Close()
+
+			before := paths(orgFolders(t, driver, orgID1))
+			restored, err := driver.RestoreFolder(ctx, tt.orgID, id, tt.parent)
+			if tt.expectedKind != nil {
+				assert.True(t, errors.Is(err, tt.expectedKind), "got error %v", err)
+				assert.Equal(t, before, paths(orgFolders(t, driver, orgID1)), "no folder should be restored")
+				trash, _ := driver.Trash(ctx, orgID1)
+				assert.Len(t, trash, len(tt.delete))
+				return
+			}
+
+			assert.NoError(t, err)
+			var got []string
+			for _, f := range restored {
+				got = append(got, f.Paths)
+			}
+			assert.Equal(t, tt.wantPaths, got)
+			assert.Subset(t, paths(orgFolders(t, driver, orgID1)), paths(restored))
+			if parent := restored[0].Parent; parent != nil {
+				assert.Contains(t, parent.Children, restored[0])
+			}
+			trash, _ = driver.Trash(ctx, orgID1)
+			assert.Len(t, trash, len(tt.delete)-1)
+
+			e := receive(t, sub)
+			assert.Equal(t, tt.wantPaths, e.(*folder.FolderRestored).Paths)
+		})
+	}
+}
+
+// Test_folder_PurgeTrash tests that trash items are purged after the retention, audited and published.
+func Test_folder_PurgeTrash(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	ctx := context.Background()
+	folders, _ := initializeFolders(orgID1, orgID2)
+	sink := &memorySink{}
+	bus := folder.NewEventBus()
+	sub := bus.Subscribe(folder.SubscribeOptions{OrgID: orgID1})
+	defer sub.Close()
+	driver := folder.NewDriver(folders, folder.WithTrash(time.Hour), folder.WithAuditSink(sink), folder.WithEventBus(bus))
+
+	_, err := driver.DeleteFolder(ctx, orgID1, "alpha.bravo")
+	assert.NoError(t, err)
+	_, err = driver.DeleteFolder(ctx, orgID2, "foxtrot")
+	assert.NoError(t, err)
+	receive(t, sub)
+
+	purged, err := driver.PurgeTrash(ctx, time.Now().Add(59*time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, purged)
+
+	// A purge that can't be audited keeps the item
+	sink.err = errors.New("disk full")
+	purged, err = driver.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	assert.EqualError(t, err, "audit purge: disk full")
+	trash, _ := driver.Trash(ctx, orgID1)
+	trash2, _ := driver.Trash(ctx, orgID2)
+	assert.Len(t, append(trash, trash2...), 2-len(purged))
+
+	sink.err = nil
+	purged, err = driver.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, purged)
+	for _, orgID := range []uuid.UUID{orgID1, orgID2} {
+		trash, err := driver.Trash(ctx, orgID)
+		assert.NoError(t, err)
+		assert.Empty(t, trash)
+	}
+
+	var entries []folder.AuditEntry
+	for _, e := range sink.entries {
+		if e.Op == folder.AuditPurge {
+			entries = append(entries, folder.AuditEntry{OrgID: e.OrgID, Op: e.Op, OldPath: e.OldPath, Descendants: e.Descendants})
+		}
+	}
+	assert.ElementsMatch(t, []folder.AuditEntry{
+		{OrgID: orgID1, Op: folder.AuditPurge, OldPath: "alpha.bravo", Descendants: 1},
+		{OrgID: orgID2, Op: folder.AuditPurge, OldPath: "foxtrot"},
+	}, entries)
+	e, ok := receive(t, sub).(*folder.FolderPurged)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"alpha.bravo", "alpha.bravo.charlie"}, e.Paths)
+
+	// Without a retention, items are kept
+	folders, _ = initializeFolders(orgID1, orgID2)
+	driver = folder.NewDriver(folders, folder.WithTrash(0))
+	_, err = driver.DeleteFolder(ctx, orgID1, "golf")
+	assert.NoError(t, err)
+	purged, err = driver.PurgeTrash(ctx, time.Now().Add(24*time.Hour*365))
+	assert.NoError(t, err)
+	assert.Empty(t, purged)
+}
+
+// Test_folder_Trash_SaveLoad tests that a saved trash can be restored from by another driver.
+func Test_folder_Trash_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	folders, _ := initializeFolders(orgID1, orgID2)
+	driver := folder.NewDriver(folders, folder.WithTrash(0))
+	ctx := context.Background()
+
+	_, err := driver.DeleteFolder(ctx, orgID1, "alpha.bravo")
+	assert.NoError(t, err)
+	_, err = driver.DeleteFolder(ctx, orgID2, "foxtrot")
+	assert.NoError(t, err)
+	_, err = driver.AllTrash(folder.WithPrincipal(ctx, folder.Principal{ID: "alice", OrgID: orgID1}))
+	assert.True(t, errors.Is(err, folder.ErrPermissionDenied))
+
+	items, err := driver.AllTrash(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	trash, err := folder.MarshalTrash(items)
+	assert.NoError(t, err)
+	dataset, err := folder.MarshalDataset(append(orgFolders(t, driver, orgID1), orgFolders(t, driver, orgID2)...))
+	assert.NoError(t, err)
+
+	loaded, err := folder.LoadTrash(trash)
+	assert.NoError(t, err)
+	remaining, err := folder.LoadDataset(dataset)
+	assert.NoError(t, err)
+	reloaded := folder.NewDriver(remaining, folder.WithTrash(0, loaded...))
+
+	orgTrash, err := reloaded.Trash(ctx, orgID1)
+	assert.NoError(t, err)
+	assert.Len(t, orgTrash, 1)
+	restored, err := reloaded.RestoreFolder(ctx, orgID1, orgTrash[0].ID, "alpha.delta")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{orgID1.String() + "/alpha.delta.bravo", orgID1.String() + "/alpha.delta.bravo.charlie"}, paths(restored))
+
+	_, err = folder.LoadTrash([]byte(`[{"id": "` + orgID1.String() + `", "org_id": "` + orgID1.String() + `", "path": "a", "folders": [
+		{"name": "a", "org_id": "` + orgID1.String() + `", "paths": "a"},
+		{"name": "c", "org_id": "` + orgID1.String() + `", "paths": "a.b.c"}
+	]}]`))
+	assert.EqualError(t, err, "trash item '"+orgID1.String()+"': folder 'a.b.c' is not in the deleted subtree")
+	assert.Equal(t, "data/folders.trash.json", folder.TrashFile("data/folders.json"))
+}
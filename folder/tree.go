@@ -0,0 +1,77 @@
+package folder
+
+import (
+	"github.com/gofrs/uuid"
+)
+
+// Tree is the nested form of an organization's folders.
+type Tree struct {
+	OrgId   uuid.UUID   `json:"org_id" yaml:"org_id"`
+	Folders []*TreeNode `json:"folders" yaml:"folders"`
+}
+
+// TreeNode is a folder in the nested form; its path is implied by its position.
+type TreeNode struct {
+	Name     string      `json:"name" yaml:"name"`
+	Children []*TreeNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// ToTrees converts folders in the Paths form to one nested tree per organization.
+// Organizations are ordered by first appearance and children keep input order.
+func ToTrees(folders []*Folder) ([]*Tree, error) {
+	_, parents, err := indexFolders(folders)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*TreeNode, len(folders))
+	for i, folder := range folders {
+		nodes[i] = &TreeNode{Name: folder.Name}
+	}
+
+	var trees []*Tree
+	byOrg := make(map[uuid.UUID]*Tree)
+	for i, parent := range parents {
+		if parent != 0 {
+			nodes[parent-1].Children = append(nodes[parent-1].Children, nodes[i])
+			continue
+		}
+
+		tree, exists := byOrg[folders[i].OrgId]
+		if !exists {
+			tree = &Tree{OrgId: folders[i].OrgId}
+			byOrg[folders[i].OrgId] = tree
+			trees = append(trees, tree)
+		}
+		tree.Folders = append(tree.Folders, nodes[i])
+	}
+
+	return trees, nil
+}
+
+// FromTrees converts nested trees to linked folders in the Paths form, listed depth first.
+func FromTrees(trees []*Tree) []*Folder {
+	var folders []*Folder
+
+	var visit func(node *TreeNode, orgID uuid.UUID, parent *Folder)
+	visit = func(node *TreeNode, orgID uuid.UUID, parent *Folder) {
+		folder := &Folder{Name: node.Name, OrgId: orgID, Paths: node.Name, Parent: parent}
+		if parent != nil {
+			folder.Paths = parent.Paths + "." + node.Name
+			parent.Children = append(parent.Children, folder)
+		}
+		folders = append(folders, folder)
+
+		for _, child := range node.Children {
+			visit(child, orgID, folder)
+		}
+	}
+
+	for _, tree := range trees {
+		for _, node := range tree.Folders {
+			visit(node, tree.OrgId, nil)
+		}
+	}
+
+	return folders
+}
@@ -0,0 +1,165 @@
+package folder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadYAML imports linked folders from the nested YAML form:
+//
+//	- org_id: c1556e17-b7c0-45a3-a6ae-9546248fb17a
+//	  folders:
+//	    - name: alpha
+//	      children:
+//	        - name: bravo
+//
+// Every invalid node is reported as a *LineError joined into the returned error.
+func ReadYAML(r io.Reader) ([]*Folder, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return []*Folder{}, nil
+		}
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, lineErrorf(root.Line, "expected a list of organizations")
+	}
+
+	var errs []error
+	var trees []*Tree
+	roots := make(map[uuid.UUID]map[string]int) // root names per org, to detect duplicates across entries
+
+	for _, item := range root.Content {
+		tree, err := decodeTree(item, roots)
+		errs = append(errs, err...)
+		if tree != nil {
+			trees = append(trees, tree)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return FromTrees(trees), nil
+}
+
+// decodeTree decodes an organization entry of the YAML form.
+func decodeTree(node *yaml.Node, roots map[uuid.UUID]map[string]int) (*Tree, []error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, []error{lineErrorf(node.Line, "expected an organization mapping")}
+	}
+
+	var errs []error
+	tree := &Tree{}
+	var orgNode, foldersNode *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "org_id":
+			orgNode = value
+		case "folders":
+			foldersNode = value
+		default:
+			errs = append(errs, lineErrorf(key.Line, "unknown field '%s'", key.Value))
+		}
+	}
+
+	if orgNode == nil {
+		return nil, append(errs, lineErrorf(node.Line, "org_id is required"))
+	}
+	orgID, err := uuid.FromString(orgNode.Value)
+	if err != nil || orgNode.Kind != yaml.ScalarNode {
+		return nil, append(errs, lineErrorf(orgNode.Line, "invalid org_id '%s'", orgNode.Value))
+	}
+	tree.OrgId = orgID
+
+	if roots[orgID] == nil {
+		roots[orgID] = make(map[string]int)
+	}
+	if foldersNode != nil {
+		var folderErrs []error
+		tree.Folders, folderErrs = decodeTreeNodes(foldersNode, roots[orgID])
+		errs = append(errs, folderErrs...)
+	}
+
+	return tree, errs
+}
+
+// decodeTreeNodes decodes a list of sibling folders; siblings records the line each name was first used on.
+func decodeTreeNodes(node *yaml.Node, siblings map[string]int) ([]*TreeNode, []error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, []error{lineErrorf(node.Line, "expected a list of folders")}
+	}
+
+	var errs []error
+	var res []*TreeNode
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			errs = append(errs, lineErrorf(item.Line, "expected a folder mapping"))
+			continue
+		}
+
+		treeNode := &TreeNode{}
+		var childrenNode *yaml.Node
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+			switch key.Value {
+			case "name":
+				treeNode.Name = value.Value
+			case "children":
+				childrenNode = value
+			default:
+				errs = append(errs, lineErrorf(key.Line, "unknown field '%s'", key.Value))
+			}
+		}
+
+		switch {
+		case treeNode.Name == "":
+			errs = append(errs, lineErrorf(item.Line, "name is required"))
+			continue
+		case strings.Contains(treeNode.Name, "."):
+			errs = append(errs, lineErrorf(item.Line, "name '%s' must not contain '.'", treeNode.Name))
+			continue
+		}
+		if first, exists := siblings[treeNode.Name]; exists {
+			errs = append(errs, lineErrorf(item.Line, "duplicate folder '%s', first used on line %d", treeNode.Name, first))
+			continue
+		}
+		siblings[treeNode.Name] = item.Line
+
+		if childrenNode != nil {
+			var childErrs []error
+			treeNode.Children, childErrs = decodeTreeNodes(childrenNode, make(map[string]int))
+			errs = append(errs, childErrs...)
+		}
+		res = append(res, treeNode)
+	}
+
+	return res, errs
+}
+
+// WriteYAML exports folders in the nested YAML form.
+func WriteYAML(w io.Writer, folders []*Folder) error {
+	trees, err := ToTrees(folders)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(trees); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,105 @@
+package folder_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_folder_YAML_RoundTrip tests that sample.json survives an export to YAML and back.
+func Test_folder_YAML_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sample := folder.GetSampleData()
+
+	var buf bytes.Buffer
+	assert.NoError(t, folder.WriteYAML(&buf, sample))
+
+	got, err := folder.ReadYAML(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, paths(sample), paths(got))
+}
+
+// Test_folder_ReadYAML tests the nested form and validation errors of ReadYAML.
+func Test_folder_ReadYAML(t *testing.T) {
+	t.Parallel()
+
+	const org = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+
+	tests := [...]struct {
+		name          string
+		input         string
+		want          []string
+		expectedError string
+	}{
+		{
+			name: "Nested folders",
+			input: `
+- org_id: ` + org + `
+  folders:
+    - name: alpha
+      children:
+        - name: bravo
+          children:
+            - name: charlie
+        - name: delta
+    - name: echo
+`,
+			want: []string{org + "/alpha", org + "/alpha.bravo", org + "/alpha.bravo.charlie", org + "/alpha.delta", org + "/echo"},
+		},
+		{
+			name:  "Empty file",
+			input: "",
+			want:  []string{},
+		},
+		{
+			name: "Every invalid node is reported with its line",
+			input: `
+- org_id: not-a-uuid
+- folders:
+    - name: alpha
+- org_id: ` + org + `
+  color: red
+  folders:
+    - name: alpha
+      children:
+        - name: bravo
+        - name: bravo
+        - name: a.b
+    - children: []
+    - name: alpha
+`,
+			expectedError: strings.Join([]string{
+				"line 2: invalid org_id 'not-a-uuid'",
+				"line 3: org_id is required",
+				"line 6: unknown field 'color'",
+				"line 11: duplicate folder 'bravo', first used on line 10",
+				"line 12: name 'a.b' must not contain '.'",
+				"line 13: name is required",
+				"line 14: duplicate folder 'alpha', first used on line 8",
+			}, "\n"),
+		},
+		{
+			name:          "Not a list",
+			input:         "org_id: " + org + "\n",
+			expectedError: "line 1: expected a list of organizations",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := folder.ReadYAML(strings.NewReader(tt.input))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, paths(got))
+		})
+	}
+}
@@ -4,12 +4,21 @@ go 1.23
 
 require (
 	github.com/gofrs/uuid v4.3.0+incompatible
+	github.com/graph-gophers/graphql-go v1.5.0
 	github.com/lucasepe/codename v0.2.0
+	github.com/peterh/liner v1.2.2
 	github.com/stretchr/testify v1.8.0
+	google.golang.org/grpc v1.65.0
+	google.golang.org/protobuf v1.36.9
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/mattn/go-runewidth v0.0.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
 )
@@ -0,0 +1,93 @@
+package graphqlapi
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+)
+
+// node is an immutable snapshot of a folder, linked within its organization.
+type node struct {
+	name     string
+	path     string
+	org      uuid.UUID
+	parent   *node
+	children []*node
+}
+
+// orgIndex is every folder of an organization, indexed by path.
+type orgIndex struct {
+	byPath map[string]*node
+	all    []*node
+}
+
+// loader batches folder lookups per request: the first lookup in an organization loads
+// and links all of its folders with a single driver call, so resolving parents and
+// children of many folders doesn't traverse the driver's folders once per field.
+type loader struct {
+	driver   folder.IDriver
+	driverMu *sync.RWMutex
+
+	mu   sync.Mutex
+	orgs map[uuid.UUID]*orgIndex
+}
+
+type loaderKey struct{}
+
+func newLoader(driver folder.IDriver, driverMu *sync.RWMutex) *loader {
+	return &loader{driver: driver, driverMu: driverMu, orgs: make(map[uuid.UUID]*orgIndex)}
+}
+
+func withLoader(ctx context.Context, l *loader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFrom(ctx context.Context) *loader {
+	return ctx.Value(loaderKey{}).(*loader)
+}
+
+// org returns the index of an organization, loading it on first use.
+func (l *loader) org(ctx context.Context, orgID uuid.UUID) (*orgIndex, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if index, exists := l.orgs[orgID]; exists {
+		return index, nil
+	}
+
+	l.driverMu.RLock()
+	folders, err := l.driver.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		l.driverMu.RUnlock()
+		return nil, err
+	}
+	index := &orgIndex{byPath: make(map[string]*node, len(folders))}
+	for _, f := range folders {
+		n := &node{name: f.Name, path: f.Paths, org: f.OrgId}
+		index.byPath[n.path] = n
+		index.all = append(index.all, n)
+	}
+	l.driverMu.RUnlock()
+
+	for _, n := range index.all {
+		if idx := strings.LastIndex(n.path, "."); idx >= 0 {
+			if parent, exists := index.byPath[n.path[:idx]]; exists {
+				n.parent = parent
+				parent.children = append(parent.children, n)
+			}
+		}
+	}
+
+	l.orgs[orgID] = index
+	return index, nil
+}
+
+// invalidate drops every loaded organization, after a mutation.
+func (l *loader) invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.orgs = make(map[uuid.UUID]*orgIndex)
+}
@@ -0,0 +1,250 @@
+// Package graphqlapi serves nested folder queries over GraphQL, backed by a folder driver.
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// NewHandler returns an HTTP handler executing GraphQL requests against driver.
+// mu guards driver and must be shared with anything else using it.
+func NewHandler(driver folder.IDriver, mu *sync.RWMutex) http.Handler {
+	h := &relay.Handler{Schema: graphql.MustParseSchema(schema, &resolver{driver: driver, mu: mu})}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(withLoader(r.Context(), newLoader(driver, mu))))
+	})
+}
+
+// resolver is the root resolver of queries and mutations.
+type resolver struct {
+	driver folder.IDriver
+	mu     *sync.RWMutex
+}
+
+func (r *resolver) Folder(ctx context.Context, args struct {
+	Org  graphql.ID
+	Path string
+}) (*folderResolver, error) {
+	orgID, err := parseOrgID(args.Org)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := loaderFrom(ctx).org(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	n, exists := index.byPath[args.Path]
+	if !exists {
+		return nil, nil
+	}
+	return &folderResolver{n}, nil
+}
+
+func (r *resolver) Folders(ctx context.Context, args struct{ Org graphql.ID }) ([]*folderResolver, error) {
+	orgID, err := parseOrgID(args.Org)
+	if err != nil {
+		return nil, err
+	}
+	index, err := loaderFrom(ctx).org(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return resolvers(index.all), nil
+}
+
+func (r *resolver) MoveFolder(ctx context.Context, args struct {
+	Org         graphql.ID
+	Path        string
+	Destination string
+}) (*folderResolver, error) {
+	orgID, err := parseOrgID(args.Org)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	_, err = r.driver.MoveFolderByPath(ctx, orgID, args.Path, args.Destination)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	l := loaderFrom(ctx)
+	l.invalidate()
+	index, err := l.org(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &folderResolver{index.byPath[folder.MovedPath(args.Path, args.Destination)]}, nil
+}
+
+// folderResolver resolves the fields of a Folder.
+type folderResolver struct {
+	n *node
+}
+
+func (f *folderResolver) Name() string {
+	return f.n.name
+}
+
+func (f *folderResolver) Path() string {
+	return f.n.path
+}
+
+func (f *folderResolver) Org() graphql.ID {
+	return graphql.ID(f.n.org.String())
+}
+
+func (f *folderResolver) Parent() *folderResolver {
+	if f.n.parent == nil {
+		return nil
+	}
+	return &folderResolver{f.n.parent}
+}
+
+func (f *folderResolver) Children(args struct{ Depth int32 }) []*folderResolver {
+	var res []*node
+	var visit func(n *node, depth int32)
+	visit = func(n *node, depth int32) {
+		if depth > args.Depth {
+			return
+		}
+		for _, child := range n.children {
+			res = append(res, child)
+			visit(child, depth+1)
+		}
+	}
+	visit(f.n, 1)
+	return resolvers(res)
+}
+
+func (f *folderResolver) Descendants(args struct {
+	First *int32
+	After *string
+}) (*connectionResolver, error) {
+	var all []*node
+	var visit func(n *node)
+	visit = func(n *node) {
+		for _, child := range n.children {
+			all = append(all, child)
+			visit(child)
+		}
+	}
+	visit(f.n)
+
+	start := 0
+	if args.After != nil {
+		after, err := base64.StdEncoding.DecodeString(*args.After)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor '%s'", *args.After)
+		}
+		start = -1
+		for i, n := range all {
+			if n.path == string(after) {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("cursor '%s' is not a descendant of '%s'", *args.After, f.n.path)
+		}
+	}
+
+	end := len(all)
+	if args.First != nil {
+		if *args.First < 0 {
+			return nil, fmt.Errorf("first must not be negative")
+		}
+		end = min(start+int(*args.First), len(all))
+	}
+
+	return &connectionResolver{total: len(all), page: all[start:end], hasNext: end < len(all)}, nil
+}
+
+// connectionResolver resolves a page of descendants.
+type connectionResolver struct {
+	total   int
+	page    []*node
+	hasNext bool
+}
+
+func (c *connectionResolver) TotalCount() int32 {
+	return int32(c.total)
+}
+
+func (c *connectionResolver) Edges() []*edgeResolver {
+	res := make([]*edgeResolver, len(c.page))
+	for i, n := range c.page {
+		res[i] = &edgeResolver{n}
+	}
+	return res
+}
+
+func (c *connectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNext: c.hasNext}
+	if len(c.page) > 0 {
+		cursor := cursorOf(c.page[len(c.page)-1])
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+type edgeResolver struct {
+	n *node
+}
+
+func (e *edgeResolver) Cursor() string {
+	return cursorOf(e.n)
+}
+
+func (e *edgeResolver) Node() *folderResolver {
+	return &folderResolver{e.n}
+}
+
+type pageInfoResolver struct {
+	hasNext   bool
+	endCursor *string
+}
+
+func (p *pageInfoResolver) HasNextPage() bool {
+	return p.hasNext
+}
+
+func (p *pageInfoResolver) EndCursor() *string {
+	return p.endCursor
+}
+
+// cursorOf returns the opaque pagination cursor of a folder.
+func cursorOf(n *node) string {
+	return base64.StdEncoding.EncodeToString([]byte(n.path))
+}
+
+func resolvers(nodes []*node) []*folderResolver {
+	res := make([]*folderResolver, len(nodes))
+	for i, n := range nodes {
+		res[i] = &folderResolver{n}
+	}
+	return res
+}
+
+func parseOrgID(id graphql.ID) (uuid.UUID, error) {
+	orgID, err := uuid.FromString(string(id))
+	if err != nil || orgID == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("invalid org ID '%s'", id)
+	}
+	return orgID, nil
+}
@@ -0,0 +1,205 @@
+package graphqlapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/graphqlapi"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	orgID1 = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+	orgID2 = "38b9879b-f73b-4b0e-b9d9-4fc4c23643a7"
+)
+
+// countingDriver counts the calls to GetFoldersByOrgID, each of which traverses every folder.
+type countingDriver struct {
+	folder.IDriver
+	calls int
+}
+
+func (d *countingDriver) GetFoldersByOrgID(ctx context.Context, orgID uuid.UUID) ([]*folder.Folder, error) {
+	d.calls++
+	return d.IDriver.GetFoldersByOrgID(ctx, orgID)
+}
+
+// newTestServer serves GraphQL over a small linked folder tree in two organizations.
+func newTestServer(t *testing.T) (*httptest.Server, *countingDriver) {
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+		{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "alpha.bravo"},
+		{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "alpha.bravo.charlie"},
+		{"name": "delta", "org_id": "` + orgID1 + `", "paths": "alpha.delta"},
+		{"name": "echo", "org_id": "` + orgID1 + `", "paths": "alpha.delta.echo"},
+		{"name": "foxtrot", "org_id": "` + orgID2 + `", "paths": "foxtrot"}
+	]`))
+	assert.NoError(t, err)
+
+	driver := &countingDriver{IDriver: folder.NewDriver(folders)}
+	srv := httptest.NewServer(graphqlapi.NewHandler(driver, &sync.RWMutex{}))
+	t.Cleanup(srv.Close)
+	return srv, driver
+}
+
+// query posts a GraphQL query and returns the raw JSON response.
+func query(t *testing.T, srv *httptest.Server, q string, variables map[string]interface{}) string {
+	body, err := json.Marshal(map[string]interface{}{"query": q, "variables": variables})
+	assert.NoError(t, err)
+
+	resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+// Test_graphqlapi_Queries tests folder queries with nested fields and pagination.
+func Test_graphqlapi_Queries(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		want      string
+	}{
+		{
+			name: "Folder with its ancestors and two levels of children",
+			query: `query($org: ID!) {
+				folder(org: $org, path: "alpha.bravo.charlie") {
+					name
+					parent { name parent { name parent { name } } }
+				}
+				root: folder(org: $org, path: "alpha") {
+					children(depth: 2) { path }
+					direct: children { path }
+				}
+			}`,
+			variables: map[string]interface{}{"org": orgID1},
+			want: `{"data": {
+				"folder": {"name": "charlie", "parent": {"name": "bravo", "parent": {"name": "alpha", "parent": null}}},
+				"root": {
+					"children": [{"path": "alpha.bravo"}, {"path": "alpha.bravo.charlie"}, {"path": "alpha.delta"}, {"path": "alpha.delta.echo"}],
+					"direct": [{"path": "alpha.bravo"}, {"path": "alpha.delta"}]
+				}
+			}}`,
+		},
+		{
+			name:      "Folder that does not exist",
+			query:     `query($org: ID!) { folder(org: $org, path: "alpha") { name } }`,
+			variables: map[string]interface{}{"org": orgID2},
+			want:      `{"data": {"folder": null}}`,
+		},
+		{
+			name:      "Folders of an organization",
+			query:     `query($org: ID!) { folders(org: $org) { path org } }`,
+			variables: map[string]interface{}{"org": orgID2},
+			want:      `{"data": {"folders": [{"path": "foxtrot", "org": "` + orgID2 + `"}]}}`,
+		},
+		{
+			name: "First page of descendants",
+			query: `query($org: ID!) { folder(org: $org, path: "alpha") {
+				descendants(first: 2) { totalCount edges { node { path } } pageInfo { hasNextPage endCursor } }
+			} }`,
+			variables: map[string]interface{}{"org": orgID1},
+			want: `{"data": {"folder": {"descendants": {
+				"totalCount": 4,
+				"edges": [{"node": {"path": "alpha.bravo"}}, {"node": {"path": "alpha.bravo.charlie"}}],
+				"pageInfo": {"hasNextPage": true, "endCursor": "YWxwaGEuYnJhdm8uY2hhcmxpZQ=="}
+			}}}}`,
+		},
+		{
+			name: "Last page of descendants",
+			query: `query($org: ID!) { folder(org: $org, path: "alpha") {
+				descendants(first: 5, after: "YWxwaGEuYnJhdm8uY2hhcmxpZQ==") { edges { cursor node { path } } pageInfo { hasNextPage } }
+			} }`,
+			variables: map[string]interface{}{"org": orgID1},
+			want: `{"data": {"folder": {"descendants": {
+				"edges": [{"cursor": "YWxwaGEuZGVsdGE=", "node": {"path": "alpha.delta"}}, {"cursor": "YWxwaGEuZGVsdGEuZWNobw==", "node": {"path": "alpha.delta.echo"}}],
+				"pageInfo": {"hasNextPage": false}
+			}}}}`,
+		},
+		{
+			name:  "Invalid org ID",
+			query: `{ folders(org: "not-a-uuid") { path } }`,
+			want:  `{"errors": [{"message": "invalid org ID 'not-a-uuid'", "path": ["folders"]}], "data": null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, _ := newTestServer(t)
+			assert.JSONEq(t, tt.want, query(t, srv, tt.query, tt.variables))
+		})
+	}
+}
+
+// Test_graphqlapi_Batching tests that nested fields don't traverse the driver once per folder.
+func Test_graphqlapi_Batching(t *testing.T) {
+	t.Parallel()
+	srv, driver := newTestServer(t)
+
+	query(t, srv, `query($org: ID!) {
+		folders(org: $org) { parent { name } children(depth: 3) { parent { name } children { name } } }
+		folder(org: $org, path: "alpha") { descendants { totalCount } }
+	}`, map[string]interface{}{"org": orgID1})
+
+	assert.Equal(t, 1, driver.calls, "one organization should be loaded once per request")
+}
+
+// Test_graphqlapi_MoveFolder tests the moveFolder mutation and its errors.
+func Test_graphqlapi_MoveFolder(t *testing.T) {
+	t.Parallel()
+	srv, _ := newTestServer(t)
+	vars := map[string]interface{}{"org": orgID1}
+
+	got := query(t, srv, `mutation($org: ID!) {
+		moveFolder(org: $org, path: "alpha.bravo", destination: "alpha.delta.echo") { path children { path } parent { path } }
+	}`, vars)
+	assert.JSONEq(t, `{"data": {"moveFolder": {
+		"path": "alpha.delta.echo.bravo",
+		"children": [{"path": "alpha.delta.echo.bravo.charlie"}],
+		"parent": {"path": "alpha.delta.echo"}
+	}}}`, got)
+
+	got = query(t, srv, `mutation($org: ID!) { moveFolder(org: $org, path: "alpha.delta", destination: "alpha.delta.echo") { path } }`, vars)
+	assert.JSONEq(t, `{"errors": [{"message": "cannot move folder 'alpha.delta' to a child of itself", "path": ["moveFolder"]}], "data": null}`, got)
+
+	got = query(t, srv, `mutation($org: ID!) { moveFolder(org: $org, path: "alpha.bravo", destination: "alpha") { path } }`, vars)
+	assert.JSONEq(t, `{"errors": [{"message": "source folder 'alpha.bravo' does not exist", "path": ["moveFolder"]}], "data": null}`, got)
+}
+
+// Test_graphqlapi_MoveFolder_SameNames tests that moveFolder moves and returns the folder at the path when names repeat.
+func Test_graphqlapi_MoveFolder_SameNames(t *testing.T) {
+	t.Parallel()
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+		{"name": "x", "org_id": "` + orgID1 + `", "paths": "alpha.x"},
+		{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "bravo"},
+		{"name": "x", "org_id": "` + orgID1 + `", "paths": "bravo.x"},
+		{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "charlie"}
+	]`))
+	assert.NoError(t, err)
+	srv := httptest.NewServer(graphqlapi.NewHandler(folder.NewDriver(folders), &sync.RWMutex{}))
+	t.Cleanup(srv.Close)
+
+	got := query(t, srv, `mutation($org: ID!) {
+		moveFolder(org: $org, path: "bravo.x", destination: "charlie") { path parent { path } }
+	}`, map[string]interface{}{"org": orgID1})
+	assert.JSONEq(t, `{"data": {"moveFolder": {"path": "charlie.x", "parent": {"path": "charlie"}}}}`, got)
+	assert.Equal(t, "alpha.x", folders[1].Paths)
+	assert.Equal(t, "charlie.x", folders[3].Paths)
+}
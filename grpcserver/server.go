@@ -0,0 +1,250 @@
+// Package grpcserver implements the FolderService gRPC API on top of a folder driver.
+//
+// Calls identify their caller with the x-org-id metadata, and optionally x-user-id and x-request-id.
+// They are limited to the caller's organization, and their request ID prefixes the driver's logs.
+// The server doesn't verify this metadata: it must only be reachable through a proxy that authenticates
+// callers and sets x-org-id and x-user-id, overwriting any value sent by the caller.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/folderpb"
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys of the caller's identity. Every call must carry an organization, which it is limited to.
+const (
+	orgIDKey     = "x-org-id"
+	userIDKey    = "x-user-id"
+	requestIDKey = "x-request-id"
+)
+
+// Server serves a folder driver as a FolderService.
+type Server struct {
+	folderpb.UnimplementedFolderServiceServer
+
+	// mu guards driver, which is not safe for concurrent use.
+	mu     sync.RWMutex
+	driver folder.IDriver
+}
+
+func NewServer(driver folder.IDriver) *Server {
+	return &Server{driver: driver}
+}
+
+// GetFoldersByOrgID returns all folders that belong to a specific orgID.
+func (s *Server) GetFoldersByOrgID(ctx context.Context, req *folderpb.GetFoldersByOrgIDRequest) (*folderpb.GetFoldersByOrgIDResponse, error) {
+	ctx, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orgID, err := parseOrgID(req.GetOrgId())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	folders, err := s.driver.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &folderpb.GetFoldersByOrgIDResponse{Folders: toProto(folders)}, nil
+}
+
+// GetAllChildFolders returns all child folders of a specific folder.
+func (s *Server) GetAllChildFolders(ctx context.Context, req *folderpb.GetAllChildFoldersRequest) (*folderpb.GetAllChildFoldersResponse, error) {
+	ctx, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	children, err := s.childFolders(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &folderpb.GetAllChildFoldersResponse{Folders: toProto(children)}, nil
+}
+
+// ListChildren streams the child folders of a specific folder one message at a time.
+func (s *Server) ListChildren(req *folderpb.GetAllChildFoldersRequest, stream folderpb.FolderService_ListChildrenServer) error {
+	ctx, err := scope(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	children, err := s.childFolders(ctx, req)
+	if err != nil {
+		s.mu.RUnlock()
+		return err
+	}
+	// Copy the folders so the lock isn't held while a slow client receives them.
+	res := toProto(children)
+	s.mu.RUnlock()
+
+	for _, child := range res {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err := stream.Send(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveFolder moves a folder to a new destination, both found by path if path is set and by name otherwise.
+// Only folders of the caller's organization are moved or returned.
+func (s *Server) MoveFolder(ctx context.Context, req *folderpb.MoveFolderRequest) (*folderpb.MoveFolderResponse, error) {
+	ctx, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []*folder.Folder
+	if req.GetPath() != "" || req.GetDstPath() != "" {
+		orgID, err := parseOrgID(req.GetOrgId())
+		if err != nil {
+			return nil, err
+		}
+		if req.GetPath() == "" || req.GetDstPath() == "" {
+			return nil, status.Error(codes.InvalidArgument, "path and dst_path are required")
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		folders, err = s.driver.MoveFolderByPath(ctx, orgID, req.GetPath(), req.GetDstPath())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+	} else {
+		if req.GetName() == "" || req.GetDst() == "" {
+			return nil, status.Error(codes.InvalidArgument, "name and dst, or path and dst_path, are required")
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		folders, err = s.driver.MoveFolder(ctx, req.GetName(), req.GetDst())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return &folderpb.MoveFolderResponse{Folders: toProto(folders)}, nil
+}
+
+// childFolders validates a request and returns the child folders of an existing folder; s.mu must be held.
+// The folder is found by path if path is set, and otherwise by a name no other folder of the organization has.
+func (s *Server) childFolders(ctx context.Context, req *folderpb.GetAllChildFoldersRequest) ([]*folder.Folder, error) {
+	orgID, err := parseOrgID(req.GetOrgId())
+	if err != nil {
+		return nil, err
+	}
+
+	path := req.GetPath()
+	if path == "" {
+		if req.GetName() == "" {
+			return nil, status.Error(codes.InvalidArgument, "name or path is required")
+		}
+
+		// Names may be shared, so list the children of the only folder with that name.
+		folders, err := s.driver.GetFoldersByOrgID(ctx, orgID)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		var matches []string
+		for _, f := range folders {
+			if f.Name == req.GetName() {
+				matches = append(matches, f.Paths)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, status.Errorf(codes.NotFound, "folder '%s' does not exist in organization '%s'", req.GetName(), orgID)
+		case 1:
+			path = matches[0]
+		default:
+			return nil, status.Errorf(codes.FailedPrecondition, "folder name '%s' is shared by %d folders, list them by path", req.GetName(), len(matches))
+		}
+	}
+
+	children, err := s.driver.GetAllChildFoldersByPath(ctx, orgID, path)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return children, nil
+}
+
+// scope returns ctx with the principal and request ID given by the call's metadata,
+// or an UNAUTHENTICATED status if it has no valid organization. A request ID is generated if none is given.
+func scope(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	org := first(md, orgIDKey)
+	orgID, err := uuid.FromString(org)
+	if err != nil || orgID == uuid.Nil {
+		return nil, status.Errorf(codes.Unauthenticated, "missing or invalid %s '%s'", orgIDKey, org)
+	}
+
+	requestID := first(md, requestIDKey)
+	if requestID == "" {
+		requestID = uuid.Must(uuid.NewV4()).String()
+	}
+	ctx = folder.WithRequestID(ctx, requestID)
+	return folder.WithPrincipal(ctx, folder.Principal{ID: first(md, userIDKey), OrgID: orgID}), nil
+}
+
+// first returns the first value of a metadata key, or "".
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// parseOrgID parses an org ID, returning an INVALID_ARGUMENT status if it is malformed.
+func parseOrgID(value string) (uuid.UUID, error) {
+	orgID, err := uuid.FromString(value)
+	if err != nil || orgID == uuid.Nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid org ID '%s'", value)
+	}
+	return orgID, nil
+}
+
+// toStatus maps the driver's error kinds to gRPC status codes.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, folder.ErrFolderNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, folder.ErrInvalidMove):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, folder.ErrFolderExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, folder.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toProto(folders []*folder.Folder) []*folderpb.Folder {
+	res := make([]*folderpb.Folder, len(folders))
+	for i, f := range folders {
+		res[i] = &folderpb.Folder{Name: f.Name, OrgId: f.OrgId.String(), Paths: f.Paths}
+	}
+	return res
+}
@@ -0,0 +1,248 @@
+package grpcserver_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/folderpb"
+	"github.com/georgechieng-sc/interns-2022/grpcserver"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	orgID1 = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+	orgID2 = "38b9879b-f73b-4b0e-b9d9-4fc4c23643a7"
+)
+
+// newClient starts an in-process server over a small folder tree and returns a client connected through bufconn.
+func newClient(t *testing.T) folderpb.FolderServiceClient {
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+		{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "alpha.bravo"},
+		{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "alpha.bravo.charlie"},
+		{"name": "delta", "org_id": "` + orgID1 + `", "paths": "alpha.delta"},
+		{"name": "foxtrot", "org_id": "` + orgID2 + `", "paths": "foxtrot"}
+	]`))
+	assert.NoError(t, err)
+	return newServer(t, folder.NewDriver(folders))
+}
+
+// newServer starts an in-process server over a driver and returns a client connected through bufconn.
+func newServer(t *testing.T, driver folder.IDriver) folderpb.FolderServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	folderpb.RegisterFolderServiceServer(srv, grpcserver.NewServer(driver))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return folderpb.NewFolderServiceClient(conn)
+}
+
+// orgContext returns a context calling as a user of an organization.
+func orgContext(orgID string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-org-id", orgID, "x-user-id", "alice")
+}
+
+// paths returns the paths of the folders in a response.
+func paths(folders []*folderpb.Folder) []string {
+	res := []string{}
+	for _, f := range folders {
+		res = append(res, f.GetPaths())
+	}
+	return res
+}
+
+// Test_grpcserver_Queries tests GetFoldersByOrgID, GetAllChildFolders and ListChildren.
+func Test_grpcserver_Queries(t *testing.T) {
+	t.Parallel()
+	client := newClient(t)
+	ctx := orgContext(orgID1)
+
+	orgFolders, err := client.GetFoldersByOrgID(orgContext(orgID2), &folderpb.GetFoldersByOrgIDRequest{OrgId: orgID2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foxtrot"}, paths(orgFolders.GetFolders()))
+
+	children, err := client.GetAllChildFolders(ctx, &folderpb.GetAllChildFoldersRequest{OrgId: orgID1, Name: "alpha"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha.bravo", "alpha.bravo.charlie", "alpha.delta"}, paths(children.GetFolders()))
+
+	stream, err := client.ListChildren(ctx, &folderpb.GetAllChildFoldersRequest{OrgId: orgID1, Name: "alpha"})
+	assert.NoError(t, err)
+	var streamed []*folderpb.Folder
+	for {
+		f, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		streamed = append(streamed, f)
+	}
+	assert.Equal(t, paths(children.GetFolders()), paths(streamed))
+}
+
+// Test_grpcserver_Errors tests the mapping of invalid requests and driver errors to status codes.
+func Test_grpcserver_Errors(t *testing.T) {
+	t.Parallel()
+	client := newClient(t)
+	ctx := orgContext(orgID1)
+
+	tests := [...]struct {
+		name     string
+		call     func() error
+		wantCode codes.Code
+		wantMsg  string
+	}{
+		{
+			name: "Invalid org ID",
+			call: func() error {
+				_, err := client.GetFoldersByOrgID(ctx, &folderpb.GetFoldersByOrgIDRequest{OrgId: "not-a-uuid"})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+			wantMsg:  "invalid org ID 'not-a-uuid'",
+		},
+		{
+			name: "No organization in the metadata",
+			call: func() error {
+				_, err := client.GetFoldersByOrgID(context.Background(), &folderpb.GetFoldersByOrgIDRequest{OrgId: orgID1})
+				return err
+			},
+			wantCode: codes.Unauthenticated,
+			wantMsg:  "missing or invalid x-org-id ''",
+		},
+		{
+			name: "Folders of another organization",
+			call: func() error {
+				_, err := client.GetFoldersByOrgID(ctx, &folderpb.GetFoldersByOrgIDRequest{OrgId: orgID2})
+				return err
+			},
+			wantCode: codes.PermissionDenied,
+			wantMsg:  "'alice' may not access orgID '" + orgID2 + "'",
+		},
+		{
+			name: "Children of a folder in another organization",
+			call: func() error {
+				_, err := client.GetAllChildFolders(orgContext(orgID2), &folderpb.GetAllChildFoldersRequest{OrgId: orgID2, Name: "alpha"})
+				return err
+			},
+			wantCode: codes.NotFound,
+			wantMsg:  "folder 'alpha' does not exist in organization '" + orgID2 + "'",
+		},
+		{
+			name: "Streamed children without a name",
+			call: func() error {
+				stream, err := client.ListChildren(ctx, &folderpb.GetAllChildFoldersRequest{OrgId: orgID1})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+			wantMsg:  "name or path is required",
+		},
+		{
+			name: "Move a folder that does not exist",
+			call: func() error {
+				_, err := client.MoveFolder(ctx, &folderpb.MoveFolderRequest{Name: "echo", Dst: "delta"})
+				return err
+			},
+			wantCode: codes.NotFound,
+			wantMsg:  "source folder 'echo' does not exist",
+		},
+		{
+			name: "Move to a child of itself",
+			call: func() error {
+				_, err := client.MoveFolder(ctx, &folderpb.MoveFolderRequest{Name: "bravo", Dst: "charlie"})
+				return err
+			},
+			wantCode: codes.FailedPrecondition,
+			wantMsg:  "cannot move folder 'bravo' to a child of itself",
+		},
+		{
+			// Folders of other organizations can't be found
+			name: "Move to a different organization",
+			call: func() error {
+				_, err := client.MoveFolder(ctx, &folderpb.MoveFolderRequest{Name: "bravo", Dst: "foxtrot"})
+				return err
+			},
+			wantCode: codes.NotFound,
+			wantMsg:  "destination folder 'foxtrot' does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			st, ok := status.FromError(tt.call())
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantCode, st.Code())
+			assert.Equal(t, tt.wantMsg, st.Message())
+		})
+	}
+}
+
+// Test_grpcserver_MoveFolder tests that a move is visible to later calls.
+func Test_grpcserver_MoveFolder(t *testing.T) {
+	t.Parallel()
+	client := newClient(t)
+	ctx := orgContext(orgID1)
+
+	moved, err := client.MoveFolder(ctx, &folderpb.MoveFolderRequest{Name: "bravo", Dst: "delta"})
+	assert.NoError(t, err)
+	assert.NotContains(t, paths(moved.GetFolders()), "foxtrot", "folders of other organizations should not be returned")
+
+	children, err := client.GetAllChildFolders(ctx, &folderpb.GetAllChildFoldersRequest{OrgId: orgID1, Name: "delta"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha.delta.bravo", "alpha.delta.bravo.charlie"}, paths(children.GetFolders()))
+}
+
+// Test_grpcserver_SameNames tests that folders sharing a name are listed and moved by path.
+func Test_grpcserver_SameNames(t *testing.T) {
+	t.Parallel()
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+		{"name": "x", "org_id": "` + orgID1 + `", "paths": "alpha.x"},
+		{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "bravo"},
+		{"name": "x", "org_id": "` + orgID1 + `", "paths": "bravo.x"},
+		{"name": "y", "org_id": "` + orgID1 + `", "paths": "bravo.x.y"}
+	]`))
+	assert.NoError(t, err)
+	client := newServer(t, folder.NewDriver(folders))
+	ctx := orgContext(orgID1)
+
+	_, err = client.GetAllChildFolders(ctx, &folderpb.GetAllChildFoldersRequest{OrgId: orgID1, Name: "x"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = client.MoveFolder(ctx, &folderpb.MoveFolderRequest{Name: "x", Dst: "alpha"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	children, err := client.GetAllChildFolders(ctx, &folderpb.GetAllChildFoldersRequest{OrgId: orgID1, Path: "bravo.x"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bravo.x.y"}, paths(children.GetFolders()))
+
+	_, err = client.MoveFolder(ctx, &folderpb.MoveFolderRequest{OrgId: orgID1, Path: "bravo.x", DstPath: "alpha"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.MoveFolder(ctx, &folderpb.MoveFolderRequest{OrgId: orgID1, Path: "bravo.x"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	moved, err := client.MoveFolder(ctx, &folderpb.MoveFolderRequest{OrgId: orgID1, Path: "bravo.x", DstPath: "alpha.x"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alpha", "alpha.x", "alpha.x.x", "alpha.x.x.y", "bravo"}, paths(moved.GetFolders()))
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 
 	"github.com/georgechieng-sc/interns-2022/folder"
 	"github.com/gofrs/uuid"
@@ -14,7 +16,10 @@ func main() {
 
 	// example usage
 	folderDriver := folder.NewDriver(res)
-	orgFolder := folderDriver.GetFoldersByOrgID(orgID)
+	orgFolder, err := folderDriver.GetFoldersByOrgID(context.Background(), orgID)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	folder.PrettyPrint(res)
 	fmt.Printf("\n Folders for orgID: %s", orgID)
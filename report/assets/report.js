@@ -0,0 +1,49 @@
+(function () {
+  var items = Array.prototype.slice.call(document.querySelectorAll(".tree li"));
+
+  // Shows the folders whose name or path contains the query, with their ancestors.
+  function search(query) {
+    query = query.trim().toLowerCase();
+    items.forEach(function (li) {
+      li.classList.remove("match", "hidden");
+    });
+    if (query === "") {
+      return;
+    }
+
+    items.forEach(function (li) {
+      li.classList.add("hidden");
+    });
+    items.forEach(function (li) {
+      var name = li.dataset.name.toLowerCase();
+      var path = li.dataset.path.toLowerCase();
+      if (name.indexOf(query) < 0 && path.indexOf(query) < 0) {
+        return;
+      }
+      li.classList.add("match");
+      for (var el = li; el && el.tagName; el = el.parentElement) {
+        if (el.tagName === "LI") {
+          el.classList.remove("hidden");
+        } else if (el.tagName === "DETAILS") {
+          el.open = true;
+        }
+      }
+    });
+  }
+
+  function setOpen(open) {
+    document.querySelectorAll(".tree details").forEach(function (d) {
+      d.open = open;
+    });
+  }
+
+  document.getElementById("search").addEventListener("input", function (e) {
+    search(e.target.value);
+  });
+  document.getElementById("expand").addEventListener("click", function () {
+    setOpen(true);
+  });
+  document.getElementById("collapse").addEventListener("click", function () {
+    setOpen(false);
+  });
+})();
@@ -0,0 +1,109 @@
+// Package report renders an organization's folders as a self-contained HTML page.
+package report
+
+import (
+	"context"
+	"embed"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+)
+
+//go:embed assets
+var assets embed.FS
+
+var page = template.Must(template.New("report.html.tmpl").ParseFS(assets, "assets/report.html.tmpl"))
+
+// style and script are inlined into every report, so it can be viewed without network access.
+var (
+	style  = template.CSS(mustReadAsset("assets/report.css"))
+	script = template.JS(mustReadAsset("assets/report.js"))
+)
+
+func mustReadAsset(name string) string {
+	data, err := assets.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// Options configures Write.
+type Options struct {
+	// Title defaults to "Folders".
+	Title string
+	// Generated is the time shown in the footer; it defaults to the current time.
+	Generated time.Time
+}
+
+// node is a folder as shown in the report.
+type node struct {
+	Name        string
+	Path        string
+	Descendants int
+	Children    []*node
+}
+
+// pageData is the data of the report template.
+type pageData struct {
+	Title     string
+	OrgID     uuid.UUID
+	Folders   int
+	Roots     []*node
+	Generated time.Time
+	Style     template.CSS
+	Script    template.JS
+}
+
+// Write renders the folders of an organization as an HTML report with a collapsible tree,
+// a search box and the path and descendant count of every folder.
+func Write(ctx context.Context, w io.Writer, driver folder.IDriver, orgID uuid.UUID, opts Options) error {
+	if opts.Title == "" {
+		opts.Title = "Folders"
+	}
+	if opts.Generated.IsZero() {
+		opts.Generated = time.Now()
+	}
+
+	folders, err := driver.GetFoldersByOrgID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	trees, err := folder.ToTrees(folders)
+	if err != nil {
+		return err
+	}
+
+	data := pageData{
+		Title:     opts.Title,
+		OrgID:     orgID,
+		Folders:   len(folders),
+		Generated: opts.Generated.UTC(),
+		Style:     style,
+		Script:    script,
+	}
+	for _, tree := range trees {
+		for _, root := range tree.Folders {
+			data.Roots = append(data.Roots, toNode(root, ""))
+		}
+	}
+
+	return page.Execute(w, data)
+}
+
+// toNode converts a tree node under parentPath, counting its descendants.
+func toNode(treeNode *folder.TreeNode, parentPath string) *node {
+	n := &node{Name: treeNode.Name, Path: treeNode.Name}
+	if parentPath != "" {
+		n.Path = parentPath + "." + treeNode.Name
+	}
+	for _, child := range treeNode.Children {
+		c := toNode(child, n.Path)
+		n.Children = append(n.Children, c)
+		n.Descendants += 1 + c.Descendants
+	}
+	return n
+}
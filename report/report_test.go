@@ -0,0 +1,96 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/report"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	orgID1 = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
+	orgID2 = "38b9879b-f73b-4b0e-b9d9-4fc4c23643a7"
+)
+
+// Test_report_Write tests the content of the HTML report.
+func Test_report_Write(t *testing.T) {
+	t.Parallel()
+
+	folders, err := folder.LoadDataset([]byte(`[
+		{"name": "alpha", "org_id": "` + orgID1 + `", "paths": "alpha"},
+		{"name": "bravo", "org_id": "` + orgID1 + `", "paths": "alpha.bravo"},
+		{"name": "charlie", "org_id": "` + orgID1 + `", "paths": "alpha.bravo.charlie"},
+		{"name": "delta", "org_id": "` + orgID1 + `", "paths": "alpha.delta"},
+		{"name": "foxtrot", "org_id": "` + orgID2 + `", "paths": "foxtrot"}
+	]`))
+	assert.NoError(t, err)
+	driver := folder.NewDriver(folders)
+	generated := time.Date(2022, 11, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := [...]struct {
+		name        string
+		orgID       string
+		opts        report.Options
+		wantContain []string
+		wantMissing []string
+	}{
+		{
+			name:  "tree with paths and counts",
+			orgID: orgID1,
+			opts:  report.Options{Generated: generated},
+			wantContain: []string{
+				"<title>Folders</title>",
+				"<code>" + orgID1 + "</code>, 4 folders",
+				`<summary><span class="name">alpha</span> <span class="count">3</span> <code class="path">alpha</code></summary>`,
+				`<summary><span class="name">bravo</span> <span class="count">1</span> <code class="path">alpha.bravo</code></summary>`,
+				`<li data-name="charlie" data-path="alpha.bravo.charlie">`,
+				`<input id="search" type="search"`,
+				"Generated 2022-11-01 09:30 UTC",
+			},
+			wantMissing: []string{"foxtrot", "No folders."},
+		},
+		{
+			// The title is escaped
+			name:        "custom title",
+			orgID:       orgID2,
+			opts:        report.Options{Title: "<Acme> folders", Generated: generated},
+			wantContain: []string{"<title>&lt;Acme&gt; folders</title>", `<li data-name="foxtrot" data-path="foxtrot">`},
+		},
+		{
+			name:        "organization without folders",
+			orgID:       "00000000-0000-0000-0000-000000000001",
+			opts:        report.Options{Generated: generated},
+			wantContain: []string{"0 folders", "No folders."},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			assert.NoError(t, report.Write(context.Background(), &buf, driver, uuid.FromStringOrNil(tt.orgID), tt.opts))
+			html := buf.String()
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, html, want)
+			}
+			for _, missing := range tt.wantMissing {
+				assert.NotContains(t, html, missing)
+			}
+
+			// The page is self-contained: styles and scripts are inline, nothing is fetched
+			assert.Contains(t, html, "<style>body {")
+			assert.Contains(t, html, `document.getElementById("search")`)
+			for _, external := range []string{"http://", "https://", " src=", " href="} {
+				assert.False(t, strings.Contains(html, external), "report references %q", external)
+			}
+		})
+	}
+}
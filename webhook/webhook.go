@@ -0,0 +1,420 @@
+// Package webhook delivers folder change events to customers' HTTP endpoints.
+//
+// Every delivery is a POST of a JSON Payload, signed with the endpoint's secret: the X-Folder-Signature
+// header is "sha256=" followed by the hex HMAC-SHA256 of the body. Failed deliveries are retried with
+// exponential backoff, and the deliveries of an endpoint are made in order. The queue of pending deliveries
+// can be persisted to a file, so they survive restarts.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+)
+
+// Headers of every delivery.
+const (
+	EventHeader     = "X-Folder-Event"
+	DeliveryHeader  = "X-Folder-Delivery"
+	SignatureHeader = "X-Folder-Signature"
+)
+
+// Endpoint is a customer's URL receiving the events of an organization.
+type Endpoint struct {
+	ID     string    `json:"id"`
+	OrgID  uuid.UUID `json:"org_id"`
+	URL    string    `json:"url"`
+	Secret string    `json:"secret"`
+}
+
+// Payload is the body of a delivery. Path is set for folder.created, Changes for folder.moved and Paths for folder.deleted,
+// folder.restored and folder.purged. folder.copied sets Path to the source and Paths to the copies. folder.transferred sets
+// SrcOrgID, DstOrgID and Changes, and is delivered to the endpoints of both organizations.
+type Payload struct {
+	Type      string     `json:"type"`
+	OrgID     uuid.UUID  `json:"org_id"`
+	Seq       uint64     `json:"seq"`
+	Time      time.Time  `json:"time"`
+	Actor     string     `json:"actor,omitempty"`
+	RequestID string     `json:"request_id,omitempty"`
+	Path      string     `json:"path,omitempty"`
+	Changes   []Change   `json:"changes,omitempty"`
+	Paths     []string   `json:"paths,omitempty"`
+	SrcOrgID  *uuid.UUID `json:"src_org_id,omitempty"`
+	DstOrgID  *uuid.UUID `json:"dst_org_id,omitempty"`
+}
+
+// Change is the old and new path of a moved folder.
+type Change struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// NewPayload returns the payload of an event.
+func NewPayload(e folder.Event) Payload {
+	meta := e.Meta()
+	p := Payload{OrgID: meta.OrgID, Seq: meta.Seq, Time: meta.Time, Actor: meta.Actor, RequestID: meta.RequestID}
+	switch e := e.(type) {
+	case *folder.FolderCreated:
+		p.Type, p.Path = "folder.created", e.Path
+	case *folder.FolderMoved:
+		p.Type = "folder.moved"
+		for _, c := range e.Changes {
+			p.Changes = append(p.Changes, Change{Old: c.Old, New: c.New})
+		}
+	case *folder.FolderDeleted:
+		p.Type, p.Paths = "folder.deleted", e.Paths
+	case *folder.FolderRestored:
+		p.Type, p.Paths = "folder.restored", e.Paths
+	case *folder.FolderPurged:
+		p.Type, p.Paths = "folder.purged", e.Paths
+	case *folder.FolderCopied:
+		p.Type, p.Path, p.Paths = "folder.copied", e.Source, e.Paths
+	case *folder.FolderTransferred:
+		p.Type, p.SrcOrgID, p.DstOrgID = "folder.transferred", &e.SrcOrgID, &e.DstOrgID
+		for _, c := range e.Changes {
+			p.Changes = append(p.Changes, Change{Old: c.Old, New: c.New})
+		}
+	}
+	return p
+}
+
+// Sign returns the signature of a body, as sent in the SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether a signature of a body is valid, in constant time.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Backoff is the retry schedule of failed deliveries: the nth retry waits Initial * 2^(n-1), at most Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	// Attempts is the number of attempts after which a delivery is given up.
+	Attempts int
+}
+
+// DefaultBackoff retries for about an hour.
+var DefaultBackoff = Backoff{Initial: time.Second, Max: 10 * time.Minute, Attempts: 12}
+
+// delay returns the wait after the given number of failed attempts.
+func (b Backoff) delay(attempts int) time.Duration {
+	d := b.Initial
+	for i := 1; i < attempts && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		return b.Max
+	}
+	return d
+}
+
+// Options configures a dispatcher.
+type Options struct {
+	// Client sends the deliveries; it defaults to a client with a 10 second timeout.
+	Client *http.Client
+	// QueueFile persists the pending deliveries, if set.
+	QueueFile string
+	// Backoff defaults to DefaultBackoff.
+	Backoff Backoff
+}
+
+// delivery is a pending delivery of a payload to an endpoint.
+type delivery struct {
+	ID          string          `json:"id"`
+	EndpointID  string          `json:"endpoint_id"`
+	Type        string          `json:"type"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt"`
+}
+
+// Dispatcher delivers the events of a subscription to the endpoints of their organization.
+type Dispatcher struct {
+	endpoints map[string]Endpoint
+	opts      Options
+
+	mu     sync.Mutex
+	queue  []*delivery
+	failed int
+	wake   chan struct{}
+}
+
+// NewDispatcher returns a dispatcher to endpoints, resuming the deliveries pending in opts.QueueFile.
+func NewDispatcher(endpoints []Endpoint, opts Options) (*Dispatcher, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Backoff == (Backoff{}) {
+		opts.Backoff = DefaultBackoff
+	}
+
+	d := &Dispatcher{endpoints: make(map[string]Endpoint), opts: opts, wake: make(chan struct{}, 1)}
+	for _, e := range endpoints {
+		if _, exists := d.endpoints[e.ID]; exists {
+			return nil, fmt.Errorf("duplicate webhook endpoint '%s'", e.ID)
+		}
+		d.endpoints[e.ID] = e
+	}
+
+	if opts.QueueFile != "" {
+		data, err := os.ReadFile(opts.QueueFile)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &d.queue); err != nil {
+				return nil, fmt.Errorf("webhook queue '%s': %w", opts.QueueFile, err)
+			}
+		}
+	}
+	return d, nil
+}
+
+// Pending returns the number of deliveries waiting to be made.
+func (d *Dispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queue)
+}
+
+// Failed returns the number of deliveries given up after the last attempt, or whose endpoint was removed.
+func (d *Dispatcher) Failed() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.failed
+}
+
+// Run queues the events of sub and makes the deliveries until ctx is done or sub is closed.
+// Deliveries still pending then are kept in the queue file, if there is one.
+// Events that can't be queued are logged, and don't stop the deliveries of the next ones.
+// Run closes sub when it returns, so mutations never wait on a stopped dispatcher, even with OverflowBlock.
+func (d *Dispatcher) Run(ctx context.Context, sub *folder.Subscription) error {
+	defer sub.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		d.send(ctx)
+	}()
+	// Stop the sender before waiting for it, whatever made Run return
+	defer func() {
+		cancel()
+		<-sent
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				return sub.Err()
+			}
+			if err := d.Enqueue(e); err != nil {
+				log.Printf("Error: Failed to queue webhook deliveries of event %d: %v", e.Meta().Seq, err)
+			}
+		}
+	}
+}
+
+// Enqueue queues the deliveries of an event to the endpoints of its organization.
+// If the queue file can't be written, the deliveries are still made but the error is returned,
+// as a restart would lose them.
+func (d *Dispatcher) Enqueue(e folder.Event) error {
+	payload := NewPayload(e)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	queued := false
+	for _, endpoint := range d.endpoints {
+		if endpoint.OrgID != payload.OrgID {
+			continue
+		}
+		d.queue = append(d.queue, &delivery{
+			ID:          uuid.Must(uuid.NewV4()).String(),
+			EndpointID:  endpoint.ID,
+			Type:        payload.Type,
+			Body:        body,
+			NextAttempt: time.Now(),
+		})
+		queued = true
+	}
+	if !queued {
+		return nil
+	}
+	err = d.save()
+	d.notify()
+	return err
+}
+
+// notify wakes the sender up.
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// send makes the due deliveries until ctx is done, waiting for the next one in between.
+func (d *Dispatcher) send(ctx context.Context) {
+	for ctx.Err() == nil {
+		next, wait := d.due()
+		if next != nil {
+			d.attempt(ctx, next)
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// due returns the first due delivery that is the oldest of its endpoint, or how long to wait for one.
+func (d *Dispatcher) due() (*delivery, time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	wait := time.Hour
+	seen := make(map[string]bool)
+	for _, dl := range d.queue {
+		if seen[dl.EndpointID] {
+			continue
+		}
+		seen[dl.EndpointID] = true
+		if !dl.NextAttempt.After(now) {
+			return dl, 0
+		}
+		if w := dl.NextAttempt.Sub(now); w < wait {
+			wait = w
+		}
+	}
+	return nil, wait
+}
+
+// attempt makes a delivery, removing it from the queue on success or when it is given up.
+func (d *Dispatcher) attempt(ctx context.Context, dl *delivery) {
+	endpoint, exists := d.endpoints[dl.EndpointID]
+	var err error
+	if exists {
+		err = d.post(ctx, endpoint, dl)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dl.Attempts++
+	switch {
+	case !exists:
+		log.Printf("Error: Webhook endpoint '%s' of delivery %s no longer exists", dl.EndpointID, dl.ID)
+		d.failed++
+		d.remove(dl)
+	case err == nil:
+		d.remove(dl)
+	case dl.Attempts >= d.opts.Backoff.Attempts:
+		log.Printf("Error: Giving up webhook delivery %s to '%s' after %d attempts: %v", dl.ID, endpoint.ID, dl.Attempts, err)
+		d.failed++
+		d.remove(dl)
+	default:
+		log.Printf("Error: Webhook delivery %s to '%s' failed, attempt %d: %v", dl.ID, endpoint.ID, dl.Attempts, err)
+		dl.NextAttempt = time.Now().Add(d.opts.Backoff.delay(dl.Attempts))
+	}
+	if err := d.save(); err != nil {
+		log.Printf("Error: Failed to save webhook queue: %v", err)
+	}
+}
+
+// post sends a delivery, which succeeds with any 2xx status.
+func (d *Dispatcher) post(ctx context.Context, endpoint Endpoint, dl *delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(dl.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, dl.Type)
+	req.Header.Set(DeliveryHeader, dl.ID)
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, dl.Body))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// remove removes a delivery from the queue; d.mu must be held.
+func (d *Dispatcher) remove(dl *delivery) {
+	for i, other := range d.queue {
+		if other == dl {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// save writes the queue to the queue file, replacing it atomically; d.mu must be held.
+func (d *Dispatcher) save() error {
+	if d.opts.QueueFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(d.queue)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(d.opts.QueueFile), filepath.Base(d.opts.QueueFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Flush the queue to disk before it replaces the old one, so a crash doesn't leave an empty file.
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.opts.QueueFile)
+}
@@ -0,0 +1,277 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/georgechieng-sc/interns-2022/webhook"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "s3cret"
+
+// fastBackoff retries quickly, so tests don't wait.
+var fastBackoff = webhook.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Attempts: 3}
+
+// receiver is a webhook endpoint failing the first failures requests, and recording the others.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts []string // delivery IDs of every request
+	payloads []webhook.Payload
+	headers  []http.Header
+	badSigs  int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, req.Header.Get(webhook.DeliveryHeader))
+	if !webhook.Verify(secret, body, req.Header.Get(webhook.SignatureHeader)) {
+		r.badSigs++
+	}
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var p webhook.Payload
+	json.Unmarshal(body, &p)
+	r.payloads = append(r.payloads, p)
+	r.headers = append(r.headers, req.Header)
+}
+
+// received returns the payloads received so far.
+func (r *receiver) received() []webhook.Payload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]webhook.Payload(nil), r.payloads...)
+}
+
+// start serves a receiver and returns it with an endpoint of an organization.
+func start(t *testing.T, orgID uuid.UUID, failures int) (*receiver, webhook.Endpoint) {
+	r := &receiver{failures: failures}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, webhook.Endpoint{ID: "hook-" + orgID.String(), OrgID: orgID, URL: srv.URL, Secret: secret}
+}
+
+// run runs a dispatcher on the events of a driver until the test ends.
+func run(t *testing.T, d *webhook.Dispatcher, bus *folder.EventBus) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := bus.Subscribe(folder.SubscribeOptions{Overflow: folder.OverflowBlock})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, d.Run(ctx, sub))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		sub.Close()
+	})
+}
+
+// Test_webhook_Delivery tests that the events of an organization are delivered signed to its endpoints only.
+func Test_webhook_Delivery(t *testing.T) {
+	t.Parallel()
+	orgID1 := uuid.Must(uuid.NewV4())
+	orgID2 := uuid.Must(uuid.NewV4())
+	r1, endpoint1 := start(t, orgID1, 0)
+	r2, endpoint2 := start(t, orgID2, 0)
+
+	d, err := webhook.NewDispatcher([]webhook.Endpoint{endpoint1, endpoint2}, webhook.Options{Backoff: fastBackoff})
+	assert.NoError(t, err)
+	bus := folder.NewEventBus()
+	run(t, d, bus)
+
+	driver := folder.NewDriver(nil, folder.WithEventBus(bus))
+	ctx := folder.WithPrincipal(context.Background(), folder.Principal{ID: "alice", OrgID: orgID1})
+	_, err = driver.CreateFolder(ctx, orgID1, "alpha", "")
+	assert.NoError(t, err)
+	_, err = driver.CreateFolder(ctx, orgID1, "bravo", "")
+	assert.NoError(t, err)
+	_, err = driver.MoveFolder(ctx, "bravo", "alpha")
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return len(r1.received()) == 3 }, time.Second, time.Millisecond)
+	got := r1.received()
+	assert.Equal(t, "folder.moved", got[2].Type)
+	assert.Equal(t, uint64(3), got[2].Seq)
+	assert.Equal(t, "alice", got[2].Actor)
+	assert.Equal(t, []webhook.Change{{Old: "bravo", New: "alpha.bravo"}}, got[2].Changes)
+	assert.Equal(t, "folder.moved", r1.headers[2].Get(webhook.EventHeader))
+	assert.Zero(t, r1.badSigs)
+	assert.Empty(t, r2.received())
+	assert.Eventually(t, func() bool { return d.Pending() == 0 }, time.Second, time.Millisecond)
+}
+
+// Test_webhook_Retries tests that failed deliveries are retried in order, and given up after the last attempt.
+func Test_webhook_Retries(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.Must(uuid.NewV4())
+
+	tests := [...]struct {
+		name       string
+		failures   int
+		wantPaths  []string
+		wantFailed int
+	}{
+		{
+			// The second event waits for the first one
+			name:      "Retried in order",
+			failures:  2,
+			wantPaths: []string{"alpha", "bravo"},
+		},
+		{
+			name:       "Given up",
+			failures:   3,
+			wantPaths:  []string{"bravo"},
+			wantFailed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, endpoint := start(t, orgID, tt.failures)
+			d, err := webhook.NewDispatcher([]webhook.Endpoint{endpoint}, webhook.Options{Backoff: fastBackoff})
+			assert.NoError(t, err)
+			bus := folder.NewEventBus()
+			run(t, d, bus)
+
+			driver := folder.NewDriver(nil, folder.WithEventBus(bus))
+			for _, name := range []string{"alpha", "bravo"} {
+				_, err := driver.CreateFolder(context.Background(), orgID, name, "")
+				assert.NoError(t, err)
+			}
+
+			assert.Eventually(t, func() bool { return d.Pending() == 0 }, time.Second, time.Millisecond)
+			var paths []string
+			for _, p := range r.received() {
+				paths = append(paths, p.Path)
+			}
+			assert.Equal(t, tt.wantPaths, paths)
+			assert.Equal(t, tt.wantFailed, d.Failed())
+
+			// Retries keep the delivery ID
+			r.mu.Lock()
+			assert.Equal(t, r.attempts[0], r.attempts[tt.failures-1])
+			r.mu.Unlock()
+		})
+	}
+}
+
+// Test_webhook_QueueFile tests that pending deliveries are resumed by a new dispatcher.
+func Test_webhook_QueueFile(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.Must(uuid.NewV4())
+	r, endpoint := start(t, orgID, 0)
+	queueFile := filepath.Join(t.TempDir(), "queue.json")
+
+	// The first dispatcher stops before delivering
+	d, err := webhook.NewDispatcher([]webhook.Endpoint{endpoint}, webhook.Options{QueueFile: queueFile, Backoff: fastBackoff})
+	assert.NoError(t, err)
+	assert.NoError(t, d.Enqueue(&folder.FolderCreated{EventMeta: folder.EventMeta{OrgID: orgID, Seq: 1}, Path: "alpha"}))
+	assert.Equal(t, 1, d.Pending())
+
+	d, err = webhook.NewDispatcher([]webhook.Endpoint{endpoint}, webhook.Options{QueueFile: queueFile, Backoff: fastBackoff})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, d.Pending())
+	run(t, d, folder.NewEventBus())
+
+	assert.Eventually(t, func() bool { return len(r.received()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "alpha", r.received()[0].Path)
+	assert.Eventually(t, func() bool { return d.Pending() == 0 }, time.Second, time.Millisecond)
+
+	d, err = webhook.NewDispatcher([]webhook.Endpoint{endpoint}, webhook.Options{QueueFile: queueFile})
+	assert.NoError(t, err)
+	assert.Zero(t, d.Pending(), "delivered payloads should be removed from the file")
+}
+
+// Test_webhook_Run_Stopped tests that a stopped dispatcher doesn't block the mutations.
+func Test_webhook_Run_Stopped(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.Must(uuid.NewV4())
+	_, endpoint := start(t, orgID, 0)
+
+	d, err := webhook.NewDispatcher([]webhook.Endpoint{endpoint}, webhook.Options{Backoff: fastBackoff})
+	assert.NoError(t, err)
+	bus := folder.NewEventBus()
+	sub := bus.Subscribe(folder.SubscribeOptions{Buffer: 1, Overflow: folder.OverflowBlock})
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- d.Run(ctx, sub) }()
+	cancel()
+
+	driver := folder.NewDriver(nil, folder.WithEventBus(bus))
+	created := make(chan struct{})
+	go func() {
+		defer close(created)
+		for i := 0; i < 10; i++ {
+			_, err := driver.CreateFolder(context.Background(), orgID, fmt.Sprintf("f%d", i), "")
+			assert.NoError(t, err)
+		}
+	}()
+
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the dispatcher didn't stop")
+	}
+	select {
+	case <-created:
+	case <-time.After(time.Second):
+		t.Fatal("mutations blocked on the stopped dispatcher")
+	}
+}
+
+// Test_webhook_Run_SaveError tests that deliveries go on when the queue file can't be written.
+func Test_webhook_Run_SaveError(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.Must(uuid.NewV4())
+	r, endpoint := start(t, orgID, 0)
+	// The queue can't be saved in a missing directory
+	queueFile := filepath.Join(t.TempDir(), "missing", "queue.json")
+
+	d, err := webhook.NewDispatcher([]webhook.Endpoint{endpoint}, webhook.Options{QueueFile: queueFile, Backoff: fastBackoff})
+	assert.NoError(t, err)
+	assert.Error(t, d.Enqueue(&folder.FolderCreated{EventMeta: folder.EventMeta{OrgID: orgID, Seq: 1}, Path: "alpha"}))
+	bus := folder.NewEventBus()
+	run(t, d, bus)
+
+	driver := folder.NewDriver(nil, folder.WithEventBus(bus))
+	for i := 0; i < 3; i++ {
+		_, err := driver.CreateFolder(context.Background(), orgID, fmt.Sprintf("f%d", i), "")
+		assert.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool { return len(r.received()) == 4 }, time.Second, time.Millisecond)
+	assert.Equal(t, "f2", r.received()[3].Path)
+}
+
+// Test_webhook_Verify tests signature verification.
+func Test_webhook_Verify(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"type":"folder.created"}`)
+	signature := webhook.Sign(secret, body)
+
+	assert.True(t, webhook.Verify(secret, body, signature))
+	assert.False(t, webhook.Verify("other", body, signature))
+	assert.False(t, webhook.Verify(secret, []byte(`{}`), signature))
+}